@@ -2,23 +2,27 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/andre/project-app-bioskop-golang/db"
 	"github.com/andre/project-app-bioskop-golang/internal/config"
 	"github.com/andre/project-app-bioskop-golang/internal/handlers"
+	"github.com/andre/project-app-bioskop-golang/internal/health"
+	"github.com/andre/project-app-bioskop-golang/internal/metrics"
 	"github.com/andre/project-app-bioskop-golang/internal/middleware"
+	"github.com/andre/project-app-bioskop-golang/internal/migrations"
+	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/repositories"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
-	"github.com/jackc/pgx/v5"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
@@ -36,121 +40,236 @@ func main() {
 	logger.Info("Configuration loaded",
 		zap.String("server_port", cfg.Server.Port),
 		zap.String("server_env", cfg.Server.Env),
+		zap.Duration("booking_hold_ttl", cfg.Booking.HoldTTL),
 	)
 
+	// Export trace spans
+	spanExporter, err := tracing.NewExporter(cfg.Tracing.Exporter, cfg.Tracing.File)
+	if err != nil {
+		logger.Fatal("Failed to create trace exporter", zap.Error(err))
+	}
+	defer spanExporter.Close()
+	tracing.SetTracer(tracing.NewTracer(spanExporter))
+
 	// Connect to database
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-	)
+	poolCfg, err := cfg.Database.PoolConfig()
+	if err != nil {
+		logger.Fatal("Failed to parse database config", zap.Error(err))
+	}
+	poolCfg.ConnConfig.Tracer = repositories.QueryTracer{}
 
-	sqlDB, err := sql.Open("pgx", dbURL)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
-	defer sqlDB.Close()
+	defer pool.Close()
 
 	// Ping database
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	err = sqlDB.PingContext(ctx)
+	err = pool.Ping(ctx)
 	cancel()
 	if err != nil {
 		logger.Fatal("Failed to ping database", zap.Error(err))
 	}
-	logger.Info("Database connected successfully")
+	logger.Info("Database connected successfully", zap.Int32("max_conns", poolCfg.MaxConns))
 
-	// Create PGX connection pool for queries
-	pgxCfg, err := pgx.ParseConfig(dbURL)
+	// Refuse to serve until the schema matches this build
+	migrator, err := migrations.NewMigrator(pool, db.Migrations())
 	if err != nil {
-		logger.Fatal("Failed to parse database config", zap.Error(err))
+		logger.Fatal("Failed to load migrations", zap.Error(err))
 	}
-
-	conn, err := pgx.ConnectConfig(context.Background(), pgxCfg)
+	pending, err := migrator.Pending(context.Background())
 	if err != nil {
-		logger.Fatal("Failed to connect to database with pgx", zap.Error(err))
+		logger.Fatal("Failed to check migrations", zap.Error(err))
+	}
+	if len(pending) > 0 {
+		logger.Fatal("Database has pending migrations; run: go run ./cmd/migrate up",
+			zap.Int("pending", len(pending)),
+			zap.Int("latest_version", pending[len(pending)-1].Version),
+		)
 	}
-	defer conn.Close(context.Background())
 
 	// Initialize validator
 	validate := validator.New()
+	// Amounts are validated by their minor units, e.g. gt=0
+	validate.RegisterCustomTypeFunc(models.MoneyValue, models.Money{})
 
 	// Initialize repositories
-	userRepo := repositories.NewUserRepository(conn)
-	cinemaRepo := repositories.NewCinemaRepository(conn)
-	seatRepo := repositories.NewSeatRepository(conn)
-	bookingRepo := repositories.NewBookingRepository(conn)
-	paymentRepo := repositories.NewPaymentRepository(conn)
-	emailRepo := repositories.NewEmailVerificationRepository(conn)
+	userRepo := repositories.NewUserRepository(pool)
+	cinemaRepo := repositories.NewCinemaRepository(pool)
+	auditoriumRepo := repositories.NewAuditoriumRepository(pool)
+	movieRepo := repositories.NewMovieRepository(pool)
+	seatRepo := repositories.NewSeatRepository(pool)
+	screeningRepo := repositories.NewScreeningRepository(pool)
+	bookingRepo := repositories.NewBookingRepository(pool)
+	paymentRepo := repositories.NewPaymentRepository(pool)
+	emailRepo := repositories.NewEmailVerificationRepository(pool)
+	idempotencyRepo := repositories.NewIdempotencyRepository(pool)
+	unitOfWork := repositories.NewUnitOfWork(pool)
 
 	// Initialize services
-	emailService := services.NewEmailService(emailRepo, logger, cfg.Email.APIURL, cfg.Email.APIKey)
-	userService := services.NewUserService(userRepo, emailService, cfg.JWT.Secret)
+	emailService := services.NewEmailService(emailRepo, logger, cfg.Email.APIURL, cfg.Email.APIKey, cfg.Email.PasswordResetURL)
+	userService := services.NewUserService(userRepo, emailService, unitOfWork, cfg.JWT.Secret, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
+	passwordResetService := services.NewPasswordResetService(userRepo, emailRepo, emailService, unitOfWork)
 	cinemaService := services.NewCinemaService(cinemaRepo)
-	seatService := services.NewSeatService(seatRepo)
-	bookingService := services.NewBookingService(bookingRepo, seatRepo, cinemaRepo)
-	paymentService := services.NewPaymentService(paymentRepo, bookingRepo)
+	auditoriumService := services.NewAuditoriumService(auditoriumRepo, cinemaRepo)
+	movieService := services.NewMovieService(movieRepo)
+	screeningService := services.NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, cinemaRepo, cfg.Screening.CleaningTime)
+	seatService := services.NewSeatService(seatRepo, screeningRepo, auditoriumRepo)
+	// Every payment method type goes through the simulator until a real provider is plugged in
+	simulator, err := services.NewSimulatorGateway(cfg.Payment.SimulatorMode)
+	if err != nil {
+		logger.Fatal("Failed to create payment gateway", zap.Error(err))
+	}
+	// Stop calling the gateway for a while when it keeps failing
+	gateway := services.NewCircuitBreakerGateway(simulator, cfg.Payment.BreakerThreshold, cfg.Payment.BreakerCooldown)
+	gateways := services.NewGatewayRegistry(gateway)
+
+	paymentService := services.NewPaymentService(paymentRepo, bookingRepo, gateways, unitOfWork, cfg.Booking.RefundPolicy)
+	paymentWebhookService := services.NewPaymentWebhookService(paymentRepo, validate, map[string]string{
+		"simulator": cfg.Payment.WebhookSecret,
+	})
+	bookingService := services.NewBookingService(bookingRepo, seatRepo, screeningRepo, paymentService, unitOfWork, cfg.Booking.HoldTTL, cfg.Booking.CancelCutoff)
+
+	// Start releasing seats of bookings left unpaid past their hold
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	services.NewHoldSweeper(bookingRepo, logger, cfg.Booking.HoldSweepInterval).Start(sweeperCtx)
+	services.NewIdempotencySweeper(idempotencyRepo, logger, cfg.Idempotency.SweepInterval).Start(sweeperCtx)
+
+	// Check dependencies for the readiness probe; only the database and its schema
+	// take the server out of rotation, other outages are reported
+	probe := health.NewProbe(2 * time.Second)
+	probe.Add("database", true, health.DatabaseCheck(pool))
+	probe.Add("migrations", true, health.MigrationsCheck(migrator))
+	probe.Add("email_api", false, emailService.Health)
+	probe.Add("payment_gateway", false, gateway.Health)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService, validate, logger)
 	cinemaHandler := handlers.NewCinemaHandler(cinemaService, validate, logger)
+	auditoriumHandler := handlers.NewAuditoriumHandler(auditoriumService, validate, logger)
+	movieHandler := handlers.NewMovieHandler(movieService, validate, logger)
+	screeningHandler := handlers.NewScreeningHandler(screeningService, validate, logger)
 	seatHandler := handlers.NewSeatHandler(seatService, validate, logger)
 	bookingHandler := handlers.NewBookingHandler(bookingService, validate, logger)
 	paymentHandler := handlers.NewPaymentHandler(paymentService, validate, logger)
+	paymentWebhookHandler := handlers.NewPaymentWebhookHandler(paymentWebhookService, logger)
 	emailHandler := handlers.NewEmailHandler(emailService, validate, logger)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService, validate, logger)
 
 	// Setup router
 	router := chi.NewRouter()
 
 	// Global middleware
 	router.Use(chiMiddleware.RequestID)
-	router.Use(chiMiddleware.RealIP)
+	// Forwarding headers can be set by any client, so they are only read behind a proxy
+	if cfg.Server.TrustProxy {
+		router.Use(chiMiddleware.RealIP)
+	}
 	router.Use(chiMiddleware.Recoverer)
+	router.Use(middleware.TracingMiddleware)
 	router.Use(middleware.LoggingMiddleware(logger))
+	router.Use(middleware.MetricsMiddleware)
+
+	// Metrics in the Prometheus text format, for scraping
+	router.Method(http.MethodGet, "/metrics", metrics.Handler())
 
 	// Public routes
 	router.Post("/api/register", userHandler.Register)
 	router.Post("/api/login", userHandler.Login)
+	router.Post("/api/token/refresh", userHandler.RefreshToken)
 
 	// Email verification routes (public)
 	router.Post("/api/verify-email", emailHandler.VerifyEmail)
 	router.Post("/api/resend-otp", emailHandler.ResendOTP)
 
+	// Password reset (public); requests are limited per client IP on top of the per-email limits
+	router.With(middleware.RateLimit(10, 15*time.Minute)).Post("/api/password/forgot", passwordHandler.ForgotPassword)
+	router.With(middleware.RateLimit(10, 15*time.Minute)).Post("/api/password/reset", passwordHandler.ResetPassword)
+
 	// Cinema routes (public)
 	router.Get("/api/cinemas", cinemaHandler.GetAllCinemas)
 	router.Get("/api/cinemas/{cinemaId}", cinemaHandler.GetCinemaByID)
+	router.Get("/api/cinemas/{cinemaId}/auditoriums", auditoriumHandler.GetAuditoriumsByCinema)
+	router.Get("/api/cinemas/{cinemaId}/auditoriums/{auditoriumId}/seat-map", seatHandler.GetSeatMap)
+
+	// Movie routes (public)
+	router.Get("/api/movies", movieHandler.GetAllMovies)
+	router.Get("/api/movies/{movieId}", movieHandler.GetMovieByID)
+
+	// Screening routes (public)
+	router.Get("/api/cinemas/{cinemaId}/screenings", screeningHandler.GetScreeningsByCinema)
+	router.Get("/api/screenings/{screeningId}", screeningHandler.GetScreeningByID)
 
 	// Seat routes (public)
-	router.Get("/api/cinemas/{cinemaId}/seats", seatHandler.GetSeatAvailability)
+	router.Get("/api/screenings/{screeningId}/seats", seatHandler.GetSeatAvailability)
 
 	// Payment methods (public)
 	router.Get("/api/payment-methods", paymentHandler.GetPaymentMethods)
 
+	// Payment provider callbacks (authenticated by their signature)
+	router.Post("/api/payments/webhook/{provider}", paymentWebhookHandler.HandleWebhook)
+
 	// Protected routes
 	router.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(userService))
 
+		// Retries of these POSTs with the same Idempotency-Key replay the first response
+		idempotent := r.With(middleware.IdempotencyMiddleware(idempotencyRepo, cfg.Idempotency.LockTimeout, cfg.Idempotency.TTL, logger))
+
 		// User routes
 		r.Post("/api/logout", userHandler.Logout)
 		r.Get("/api/user/profile", userHandler.GetProfile)
+		r.Get("/api/user/sessions", userHandler.GetSessions)
+		r.Delete("/api/user/sessions", userHandler.RevokeAllSessions)
+		r.Delete("/api/user/sessions/{sessionId}", userHandler.RevokeSession)
 
 		// Booking routes
-		r.Post("/api/booking", bookingHandler.CreateBooking)
+		idempotent.Post("/api/booking", bookingHandler.CreateBooking)
 		r.Get("/api/user/bookings", bookingHandler.GetUserBookings)
+		r.Post("/api/bookings/{bookingId}/cancel", bookingHandler.CancelBooking)
 
 		// Payment routes
-		r.Post("/api/pay", paymentHandler.ProcessPayment)
+		idempotent.Post("/api/pay", paymentHandler.ProcessPayment)
+		r.Get("/api/payments/{reference}", paymentHandler.GetPaymentByReference)
 	})
 
-	// Health check endpoint
-	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"ok"}`)
+	// Admin routes
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(userService))
+
+		// Refunds are further limited to the payment's cinema
+		r.With(middleware.RequireRole(models.RoleCinemaAdmin, models.RoleSuperAdmin)).
+			Post("/api/admin/payments/{paymentId}/refunds", paymentHandler.RefundPayment)
+		r.With(middleware.RequireRole(models.RoleSuperAdmin)).
+			Put("/api/admin/users/{userId}/role", userHandler.ChangeUserRole)
+
+		// Schedules are further limited to the auditorium's cinema
+		r.With(middleware.RequireRole(models.RoleCinemaAdmin, models.RoleSuperAdmin)).
+			Post("/api/admin/schedules", screeningHandler.CreateSchedule)
+
+		// Cinemas: super admins open and close them, cinema admins edit their own
+		r.With(middleware.RequireRole(models.RoleSuperAdmin)).
+			Post("/api/admin/cinemas", cinemaHandler.CreateCinema)
+		r.Route("/api/admin/cinemas/{cinemaId}", func(r chi.Router) {
+			r.Use(middleware.RequireRole(models.RoleCinemaAdmin, models.RoleSuperAdmin))
+			r.Use(middleware.RequireCinemaAccess("cinemaId"))
+
+			r.Put("/", cinemaHandler.UpdateCinema)
+			r.Patch("/", cinemaHandler.PatchCinema)
+			r.With(middleware.RequireRole(models.RoleSuperAdmin)).Delete("/", cinemaHandler.DeleteCinema)
+
+			// Seats of the cinema's auditoriums are generated from their uploaded layout
+			r.Put("/auditoriums/{auditoriumId}/seat-map", seatHandler.UpdateSeatMap)
+		})
 	})
 
+	// Health check endpoint
+	router.Method(http.MethodGet, "/health", probe.LiveHandler())
+	router.Method(http.MethodGet, "/health/live", probe.LiveHandler())
+	router.Method(http.MethodGet, "/health/ready", probe.ReadyHandler())
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -170,10 +289,16 @@ func main() {
 
 	// Wait for interrupt signal
 	sigint := make(chan os.Signal, 1)
-	signal.Notify(sigint, os.Interrupt)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 	<-sigint
 
+	// Fail readiness first so load balancers stop sending requests before the server stops
+	logger.Info("Draining server...", zap.Duration("drain_delay", cfg.Server.DrainDelay))
+	probe.Shutdown()
+	time.Sleep(cfg.Server.DrainDelay)
+
 	logger.Info("Shutting down server...")
+	stopSweeper()
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/db"
+	"github.com/andre/project-app-bioskop-golang/internal/config"
+	"github.com/andre/project-app-bioskop-golang/internal/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up        apply every pending migration
+  down      revert the latest applied migration
+  status    list migrations and whether they are applied
+  to N      apply or revert migrations until exactly those up to N are applied; 0 reverts all`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	godotenv.Load()
+
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to database
+	poolCfg, err := cfg.Database.PoolConfig()
+	if err != nil {
+		log.Fatalf("Failed to parse database config: %v", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err = pool.Ping(ctx)
+	cancel()
+	if err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	migrator, err := migrations.NewMigrator(pool, db.Migrations())
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx = context.Background()
+	switch command := os.Args[1]; {
+	case command == "up" && len(os.Args) == 2:
+		ran, err := migrator.Up(ctx)
+		report(ran, math.MaxInt, err)
+	case command == "down" && len(os.Args) == 2:
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			log.Fatalf("Failed to revert migration: %v", err)
+		}
+		if migration == nil {
+			log.Println("No migration to revert")
+			return
+		}
+		log.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+	case command == "to" && len(os.Args) == 3:
+		version, err := strconv.Atoi(os.Args[2])
+		if err != nil || version < 0 {
+			log.Fatalf("Invalid migration version %q", os.Args[2])
+		}
+		ran, err := migrator.To(ctx, version)
+		report(ran, version, err)
+	case command == "status" && len(os.Args) == 2:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.DateTime)
+			}
+			if status.Modified {
+				state += " (modified since applied)"
+			}
+			if status.Unknown {
+				state += " (unknown to this build)"
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// report logs the migrations that ran migrating to version and exits on err
+func report(ran []*migrations.Migration, version int, err error) {
+	for _, migration := range ran {
+		verb := "Applied"
+		if migration.Version > version {
+			verb = "Reverted"
+		}
+		log.Printf("%s %04d_%s\n", verb, migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if len(ran) == 0 {
+		log.Println("Database is up to date")
+	}
+}
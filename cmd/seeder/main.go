@@ -2,16 +2,14 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 	"log"
 	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/config"
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/repositories"
-	"github.com/jackc/pgx/v5"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
@@ -23,89 +21,117 @@ func main() {
 	cfg := config.LoadConfig()
 
 	// Connect to database
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-	)
-
-	sqlDB, err := sql.Open("pgx", dbURL)
+	poolCfg, err := cfg.Database.PoolConfig()
+	if err != nil {
+		log.Fatalf("Failed to parse database config: %v", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer sqlDB.Close()
+	defer pool.Close()
 
 	// Ping database
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	err = sqlDB.PingContext(ctx)
+	err = pool.Ping(ctx)
 	cancel()
 	if err != nil {
 		log.Fatalf("Failed to ping database: %v", err)
 	}
 	log.Println("Database connected successfully")
 
-	// Create PGX connection pool for queries
-	pgxCfg, err := pgx.ParseConfig(dbURL)
-	if err != nil {
-		log.Fatalf("Failed to parse database config: %v", err)
-	}
-
-	conn, err := pgx.ConnectConfig(context.Background(), pgxCfg)
-	if err != nil {
-		log.Fatalf("Failed to connect to database with pgx: %v", err)
-	}
-	defer conn.Close(context.Background())
-
 	// Initialize repositories
-	cinemaRepo := repositories.NewCinemaRepository(conn)
-	seatRepo := repositories.NewSeatRepository(conn)
+	cinemaRepo := repositories.NewCinemaRepository(pool)
+	movieRepo := repositories.NewMovieRepository(pool)
+	auditoriumRepo := repositories.NewAuditoriumRepository(pool)
+	seatRepo := repositories.NewSeatRepository(pool)
+	screeningRepo := repositories.NewScreeningRepository(pool)
+	seatService := services.NewSeatService(seatRepo, screeningRepo, auditoriumRepo)
 
 	ctx = context.Background()
 
+	// Seed movies
+	log.Println("Seeding movies...")
+	today := time.Now().Truncate(24 * time.Hour)
+	movies := []*models.Movie{
+		{
+			Title:       "Agak Laen",
+			Synopsis:    "Empat sekawan penjaga rumah hantu di pasar malam berusaha menyelamatkan usaha mereka.",
+			Duration:    119,
+			Genre:       "Comedy",
+			AgeRating:   "13+",
+			PosterURL:   "https://via.placeholder.com/300x450?text=Agak+Laen",
+			ReleaseDate: today.AddDate(0, 0, -14),
+		},
+		{
+			Title:       "Pengabdi Setan 2: Communion",
+			Synopsis:    "Keluarga Rini pindah ke rumah susun dan kembali diteror oleh sosok Ibu.",
+			Duration:    119,
+			Genre:       "Horror",
+			AgeRating:   "17+",
+			PosterURL:   "https://via.placeholder.com/300x450?text=Pengabdi+Setan+2",
+			ReleaseDate: today.AddDate(0, 0, -7),
+		},
+		{
+			Title:       "Jumbo",
+			Synopsis:    "Don, anak bertubuh besar yang sering diremehkan, ingin membuktikan diri lewat pertunjukan bakat.",
+			Duration:    102,
+			Genre:       "Animation",
+			AgeRating:   "SU",
+			PosterURL:   "https://via.placeholder.com/300x450?text=Jumbo",
+			ReleaseDate: today,
+		},
+	}
+
+	var createdMovies []*models.Movie
+	for _, movie := range movies {
+		err := movieRepo.CreateMovie(ctx, movie)
+		if err != nil {
+			log.Printf("Warning: Could not create movie %s: %v\n", movie.Title, err)
+			continue
+		}
+		log.Printf("Movie created: %s (ID: %d)\n", movie.Title, movie.ID)
+		createdMovies = append(createdMovies, movie)
+	}
+
 	// Seed cinemas
 	log.Println("Seeding cinemas...")
 	cinemas := []*models.Cinema{
 		{
-			Name:       "CGV Cinemas - Jakarta",
-			Location:   "Blok M Plaza",
-			City:       "Jakarta",
-			Address:    "Jl. Melawai No. 1, Blok M, Jakarta Selatan",
-			TotalSeats: 150,
-			ImageURL:   "https://via.placeholder.com/300x200?text=CGV+Jakarta",
+			Name:     "CGV Cinemas - Jakarta",
+			Location: "Blok M Plaza",
+			City:     "Jakarta",
+			Address:  "Jl. Melawai No. 1, Blok M, Jakarta Selatan",
+			ImageURL: "https://via.placeholder.com/300x200?text=CGV+Jakarta",
 		},
 		{
-			Name:       "Cinemaxx - Surabaya",
-			Location:   "Pakuwon Indah",
-			City:       "Surabaya",
-			Address:    "Jl. Raya Pakuwon Indah, Surabaya",
-			TotalSeats: 200,
-			ImageURL:   "https://via.placeholder.com/300x200?text=Cinemaxx+Surabaya",
+			Name:     "Cinemaxx - Surabaya",
+			Location: "Pakuwon Indah",
+			City:     "Surabaya",
+			Address:  "Jl. Raya Pakuwon Indah, Surabaya",
+			ImageURL: "https://via.placeholder.com/300x200?text=Cinemaxx+Surabaya",
 		},
 		{
-			Name:       "Premiere Cinema - Bandung",
-			Location:   "Bandung Indah Plaza",
-			City:       "Bandung",
-			Address:    "Jl. Ir. H. Juanda No. 1, Bandung",
-			TotalSeats: 120,
-			ImageURL:   "https://via.placeholder.com/300x200?text=Premiere+Bandung",
+			Name:     "Premiere Cinema - Bandung",
+			Location: "Bandung Indah Plaza",
+			City:     "Bandung",
+			Address:  "Jl. Ir. H. Juanda No. 1, Bandung",
+			ImageURL: "https://via.placeholder.com/300x200?text=Premiere+Bandung",
 		},
 		{
-			Name:       "TheScreen Cinemas - Medan",
-			Location:   "Medan Fair",
-			City:       "Medan",
-			Address:    "Jl. Jend. Gatot Subroto No. 1, Medan",
-			TotalSeats: 180,
-			ImageURL:   "https://via.placeholder.com/300x200?text=TheScreen+Medan",
+			Name:     "TheScreen Cinemas - Medan",
+			Location: "Medan Fair",
+			City:     "Medan",
+			Address:  "Jl. Jend. Gatot Subroto No. 1, Medan",
+			ImageURL: "https://via.placeholder.com/300x200?text=TheScreen+Medan",
 		},
 		{
-			Name:       "Studio 21 - Bali",
-			Location:   "Denpasar",
-			City:       "Bali",
-			Address:    "Jl. Raya Puputan No. 1, Denpasar",
-			TotalSeats: 160,
-			ImageURL:   "https://via.placeholder.com/300x200?text=Studio21+Bali",
+			Name:     "Studio 21 - Bali",
+			Location: "Denpasar",
+			City:     "Bali",
+			Address:  "Jl. Raya Puputan No. 1, Denpasar",
+			ImageURL: "https://via.placeholder.com/300x200?text=Studio21+Bali",
 		},
 	}
 
@@ -120,59 +146,111 @@ func main() {
 		createdCinemas = append(createdCinemas, cinema)
 	}
 
-	// Seed seats
-	log.Println("Seeding seats...")
+	// Seed auditoriums, seats and screenings
+	log.Println("Seeding auditoriums and seats...")
+	auditoriumLayouts := []struct {
+		name        string
+		format      string
+		rows        int
+		seatsPerRow int
+	}{
+		{name: "Studio 1", format: "2D", rows: 5, seatsPerRow: 20},
+		{name: "Studio 2", format: "3D", rows: 5, seatsPerRow: 16},
+		{name: "Studio 3", format: "IMAX", rows: 6, seatsPerRow: 20},
+	}
+
+	year, month, day := time.Now().Date()
+	startOfToday := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	hours := []int{10, 13, 16, 19, 21}
+
 	for _, cinema := range createdCinemas {
-		// Create seats: 5 rows, 30 seats per row
-		seatCounter := 0
-		for row := 1; row <= 5; row++ {
-			for seatNum := 1; seatNum <= 30; seatNum++ {
-				seatType := "standard"
-				price := 50000.0
-
-				// Premium seats (rows 3-4)
+		for a, layout := range auditoriumLayouts {
+			auditorium := &models.Auditorium{
+				CinemaID: cinema.ID,
+				Name:     layout.name,
+				Format:   layout.format,
+				Capacity: layout.rows * layout.seatsPerRow,
+			}
+
+			err := auditoriumRepo.CreateAuditorium(ctx, auditorium)
+			if err != nil {
+				log.Printf("Warning: Could not create auditorium %s for cinema %s: %v\n", layout.name, cinema.Name, err)
+				continue
+			}
+
+			// Create seats from a seat map with an aisle down the middle: standard seats
+			// at the front, premium seats in rows C-D and VIP seats at the back
+			seatMap := &models.SeatMapRequest{
+				Prices: map[string]models.Money{
+					models.SeatTypeStandard: models.MustParseMoney("50000"),
+					models.SeatTypePremium:  models.MustParseMoney("70000"),
+					models.SeatTypeVIP:      models.MustParseMoney("100000"),
+				},
+			}
+			for row := 1; row <= layout.rows; row++ {
+				seatType := models.SeatTypeStandard
 				if row >= 3 && row <= 4 {
-					seatType = "premium"
-					price = 70000.0
+					seatType = models.SeatTypePremium
 				}
-
-				// VIP seats (row 5)
-				if row == 5 {
-					seatType = "vip"
-					price = 100000.0
+				if row >= 5 {
+					seatType = models.SeatTypeVIP
 				}
 
-				seatLetter := string(rune('A' + seatNum - 1))
-				seat := &models.Seat{
-					CinemaID:   cinema.ID,
-					SeatNumber: fmt.Sprintf("%d%s", row, seatLetter),
-					RowNumber:  row,
-					SeatType:   seatType,
-					Price:      price,
+				cells := make([]string, 0, layout.seatsPerRow+1)
+				for seatNum := 1; seatNum <= layout.seatsPerRow; seatNum++ {
+					if seatNum == layout.seatsPerRow/2+1 {
+						cells = append(cells, models.SeatMapCellAisle)
+					}
+					cells = append(cells, seatType)
 				}
+				seatMap.Rows = append(seatMap.Rows, models.SeatMapRowLayout{
+					Label: string(rune('A' + row - 1)),
+					Cells: cells,
+				})
+			}
 
-				err := seatRepo.CreateSeat(ctx, seat)
-				if err != nil {
-					log.Printf("Warning: Could not create seat for cinema %s: %v\n", cinema.Name, err)
-					continue
-				}
-				seatCounter++
+			savedSeatMap, err := seatService.UpdateSeatMap(ctx, cinema.ID, auditorium.ID, seatMap)
+			if err != nil || savedSeatMap == nil {
+				log.Printf("Warning: Could not create seats for %s at %s: %v\n", auditorium.Name, cinema.Name, err)
+				continue
 			}
-		}
-		log.Printf("Created %d seats for cinema: %s\n", seatCounter, cinema.Name)
-
-		// Create seat availability for next 10 days
-		for i := 0; i < 10; i++ {
-			date := time.Now().AddDate(0, 0, i)
-			times := []string{"10:00", "13:00", "16:00", "19:00", "21:00"}
-			for _, timeStr := range times {
-				err := seatRepo.CreateSeatAvailability(ctx, cinema.ID, date, timeStr)
-				if err != nil {
-					log.Printf("Warning: Could not create seat availability: %v\n", err)
+			log.Printf("Created %d seats for %s at cinema: %s\n", savedSeatMap.TotalSeats, auditorium.Name, cinema.Name)
+
+			if len(createdMovies) == 0 {
+				continue
+			}
+
+			// Create screenings and their seat availability for next 10 days,
+			// rotating through the seeded movies
+			screeningCounter := 0
+			for i := 0; i < 10; i++ {
+				date := startOfToday.AddDate(0, 0, i)
+				for j, hour := range hours {
+					movie := createdMovies[(i+j+a)%len(createdMovies)]
+					start := date.Add(time.Duration(hour) * time.Hour)
+					screening := &models.Screening{
+						AuditoriumID: auditorium.ID,
+						MovieID:      movie.ID,
+						StartTime:    start,
+						EndTime:      start.Add(time.Duration(movie.Duration) * time.Minute),
+						BasePrice:    models.MustParseMoney("50000"),
+					}
+
+					err := screeningRepo.CreateScreening(ctx, screening)
+					if err != nil {
+						log.Printf("Warning: Could not create screening: %v\n", err)
+						continue
+					}
+
+					err = seatRepo.CreateSeatAvailability(ctx, screening.ID, auditorium.ID)
+					if err != nil {
+						log.Printf("Warning: Could not create seat availability: %v\n", err)
+					}
+					screeningCounter++
 				}
 			}
+			log.Printf("Created %d screenings for %s at cinema: %s\n", screeningCounter, auditorium.Name, cinema.Name)
 		}
-		log.Printf("Created seat availability for cinema: %s\n", cinema.Name)
 	}
 
 	log.Println("Seeding completed successfully!")
@@ -0,0 +1,17 @@
+// Package db holds the database migrations, embedded so every binary carries its schema
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns the numbered migrations: NNNN_name.up.sql applies one and
+// NNNN_name.down.sql reverts it
+func Migrations() fs.FS {
+	sub, _ := fs.Sub(migrations, "migrations")
+	return sub
+}
@@ -0,0 +1,18 @@
+-- Drop every table of the initial schema, dependents first
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS payment_webhook_events;
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS payment_methods;
+DROP TABLE IF EXISTS booking_seats;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS seat_availability;
+DROP TABLE IF EXISTS screenings;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS auditoriums;
+DROP TABLE IF EXISTS movies;
+DROP TABLE IF EXISTS cinema_staff;
+DROP TABLE IF EXISTS cinemas;
+DROP TABLE IF EXISTS email_verifications;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,251 @@
+-- Initial schema. Tables are created only if missing, so databases set up from the
+-- former db/schema.sql adopt this migration without changes.
+
+-- Users table
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    email VARCHAR(100) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    is_verified BOOLEAN DEFAULT FALSE,
+    role VARCHAR(20) NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('customer', 'cinema_staff', 'cinema_admin', 'super_admin')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- User sessions table
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(500) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Email verifications table (for OTP verification)
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(100) NOT NULL,
+    otp_code VARCHAR(6) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    is_verified BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for faster email verification lookups
+CREATE INDEX IF NOT EXISTS idx_email_verifications_email ON email_verifications(email);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id);
+
+-- Cinemas table
+CREATE TABLE IF NOT EXISTS cinemas (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    location VARCHAR(100) NOT NULL,
+    city VARCHAR(50) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    image_url VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    archived_at TIMESTAMP -- set when the cinema is deleted; archived cinemas are kept for booking history
+);
+
+-- Cinema staff table: the cinemas a cinema_staff or cinema_admin user works at
+CREATE TABLE IF NOT EXISTS cinema_staff (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, cinema_id)
+);
+
+-- Movies table
+CREATE TABLE IF NOT EXISTS movies (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(150) NOT NULL,
+    synopsis TEXT NOT NULL DEFAULT '',
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    genre VARCHAR(50) NOT NULL,
+    age_rating VARCHAR(10) NOT NULL DEFAULT 'SU',
+    poster_url VARCHAR(255) NOT NULL DEFAULT '',
+    release_date DATE NOT NULL,
+    end_date DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Auditoriums table (studios within a cinema; a cinema's total seats is the sum of capacities)
+CREATE TABLE IF NOT EXISTS auditoriums (
+    id SERIAL PRIMARY KEY,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL DEFAULT '2D',
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    seat_map JSONB, -- seat layout uploaded by admins: rows of seat, aisle and gap cells
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(cinema_id, name)
+);
+
+-- Seats table
+CREATE TABLE IF NOT EXISTS seats (
+    id SERIAL PRIMARY KEY,
+    auditorium_id INTEGER NOT NULL REFERENCES auditoriums(id) ON DELETE CASCADE,
+    seat_number VARCHAR(10) NOT NULL,
+    row_number INTEGER NOT NULL,
+    seat_type VARCHAR(20) DEFAULT 'standard',
+    price DECIMAL(10, 2) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE, -- false once removed from the seat map; kept for past bookings
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(auditorium_id, seat_number)
+);
+
+-- Screenings table (a movie showing in an auditorium at a specific time)
+CREATE TABLE IF NOT EXISTS screenings (
+    id SERIAL PRIMARY KEY,
+    auditorium_id INTEGER NOT NULL REFERENCES auditoriums(id) ON DELETE CASCADE,
+    movie_id INTEGER NOT NULL REFERENCES movies(id),
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    base_price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time)
+);
+
+-- Seat availability table (one row per seat per screening)
+CREATE TABLE IF NOT EXISTS seat_availability (
+    id SERIAL PRIMARY KEY,
+    screening_id INTEGER NOT NULL REFERENCES screenings(id) ON DELETE CASCADE,
+    seat_id INTEGER NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+    is_available BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(screening_id, seat_id)
+);
+
+-- Bookings table (header; one row per order)
+CREATE TABLE IF NOT EXISTS bookings (
+    id SERIAL PRIMARY KEY,
+    reference VARCHAR(32) NOT NULL UNIQUE, -- shown to customers, e.g. BKG-01M53FF4X3KTAGJC2KZ
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    screening_id INTEGER NOT NULL REFERENCES screenings(id) ON DELETE CASCADE,
+    booking_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20) DEFAULT 'pending',
+    total_price DECIMAL(10, 2) NOT NULL,
+    payment_method VARCHAR(50),
+    payment_status VARCHAR(20) DEFAULT 'pending',
+    hold_expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Booking seats table (line items; one row per booked seat)
+CREATE TABLE IF NOT EXISTS booking_seats (
+    id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    screening_id INTEGER NOT NULL REFERENCES screenings(id) ON DELETE CASCADE,
+    seat_id INTEGER NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(booking_id, seat_id)
+);
+
+-- A seat can be held by at most one active booking per screening
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_seats_active_seat ON booking_seats(screening_id, seat_id) WHERE is_active;
+
+-- Payments table
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    reference VARCHAR(32) NOT NULL UNIQUE, -- printed on receipts, e.g. PAY-01M53FF4X3GTKF94FTT
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    transaction_id VARCHAR(100), -- the gateway's ID, NULL until the gateway answers
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A booking has at most one payment that is not failed; failed attempts can be retried
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_booking_attempt ON payments(booking_id) WHERE status <> 'failed';
+
+-- Payment methods table
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Refunds table: a payment can be refunded in full or in several partial refunds
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    reference VARCHAR(32) NOT NULL UNIQUE,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Payment webhook events table: provider callbacks, kept to process each event only once
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    id SERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    transaction_id VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE CASCADE,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(provider, event_id)
+);
+
+-- Idempotency keys table: responses of POSTs sent with an Idempotency-Key header, replayed on retries
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER, -- NULL while the first request is still being processed
+    response_body BYTEA,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    UNIQUE(user_id, idempotency_key)
+);
+
+-- Insert default payment methods
+INSERT INTO payment_methods (name, type, is_active) VALUES
+    ('Kartu Kredit', 'credit_card', TRUE),
+    ('Kartu Debit', 'debit_card', TRUE),
+    ('Transfer Bank', 'transfer', TRUE),
+    ('E-Wallet (GCash)', 'e_wallet', TRUE),
+    ('E-Wallet (GoPay)', 'e_wallet', TRUE),
+    ('E-Wallet (OVO)', 'e_wallet', TRUE)
+ON CONFLICT DO NOTHING;
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_token ON user_sessions(token);
+CREATE INDEX IF NOT EXISTS idx_cinema_staff_cinema_id ON cinema_staff(cinema_id);
+CREATE INDEX IF NOT EXISTS idx_seats_auditorium_id ON seats(auditorium_id);
+CREATE INDEX IF NOT EXISTS idx_screenings_auditorium_start ON screenings(auditorium_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_screenings_movie_id ON screenings(movie_id);
+CREATE INDEX IF NOT EXISTS idx_seat_availability_seat_id ON seat_availability(seat_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_user_id ON bookings(user_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_screening_id ON bookings(screening_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_pending_hold ON bookings(hold_expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_movies_release_date ON movies(release_date);
+CREATE INDEX IF NOT EXISTS idx_payments_booking_id ON payments(booking_id);
+CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments(user_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds(payment_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_transaction_id_unique ON payments(transaction_id);
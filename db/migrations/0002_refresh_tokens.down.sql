@@ -0,0 +1,7 @@
+-- Go back to sessions holding a single token; current sessions cannot be converted
+DROP TABLE IF EXISTS refresh_tokens;
+
+DELETE FROM user_sessions;
+ALTER TABLE user_sessions DROP COLUMN revoked_at;
+ALTER TABLE user_sessions ADD COLUMN token VARCHAR(500) NOT NULL UNIQUE;
+CREATE INDEX IF NOT EXISTS idx_user_sessions_token ON user_sessions(token);
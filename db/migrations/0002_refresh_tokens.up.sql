@@ -0,0 +1,19 @@
+-- A session is now one login: its access tokens carry the session ID and it is kept
+-- alive by rotating refresh tokens. Sessions of the old single tokens are dropped,
+-- so their users log in again.
+DELETE FROM user_sessions;
+DROP INDEX IF EXISTS idx_user_sessions_token;
+ALTER TABLE user_sessions DROP COLUMN token;
+ALTER TABLE user_sessions ADD COLUMN revoked_at TIMESTAMP;
+
+-- Refresh tokens table; only a SHA-256 hash of each token is stored
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,5 @@
+-- Remember which device each session was started from and when it was last used,
+-- so users can recognize their sessions and revoke the ones they do not
+ALTER TABLE user_sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
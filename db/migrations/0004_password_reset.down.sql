@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_email_verifications_email_purpose;
+DELETE FROM email_verifications WHERE purpose <> 'email_verification';
+ALTER TABLE email_verifications DROP COLUMN attempts;
+ALTER TABLE email_verifications DROP COLUMN purpose;
@@ -0,0 +1,8 @@
+-- Email OTPs now serve more than one purpose: verifying the email after registration
+-- and resetting a forgotten password. Wrong guesses are counted so a code can be
+-- given up on before its 6 digits are brute forced.
+ALTER TABLE email_verifications ADD COLUMN purpose VARCHAR(20) NOT NULL DEFAULT 'email_verification'
+    CHECK (purpose IN ('email_verification', 'password_reset'));
+ALTER TABLE email_verifications ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_email_verifications_email_purpose ON email_verifications(email, purpose, created_at);
@@ -0,0 +1,10 @@
+-- Idempotency keys record when their request started, so a reservation left behind by a
+-- crashed request can be reclaimed, and when they expire, so completed keys are cleaned up.
+ALTER TABLE idempotency_keys ADD COLUMN locked_at TIMESTAMP;
+ALTER TABLE idempotency_keys ADD COLUMN expires_at TIMESTAMP;
+
+UPDATE idempotency_keys SET locked_at = created_at WHERE status_code IS NULL;
+UPDATE idempotency_keys SET expires_at = COALESCE(completed_at, created_at) + INTERVAL '24 hours';
+ALTER TABLE idempotency_keys ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
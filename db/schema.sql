@@ -1,141 +0,0 @@
--- Create database
-CREATE DATABASE IF NOT EXISTS bioskop_db;
-
--- Users table
-CREATE TABLE IF NOT EXISTS users (
-    id SERIAL PRIMARY KEY,
-    username VARCHAR(50) UNIQUE NOT NULL,
-    email VARCHAR(100) UNIQUE NOT NULL,
-    password VARCHAR(255) NOT NULL,
-    is_verified BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- User sessions table
-CREATE TABLE IF NOT EXISTS user_sessions (
-    id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    token VARCHAR(500) NOT NULL UNIQUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    expires_at TIMESTAMP NOT NULL
-);
-
--- Email verifications table (for OTP verification)
-CREATE TABLE IF NOT EXISTS email_verifications (
-    id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    email VARCHAR(100) NOT NULL,
-    otp_code VARCHAR(6) NOT NULL,
-    expires_at TIMESTAMP NOT NULL,
-    is_verified BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Index for faster email verification lookups
-CREATE INDEX IF NOT EXISTS idx_email_verifications_email ON email_verifications(email);
-CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id);
-
--- Cinemas table
-CREATE TABLE IF NOT EXISTS cinemas (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    location VARCHAR(100) NOT NULL,
-    city VARCHAR(50) NOT NULL,
-    address VARCHAR(255) NOT NULL,
-    total_seats INTEGER NOT NULL,
-    image_url VARCHAR(255),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Seats table
-CREATE TABLE IF NOT EXISTS seats (
-    id SERIAL PRIMARY KEY,
-    cinema_id INTEGER NOT NULL REFERENCES cinemas(id) ON DELETE CASCADE,
-    seat_number VARCHAR(10) NOT NULL,
-    row_number INTEGER NOT NULL,
-    seat_type VARCHAR(20) DEFAULT 'standard',
-    price DECIMAL(10, 2) NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(cinema_id, seat_number)
-);
-
--- Seat availability table
-CREATE TABLE IF NOT EXISTS seat_availability (
-    id SERIAL PRIMARY KEY,
-    cinema_id INTEGER NOT NULL REFERENCES cinemas(id) ON DELETE CASCADE,
-    seat_id INTEGER NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
-    show_date DATE NOT NULL,
-    show_time VARCHAR(10) NOT NULL,
-    is_available BOOLEAN DEFAULT TRUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(seat_id, show_date, show_time)
-);
-
--- Bookings table
-CREATE TABLE IF NOT EXISTS bookings (
-    id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    cinema_id INTEGER NOT NULL REFERENCES cinemas(id) ON DELETE CASCADE,
-    seat_id INTEGER NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
-    show_date DATE NOT NULL,
-    show_time VARCHAR(10) NOT NULL,
-    booking_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    status VARCHAR(20) DEFAULT 'pending',
-    total_price DECIMAL(10, 2) NOT NULL,
-    payment_method VARCHAR(50),
-    payment_status VARCHAR(20) DEFAULT 'pending',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Payments table
-CREATE TABLE IF NOT EXISTS payments (
-    id SERIAL PRIMARY KEY,
-    booking_id INTEGER NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
-    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    amount DECIMAL(10, 2) NOT NULL,
-    payment_method VARCHAR(50) NOT NULL,
-    status VARCHAR(20) DEFAULT 'pending',
-    transaction_id VARCHAR(100),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Payment methods table
-CREATE TABLE IF NOT EXISTS payment_methods (
-    id SERIAL PRIMARY KEY,
-    name VARCHAR(50) NOT NULL,
-    type VARCHAR(50) NOT NULL,
-    is_active BOOLEAN DEFAULT TRUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Insert default payment methods
-INSERT INTO payment_methods (name, type, is_active) VALUES
-    ('Kartu Kredit', 'credit_card', TRUE),
-    ('Kartu Debit', 'debit_card', TRUE),
-    ('Transfer Bank', 'transfer', TRUE),
-    ('E-Wallet (GCash)', 'e_wallet', TRUE),
-    ('E-Wallet (GoPay)', 'e_wallet', TRUE),
-    ('E-Wallet (OVO)', 'e_wallet', TRUE)
-ON CONFLICT DO NOTHING;
-
--- Create indexes for performance
-CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
-CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
-CREATE INDEX IF NOT EXISTS idx_user_sessions_token ON user_sessions(token);
-CREATE INDEX IF NOT EXISTS idx_seats_cinema_id ON seats(cinema_id);
-CREATE INDEX IF NOT EXISTS idx_seat_availability_cinema_id ON seat_availability(cinema_id);
-CREATE INDEX IF NOT EXISTS idx_seat_availability_seat_id ON seat_availability(seat_id);
-CREATE INDEX IF NOT EXISTS idx_seat_availability_show_date_time ON seat_availability(show_date, show_time);
-CREATE INDEX IF NOT EXISTS idx_bookings_user_id ON bookings(user_id);
-CREATE INDEX IF NOT EXISTS idx_bookings_cinema_id ON bookings(cinema_id);
-CREATE INDEX IF NOT EXISTS idx_bookings_seat_id ON bookings(seat_id);
-CREATE INDEX IF NOT EXISTS idx_payments_booking_id ON payments(booking_id);
-CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments(user_id);
@@ -3,16 +3,24 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/viper"
 )
 
 // Config represents application configuration
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
-	Email    EmailConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	JWT         JWTConfig
+	Email       EmailConfig
+	Booking     BookingConfig
+	Screening   ScreeningConfig
+	Payment     PaymentConfig
+	Idempotency IdempotencyConfig
+	Tracing     TracingConfig
 }
 
 // DatabaseConfig represents database configuration
@@ -23,23 +31,68 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+
+	MaxConns        int32         // largest number of pooled connections
+	MinConns        int32         // connections kept open while idle
+	MaxConnLifetime time.Duration // how long a connection is used before it is replaced
+	MaxConnIdleTime time.Duration // how long an idle connection is kept open
 }
 
 // ServerConfig represents server configuration
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port       string
+	Env        string
+	DrainDelay time.Duration // how long readiness fails before shutdown, for load balancers to stop routing
+	TrustProxy bool          // take client addresses from X-Forwarded-For and X-Real-IP; only safe behind a proxy that sets them
 }
 
 // JWTConfig represents JWT configuration
 type JWTConfig struct {
-	Secret string
+	Secret          string
+	AccessTokenTTL  time.Duration // how long an access token is accepted
+	RefreshTokenTTL time.Duration // how long a session lasts without refreshing its tokens
 }
 
 // EmailConfig represents email API configuration
 type EmailConfig struct {
-	APIURL string
-	APIKey string
+	APIURL           string
+	APIKey           string
+	PasswordResetURL string // page password reset emails link to; empty sends codes only
+}
+
+// BookingConfig represents booking configuration
+type BookingConfig struct {
+	HoldTTL           time.Duration // how long a pending booking holds its seats
+	HoldSweepInterval time.Duration // how often expired holds are released
+	CancelCutoff      time.Duration // how long before showtime cancellation closes
+	RefundPolicy      models.RefundPolicy
+}
+
+// ScreeningConfig represents screening scheduling configuration
+type ScreeningConfig struct {
+	CleaningTime time.Duration // how long an auditorium is closed between screenings
+}
+
+// PaymentConfig represents payment gateway configuration
+type PaymentConfig struct {
+	SimulatorMode string // approve, decline, timeout or async
+	WebhookSecret string // signs the simulator's payment webhooks; disabled while empty
+
+	BreakerThreshold int           // consecutive gateway errors that open the circuit breaker
+	BreakerCooldown  time.Duration // how long an open breaker fails calls before trying the gateway again
+}
+
+// IdempotencyConfig represents Idempotency-Key handling configuration
+type IdempotencyConfig struct {
+	LockTimeout   time.Duration // how long a key stays reserved by a request that never completes
+	TTL           time.Duration // how long a stored response is replayed
+	SweepInterval time.Duration // how often expired keys are deleted
+}
+
+// TracingConfig represents trace exporting configuration
+type TracingConfig struct {
+	Exporter string // none, stdout or file
+	File     string // the file spans are appended to by the file exporter
 }
 
 // LoadConfig loads configuration from environment variables
@@ -53,11 +106,34 @@ func LoadConfig() *Config {
 	viper.SetDefault("DB_USER", "postgres")
 	viper.SetDefault("DB_PASSWORD", "password")
 	viper.SetDefault("DB_NAME", "bioskop_db")
+	viper.SetDefault("DB_MAX_CONNS", 10)
+	viper.SetDefault("DB_MIN_CONNS", 2)
+	viper.SetDefault("DB_MAX_CONN_LIFETIME", "1h")
+	viper.SetDefault("DB_MAX_CONN_IDLE_TIME", "30m")
 	viper.SetDefault("SERVER_PORT", "8080")
 	viper.SetDefault("SERVER_ENV", "development")
+	viper.SetDefault("SERVER_DRAIN_DELAY", "5s")
+	viper.SetDefault("SERVER_TRUST_PROXY", false)
 	viper.SetDefault("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production")
+	viper.SetDefault("JWT_ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("JWT_REFRESH_TOKEN_TTL", "720h")
 	viper.SetDefault("EMAIL_API_URL", "https://lumoshive-academy-email-api.vercel.app/send-email")
 	viper.SetDefault("EMAIL_API_KEY", "")
+	viper.SetDefault("PASSWORD_RESET_URL", "")
+	viper.SetDefault("BOOKING_HOLD_TTL", "10m")
+	viper.SetDefault("BOOKING_HOLD_SWEEP_INTERVAL", "1m")
+	viper.SetDefault("BOOKING_CANCEL_CUTOFF", "2h")
+	viper.SetDefault("BOOKING_REFUND_POLICY", "48:100,24:50")
+	viper.SetDefault("SCREENING_CLEANING_TIME", "15m")
+	viper.SetDefault("PAYMENT_SIMULATOR_MODE", "approve")
+	viper.SetDefault("PAYMENT_WEBHOOK_SECRET", "")
+	viper.SetDefault("PAYMENT_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("PAYMENT_BREAKER_COOLDOWN", "30s")
+	viper.SetDefault("IDEMPOTENCY_LOCK_TIMEOUT", "1m")
+	viper.SetDefault("IDEMPOTENCY_KEY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_SWEEP_INTERVAL", "1h")
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_FILE", "traces.jsonl")
 
 	// Read .env file
 	if err := viper.ReadInConfig(); err != nil {
@@ -68,6 +144,11 @@ func LoadConfig() *Config {
 		}
 	}
 
+	refundPolicy, err := models.ParseRefundPolicy(viper.GetString("BOOKING_REFUND_POLICY"))
+	if err != nil {
+		log.Fatalf("Error reading BOOKING_REFUND_POLICY: %v", err)
+	}
+
 	return &Config{
 		Database: DatabaseConfig{
 			Host:     viper.GetString("DB_HOST"),
@@ -76,17 +157,52 @@ func LoadConfig() *Config {
 			Password: viper.GetString("DB_PASSWORD"),
 			Name:     viper.GetString("DB_NAME"),
 			SSLMode:  "disable",
+
+			MaxConns:        viper.GetInt32("DB_MAX_CONNS"),
+			MinConns:        viper.GetInt32("DB_MIN_CONNS"),
+			MaxConnLifetime: viper.GetDuration("DB_MAX_CONN_LIFETIME"),
+			MaxConnIdleTime: viper.GetDuration("DB_MAX_CONN_IDLE_TIME"),
 		},
 		Server: ServerConfig{
-			Port: viper.GetString("SERVER_PORT"),
-			Env:  viper.GetString("SERVER_ENV"),
+			Port:       viper.GetString("SERVER_PORT"),
+			Env:        viper.GetString("SERVER_ENV"),
+			DrainDelay: viper.GetDuration("SERVER_DRAIN_DELAY"),
+			TrustProxy: viper.GetBool("SERVER_TRUST_PROXY"),
 		},
 		JWT: JWTConfig{
-			Secret: viper.GetString("JWT_SECRET"),
+			Secret:          viper.GetString("JWT_SECRET"),
+			AccessTokenTTL:  viper.GetDuration("JWT_ACCESS_TOKEN_TTL"),
+			RefreshTokenTTL: viper.GetDuration("JWT_REFRESH_TOKEN_TTL"),
 		},
 		Email: EmailConfig{
-			APIURL: viper.GetString("EMAIL_API_URL"),
-			APIKey: viper.GetString("EMAIL_API_KEY"),
+			APIURL:           viper.GetString("EMAIL_API_URL"),
+			APIKey:           viper.GetString("EMAIL_API_KEY"),
+			PasswordResetURL: viper.GetString("PASSWORD_RESET_URL"),
+		},
+		Booking: BookingConfig{
+			HoldTTL:           viper.GetDuration("BOOKING_HOLD_TTL"),
+			HoldSweepInterval: viper.GetDuration("BOOKING_HOLD_SWEEP_INTERVAL"),
+			CancelCutoff:      viper.GetDuration("BOOKING_CANCEL_CUTOFF"),
+			RefundPolicy:      refundPolicy,
+		},
+		Screening: ScreeningConfig{
+			CleaningTime: viper.GetDuration("SCREENING_CLEANING_TIME"),
+		},
+		Payment: PaymentConfig{
+			SimulatorMode: viper.GetString("PAYMENT_SIMULATOR_MODE"),
+			WebhookSecret: viper.GetString("PAYMENT_WEBHOOK_SECRET"),
+
+			BreakerThreshold: viper.GetInt("PAYMENT_BREAKER_THRESHOLD"),
+			BreakerCooldown:  viper.GetDuration("PAYMENT_BREAKER_COOLDOWN"),
+		},
+		Idempotency: IdempotencyConfig{
+			LockTimeout:   viper.GetDuration("IDEMPOTENCY_LOCK_TIMEOUT"),
+			TTL:           viper.GetDuration("IDEMPOTENCY_KEY_TTL"),
+			SweepInterval: viper.GetDuration("IDEMPOTENCY_SWEEP_INTERVAL"),
+		},
+		Tracing: TracingConfig{
+			Exporter: viper.GetString("TRACING_EXPORTER"),
+			File:     viper.GetString("TRACING_FILE"),
 		},
 	}
 }
@@ -96,3 +212,16 @@ func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		c.User, c.Password, c.Host, c.Port, c.Name, c.SSLMode)
 }
+
+// PoolConfig returns the connection pool configuration for the database
+func (c *DatabaseConfig) PoolConfig() (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(c.GetDSN())
+	if err != nil {
+		return nil, err
+	}
+	poolCfg.MaxConns = c.MaxConns
+	poolCfg.MinConns = c.MinConns
+	poolCfg.MaxConnLifetime = c.MaxConnLifetime
+	poolCfg.MaxConnIdleTime = c.MaxConnIdleTime
+	return poolCfg, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// AuditoriumHandler handles auditorium-related HTTP requests
+type AuditoriumHandler struct {
+	auditoriumService *services.AuditoriumService
+	validator         *validator.Validate
+	logger            *zap.Logger
+}
+
+// NewAuditoriumHandler creates a new AuditoriumHandler
+func NewAuditoriumHandler(auditoriumService *services.AuditoriumService, validator *validator.Validate, logger *zap.Logger) *AuditoriumHandler {
+	return &AuditoriumHandler{
+		auditoriumService: auditoriumService,
+		validator:         validator,
+		logger:            logger,
+	}
+}
+
+// GetAuditoriumsByCinema handles listing the auditoriums of a cinema
+func (h *AuditoriumHandler) GetAuditoriumsByCinema(w http.ResponseWriter, r *http.Request) {
+	cinemaID := chi.URLParam(r, "cinemaId")
+	id, err := strconv.Atoi(cinemaID)
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get auditoriums
+	auditoriums, err := h.auditoriumService.GetAuditoriumsByCinema(r.Context(), id)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get auditoriums", zap.Error(err), zap.Int("cinema_id", id))
+		writeError(w, "Failed to get auditoriums", http.StatusInternalServerError)
+		return
+	}
+
+	if auditoriums == nil {
+		writeError(w, "Cinema not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("auditoriums retrieved successfully", zap.Int("cinema_id", id), zap.Int("total", len(auditoriums)))
+	writeJSON(w, auditoriums, http.StatusOK)
+}
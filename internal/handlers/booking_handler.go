@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/andre/project-app-bioskop-golang/internal/middleware"
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
@@ -33,21 +36,21 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, err := middleware.GetUserIDFromContext(r)
 	if err != nil {
-		h.logger.Error("failed to get user id from context", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
 		writeError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	var req models.BookingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("validation error", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
 		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -55,14 +58,19 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	// Create booking
 	response, err := h.bookingService.CreateBooking(r.Context(), userID, &req)
 	if err != nil {
-		h.logger.Error("failed to create booking", zap.Error(err), zap.Int("user_id", userID))
+		if errors.Is(err, models.ErrSeatAlreadyBooked) {
+			tracing.Logger(r.Context(), h.logger).Info("booking rejected, seat already booked", zap.Int("user_id", userID), zap.Int("screening_id", req.ScreeningID))
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to create booking", zap.Error(err), zap.Int("user_id", userID))
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Send async notification using goroutine
 	go func() {
-		h.logger.Info("Sending booking confirmation notification",
+		tracing.Logger(r.Context(), h.logger).Info("Sending booking confirmation notification",
 			zap.Int("booking_id", response.ID),
 			zap.Int("user_id", userID),
 		)
@@ -70,7 +78,7 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		// notificationService.SendBookingConfirmation(...)
 	}()
 
-	h.logger.Info("booking created successfully", zap.Int("booking_id", response.ID), zap.Int("user_id", userID))
+	tracing.Logger(r.Context(), h.logger).Info("booking created successfully", zap.Int("booking_id", response.ID), zap.Int("user_id", userID))
 	writeJSON(w, response, http.StatusCreated)
 }
 
@@ -79,7 +87,7 @@ func (h *BookingHandler) GetUserBookings(w http.ResponseWriter, r *http.Request)
 	// Get user ID from context
 	userID, err := middleware.GetUserIDFromContext(r)
 	if err != nil {
-		h.logger.Error("failed to get user id from context", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
 		writeError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -106,11 +114,54 @@ func (h *BookingHandler) GetUserBookings(w http.ResponseWriter, r *http.Request)
 	// Get user bookings
 	response, err := h.bookingService.GetUserBookings(r.Context(), userID, page, limit)
 	if err != nil {
-		h.logger.Error("failed to get user bookings", zap.Error(err), zap.Int("user_id", userID))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user bookings", zap.Error(err), zap.Int("user_id", userID))
 		writeError(w, "Failed to get bookings", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Info("user bookings retrieved successfully", zap.Int("user_id", userID), zap.Int("total", response.Total))
+	tracing.Logger(r.Context(), h.logger).Info("user bookings retrieved successfully", zap.Int("user_id", userID), zap.Int("total", response.Total))
 	writeJSON(w, response, http.StatusOK)
 }
+
+// CancelBooking handles cancelling a booking of the current user
+func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bookingID := chi.URLParam(r, "bookingId")
+	id, err := strconv.Atoi(bookingID)
+	if err != nil {
+		writeError(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+
+	// Cancel booking
+	booking, err := h.bookingService.CancelBooking(r.Context(), userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrBookingNotOwned):
+			writeError(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, models.ErrBookingNotCancellable), errors.Is(err, models.ErrCancellationWindowClosed),
+			errors.Is(err, models.ErrBookingPaymentInProgress):
+			tracing.Logger(r.Context(), h.logger).Info("booking cancellation rejected", zap.Error(err), zap.Int("booking_id", id))
+			writeError(w, err.Error(), http.StatusConflict)
+		default:
+			tracing.Logger(r.Context(), h.logger).Error("failed to cancel booking", zap.Error(err), zap.Int("booking_id", id))
+			writeError(w, "Failed to cancel booking", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if booking == nil {
+		writeError(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("booking cancelled successfully", zap.Int("booking_id", id), zap.Int("user_id", userID))
+	writeJSON(w, booking, http.StatusOK)
+}
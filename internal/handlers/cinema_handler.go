@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
@@ -60,12 +63,12 @@ func (h *CinemaHandler) GetAllCinemas(w http.ResponseWriter, r *http.Request) {
 	// Get cinemas
 	response, err := h.cinemaService.GetAllCinemas(r.Context(), page, limit, filters)
 	if err != nil {
-		h.logger.Error("failed to get cinemas", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get cinemas", zap.Error(err))
 		writeError(w, "Failed to get cinemas", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Info("cinemas retrieved successfully", zap.Int("total", response.Total))
+	tracing.Logger(r.Context(), h.logger).Info("cinemas retrieved successfully", zap.Int("total", response.Total))
 	writeJSON(w, response, http.StatusOK)
 }
 
@@ -81,7 +84,7 @@ func (h *CinemaHandler) GetCinemaByID(w http.ResponseWriter, r *http.Request) {
 	// Get cinema
 	cinema, err := h.cinemaService.GetCinemaByID(r.Context(), id)
 	if err != nil {
-		h.logger.Error("failed to get cinema", zap.Error(err), zap.Int("cinema_id", id))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get cinema", zap.Error(err), zap.Int("cinema_id", id))
 		writeError(w, "Failed to get cinema", http.StatusInternalServerError)
 		return
 	}
@@ -91,6 +94,130 @@ func (h *CinemaHandler) GetCinemaByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.logger.Info("cinema retrieved successfully", zap.Int("cinema_id", id))
+	tracing.Logger(r.Context(), h.logger).Info("cinema retrieved successfully", zap.Int("cinema_id", id))
 	writeJSON(w, cinema, http.StatusOK)
 }
+
+// CreateCinema handles creating a cinema (super admins only)
+func (h *CinemaHandler) CreateCinema(w http.ResponseWriter, r *http.Request) {
+	var req models.CinemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create cinema
+	cinema, err := h.cinemaService.CreateCinema(r.Context(), &req)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to create cinema", zap.Error(err))
+		writeError(w, "Failed to create cinema", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("cinema created successfully", zap.Int("cinema_id", cinema.ID))
+	writeJSON(w, cinema, http.StatusCreated)
+}
+
+// UpdateCinema handles replacing the details of a cinema
+func (h *CinemaHandler) UpdateCinema(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "cinemaId"))
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CinemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cinema, err := h.cinemaService.UpdateCinema(r.Context(), id, &req)
+	h.writeSavedCinema(w, r, id, cinema, err)
+}
+
+// PatchCinema handles updating some details of a cinema
+func (h *CinemaHandler) PatchCinema(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "cinemaId"))
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CinemaPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cinema, err := h.cinemaService.PatchCinema(r.Context(), id, &req)
+	h.writeSavedCinema(w, r, id, cinema, err)
+}
+
+// writeSavedCinema writes the result of updating a cinema
+func (h *CinemaHandler) writeSavedCinema(w http.ResponseWriter, r *http.Request, id int, cinema *models.Cinema, err error) {
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to update cinema", zap.Error(err), zap.Int("cinema_id", id))
+		writeError(w, "Failed to update cinema", http.StatusInternalServerError)
+		return
+	}
+
+	if cinema == nil {
+		writeError(w, "Cinema not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("cinema updated successfully", zap.Int("cinema_id", id))
+	writeJSON(w, cinema, http.StatusOK)
+}
+
+// DeleteCinema handles archiving a cinema (super admins only)
+func (h *CinemaHandler) DeleteCinema(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "cinemaId"))
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.cinemaService.DeleteCinema(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, models.ErrCinemaNotFound) {
+			writeError(w, "Cinema not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, models.ErrCinemaHasFutureBookings) {
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to delete cinema", zap.Error(err), zap.Int("cinema_id", id))
+		writeError(w, "Failed to delete cinema", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("cinema archived successfully", zap.Int("cinema_id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
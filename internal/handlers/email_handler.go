@@ -6,6 +6,7 @@ import (
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
@@ -32,14 +33,14 @@ func (h *EmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("Failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("Validation failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Validation failed", zap.Error(err))
 		writeError(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -47,7 +48,7 @@ func (h *EmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	// Verify OTP
 	err := h.emailService.VerifyOTP(r.Context(), req.Email, req.OTPCode)
 	if err != nil {
-		h.logger.Error("OTP verification failed", zap.Error(err), zap.String("email", req.Email))
+		tracing.Logger(r.Context(), h.logger).Error("OTP verification failed", zap.Error(err), zap.String("email", req.Email))
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -59,7 +60,7 @@ func (h *EmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, response, http.StatusOK)
-	h.logger.Info("Email verified", zap.String("email", req.Email))
+	tracing.Logger(r.Context(), h.logger).Info("Email verified", zap.String("email", req.Email))
 }
 
 // ResendOTP handles POST /api/resend-otp
@@ -68,14 +69,14 @@ func (h *EmailHandler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 
 	// Decode request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("Failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("Validation failed", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("Validation failed", zap.Error(err))
 		writeError(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -83,7 +84,7 @@ func (h *EmailHandler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 	// Resend OTP
 	err := h.emailService.ResendOTP(r.Context(), req.Email)
 	if err != nil {
-		h.logger.Error("Failed to resend OTP", zap.Error(err), zap.String("email", req.Email))
+		tracing.Logger(r.Context(), h.logger).Error("Failed to resend OTP", zap.Error(err), zap.String("email", req.Email))
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -95,5 +96,5 @@ func (h *EmailHandler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, response, http.StatusOK)
-	h.logger.Info("OTP resent", zap.String("email", req.Email))
+	tracing.Logger(r.Context(), h.logger).Info("OTP resent", zap.String("email", req.Email))
 }
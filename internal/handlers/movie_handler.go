@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// MovieHandler handles movie-related HTTP requests
+type MovieHandler struct {
+	movieService *services.MovieService
+	validator    *validator.Validate
+	logger       *zap.Logger
+}
+
+// NewMovieHandler creates a new MovieHandler
+func NewMovieHandler(movieService *services.MovieService, validator *validator.Validate, logger *zap.Logger) *MovieHandler {
+	return &MovieHandler{
+		movieService: movieService,
+		validator:    validator,
+		logger:       logger,
+	}
+}
+
+// GetAllMovies handles getting all movies
+func (h *MovieHandler) GetAllMovies(w http.ResponseWriter, r *http.Request) {
+	// Get query parameters
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+	title := r.URL.Query().Get("title")
+	genre := r.URL.Query().Get("genre")
+	nowShowing, _ := strconv.ParseBool(r.URL.Query().Get("now_showing"))
+
+	page := 1
+	limit := 10
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	filters := &models.MovieFilters{
+		Page:       page,
+		Limit:      limit,
+		Title:      title,
+		Genre:      genre,
+		NowShowing: nowShowing,
+	}
+
+	// Get movies
+	response, err := h.movieService.GetAllMovies(r.Context(), page, limit, filters)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get movies", zap.Error(err))
+		writeError(w, "Failed to get movies", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("movies retrieved successfully", zap.Int("total", response.Total))
+	writeJSON(w, response, http.StatusOK)
+}
+
+// GetMovieByID handles getting a movie by ID
+func (h *MovieHandler) GetMovieByID(w http.ResponseWriter, r *http.Request) {
+	movieID := chi.URLParam(r, "movieId")
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		writeError(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get movie
+	movie, err := h.movieService.GetMovieByID(r.Context(), id)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get movie", zap.Error(err), zap.Int("movie_id", id))
+		writeError(w, "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+
+	if movie == nil {
+		writeError(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("movie retrieved successfully", zap.Int("movie_id", id))
+	writeJSON(w, movie, http.StatusOK)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// PasswordHandler handles password reset HTTP requests
+type PasswordHandler struct {
+	passwordResetService *services.PasswordResetService
+	validator            *validator.Validate
+	logger               *zap.Logger
+}
+
+// NewPasswordHandler creates a new PasswordHandler
+func NewPasswordHandler(passwordResetService *services.PasswordResetService, validator *validator.Validate, logger *zap.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		passwordResetService: passwordResetService,
+		validator:            validator,
+		logger:               logger,
+	}
+}
+
+// ForgotPassword handles POST /api/password/forgot
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(r.Context(), req.Email); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to request password reset", zap.Error(err))
+		writeError(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	// The same answer for every email, so it does not tell which ones have accounts
+	writeJSON(w, map[string]string{
+		"message": "If the email is registered, a password reset code has been sent to it",
+	}, http.StatusOK)
+}
+
+// ResetPassword handles POST /api/password/reset
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.passwordResetService.ResetPassword(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidResetCode) {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to reset password", zap.Error(err))
+		writeError(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("password reset")
+	writeJSON(w, map[string]string{"message": "Password has been reset, please log in again"}, http.StatusOK)
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/andre/project-app-bioskop-golang/internal/middleware"
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
@@ -32,12 +36,12 @@ func (h *PaymentHandler) GetPaymentMethods(w http.ResponseWriter, r *http.Reques
 	// Get payment methods
 	methods, err := h.paymentService.GetPaymentMethods(r.Context())
 	if err != nil {
-		h.logger.Error("failed to get payment methods", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get payment methods", zap.Error(err))
 		writeError(w, "Failed to get payment methods", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Info("payment methods retrieved successfully")
+	tracing.Logger(r.Context(), h.logger).Info("payment methods retrieved successfully")
 	writeJSON(w, methods, http.StatusOK)
 }
 
@@ -46,21 +50,21 @@ func (h *PaymentHandler) ProcessPayment(w http.ResponseWriter, r *http.Request)
 	// Get user ID from context
 	userID, err := middleware.GetUserIDFromContext(r)
 	if err != nil {
-		h.logger.Error("failed to get user id from context", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
 		writeError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	var req models.PaymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("validation error", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
 		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -68,22 +72,135 @@ func (h *PaymentHandler) ProcessPayment(w http.ResponseWriter, r *http.Request)
 	// Process payment
 	response, err := h.paymentService.ProcessPayment(r.Context(), userID, &req)
 	if err != nil {
-		h.logger.Error("failed to process payment", zap.Error(err), zap.Int("user_id", userID))
-		writeError(w, err.Error(), http.StatusBadRequest)
+		switch {
+		case errors.Is(err, models.ErrPaymentDeclined):
+			tracing.Logger(r.Context(), h.logger).Info("payment declined", zap.Error(err), zap.Int("booking_id", req.BookingID))
+			writeError(w, err.Error(), http.StatusPaymentRequired)
+		case errors.Is(err, models.ErrPaymentAlreadyExists):
+			tracing.Logger(r.Context(), h.logger).Info("payment rejected, booking already has a payment", zap.Int("booking_id", req.BookingID))
+			writeError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrBookingNotPayable):
+			tracing.Logger(r.Context(), h.logger).Info("payment rejected, booking is not pending", zap.Int("booking_id", req.BookingID))
+			writeError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrGatewayTimeout):
+			tracing.Logger(r.Context(), h.logger).Error("payment gateway timed out", zap.Int("booking_id", req.BookingID))
+			writeError(w, err.Error(), http.StatusGatewayTimeout)
+		case errors.Is(err, models.ErrGatewayUnavailable):
+			tracing.Logger(r.Context(), h.logger).Error("payment gateway unavailable", zap.Int("booking_id", req.BookingID))
+			writeError(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			tracing.Logger(r.Context(), h.logger).Error("failed to process payment", zap.Error(err), zap.Int("user_id", userID))
+			writeError(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	// The provider settles async charges later; the booking stays pending until then
+	if response.Status == "pending" {
+		tracing.Logger(r.Context(), h.logger).Info("payment pending", zap.Int("payment_id", response.ID), zap.Int("booking_id", response.BookingID))
+		writeJSON(w, response, http.StatusAccepted)
 		return
 	}
 
 	// Send async payment confirmation using goroutine
 	go func() {
-		h.logger.Info("Sending payment confirmation notification",
+		tracing.Logger(r.Context(), h.logger).Info("Sending payment confirmation notification",
 			zap.Int("payment_id", response.ID),
 			zap.Int("booking_id", response.BookingID),
-			zap.Float64("amount", response.Amount),
+			zap.Stringer("amount", response.Amount),
 		)
 		// In production: Send payment receipt via email
 		// notificationService.SendPaymentConfirmation(...)
 	}()
 
-	h.logger.Info("payment processed successfully", zap.Int("payment_id", response.ID), zap.Int("user_id", userID))
+	tracing.Logger(r.Context(), h.logger).Info("payment processed successfully", zap.Int("payment_id", response.ID), zap.Int("user_id", userID))
 	writeJSON(w, response, http.StatusCreated)
 }
+
+// RefundPayment handles issuing a full or partial refund for a payment (cinema admins of
+// the payment's cinema and super admins only)
+func (h *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	identity, err := middleware.GetIdentityFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get identity from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID := chi.URLParam(r, "paymentId")
+	id, err := strconv.Atoi(paymentID)
+	if err != nil {
+		writeError(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Refund payment
+	refund, err := h.paymentService.RefundPayment(r.Context(), identity, id, &req)
+	if err != nil {
+		if errors.Is(err, models.ErrCinemaAccessDenied) {
+			tracing.Logger(r.Context(), h.logger).Info("refund forbidden", zap.Int("payment_id", id), zap.Int("user_id", identity.UserID))
+			writeError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, models.ErrPaymentNotRefundable) || errors.Is(err, models.ErrRefundExceedsPayment) {
+			tracing.Logger(r.Context(), h.logger).Info("refund rejected", zap.Error(err), zap.Int("payment_id", id))
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to refund payment", zap.Error(err), zap.Int("payment_id", id))
+		writeError(w, "Failed to refund payment", http.StatusInternalServerError)
+		return
+	}
+
+	if refund == nil {
+		writeError(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("payment refunded successfully", zap.Int("payment_id", id), zap.Int("refund_id", refund.ID),
+		zap.Stringer("amount", refund.Amount))
+	writeJSON(w, refund, http.StatusCreated)
+}
+
+// GetPaymentByReference handles looking up a payment of the current user by the reference on its receipt
+func (h *PaymentHandler) GetPaymentByReference(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserIDFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reference := chi.URLParam(r, "reference")
+
+	// Get payment
+	payment, err := h.paymentService.GetPaymentByReference(r.Context(), userID, reference)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get payment", zap.Error(err), zap.String("reference", reference))
+		writeError(w, "Failed to get payment", http.StatusInternalServerError)
+		return
+	}
+
+	if payment == nil {
+		writeError(w, "Payment not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("payment retrieved successfully", zap.Int("payment_id", payment.ID), zap.Int("user_id", userID))
+	writeJSON(w, payment, http.StatusOK)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// maxWebhookBodySize limits the size of a payment provider callback
+const maxWebhookBodySize = 1 << 20
+
+// PaymentWebhookHandler handles payment provider callbacks
+type PaymentWebhookHandler struct {
+	webhookService *services.PaymentWebhookService
+	logger         *zap.Logger
+}
+
+// NewPaymentWebhookHandler creates a new PaymentWebhookHandler
+func NewPaymentWebhookHandler(webhookService *services.PaymentWebhookService, logger *zap.Logger) *PaymentWebhookHandler {
+	return &PaymentWebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// HandleWebhook handles a signed payment status callback from a provider
+func (h *PaymentWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+
+	// The signature covers the raw body, so read it before decoding
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to read webhook body", zap.Error(err), zap.String("provider", provider))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, applied, err := h.webhookService.HandleWebhook(r.Context(), provider, body, r.Header.Get("X-Signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUnknownWebhookProvider):
+			writeError(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidWebhookSignature):
+			tracing.Logger(r.Context(), h.logger).Warn("rejected webhook with invalid signature", zap.String("provider", provider))
+			writeError(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, models.ErrPaymentNotFound):
+			tracing.Logger(r.Context(), h.logger).Warn("webhook for unknown payment", zap.String("provider", provider))
+			writeError(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidWebhookPayload):
+			tracing.Logger(r.Context(), h.logger).Warn("rejected invalid webhook payload", zap.Error(err), zap.String("provider", provider))
+			writeError(w, err.Error(), http.StatusBadRequest)
+		default:
+			// Answer with a 5xx so the provider redelivers the event once we recover
+			tracing.Logger(r.Context(), h.logger).Error("failed to handle webhook", zap.Error(err), zap.String("provider", provider))
+			writeError(w, "Failed to handle webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Providers redeliver until they get a 2xx, so duplicates are acknowledged too
+	tracing.Logger(r.Context(), h.logger).Info("payment webhook handled",
+		zap.String("provider", provider),
+		zap.String("event_id", event.EventID),
+		zap.Int("payment_id", event.PaymentID),
+		zap.Bool("duplicate", !applied),
+	)
+	writeJSON(w, event, http.StatusOK)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andre/project-app-bioskop-golang/internal/middleware"
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ScreeningHandler handles screening-related HTTP requests
+type ScreeningHandler struct {
+	screeningService *services.ScreeningService
+	validator        *validator.Validate
+	logger           *zap.Logger
+}
+
+// NewScreeningHandler creates a new ScreeningHandler
+func NewScreeningHandler(screeningService *services.ScreeningService, validator *validator.Validate, logger *zap.Logger) *ScreeningHandler {
+	return &ScreeningHandler{
+		screeningService: screeningService,
+		validator:        validator,
+		logger:           logger,
+	}
+}
+
+// GetScreeningsByCinema handles listing a cinema's screenings for a date
+func (h *ScreeningHandler) GetScreeningsByCinema(w http.ResponseWriter, r *http.Request) {
+	cinemaID := chi.URLParam(r, "cinemaId")
+	id, err := strconv.Atoi(cinemaID)
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+
+	// Get screenings
+	screenings, err := h.screeningService.GetScreeningsByCinema(r.Context(), id, date)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get screenings", zap.Error(err), zap.Int("cinema_id", id))
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("screenings retrieved successfully", zap.Int("cinema_id", id), zap.String("date", date))
+	writeJSON(w, screenings, http.StatusOK)
+}
+
+// GetScreeningByID handles getting a screening by ID
+func (h *ScreeningHandler) GetScreeningByID(w http.ResponseWriter, r *http.Request) {
+	screeningID := chi.URLParam(r, "screeningId")
+	id, err := strconv.Atoi(screeningID)
+	if err != nil {
+		writeError(w, "Invalid screening ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get screening
+	screening, err := h.screeningService.GetScreeningByID(r.Context(), id)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get screening", zap.Error(err), zap.Int("screening_id", id))
+		writeError(w, "Failed to get screening", http.StatusInternalServerError)
+		return
+	}
+
+	if screening == nil {
+		writeError(w, "Screening not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("screening retrieved successfully", zap.Int("screening_id", id))
+	writeJSON(w, screening, http.StatusOK)
+}
+
+// CreateSchedule handles scheduling a movie on a recurring pattern; with ?preview=true the
+// screenings are only listed, not created
+func (h *ScreeningHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	identity, err := middleware.GetIdentityFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get identity from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	preview := false
+	if value := r.URL.Query().Get("preview"); value != "" {
+		preview, err = strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, "Invalid preview flag", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var req models.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.screeningService.CreateSchedule(r.Context(), identity, &req, preview)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCinemaAccessDenied):
+			tracing.Logger(r.Context(), h.logger).Info("schedule forbidden", zap.Int("auditorium_id", req.AuditoriumID), zap.Int("user_id", identity.UserID))
+			writeError(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, models.ErrInvalidSchedule), errors.Is(err, models.ErrAuditoriumNotFound), errors.Is(err, models.ErrMovieNotFound):
+			writeError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrCinemaArchived):
+			writeError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrScheduleConflict):
+			tracing.Logger(r.Context(), h.logger).Info("schedule rejected", zap.Error(err), zap.Int("auditorium_id", req.AuditoriumID))
+			writeError(w, err.Error(), http.StatusConflict)
+		default:
+			tracing.Logger(r.Context(), h.logger).Error("failed to create schedule", zap.Error(err), zap.Int("auditorium_id", req.AuditoriumID))
+			writeError(w, "Failed to create schedule", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if preview {
+		writeJSON(w, schedule, http.StatusOK)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("schedule created successfully", zap.Int("auditorium_id", req.AuditoriumID), zap.Int("total", schedule.Total))
+	writeJSON(w, schedule, http.StatusCreated)
+}
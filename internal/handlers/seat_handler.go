@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
@@ -26,32 +30,108 @@ func NewSeatHandler(seatService *services.SeatService, validator *validator.Vali
 	}
 }
 
-// GetSeatAvailability handles getting seat availability
+// GetSeatAvailability handles getting seat availability for a screening
 func (h *SeatHandler) GetSeatAvailability(w http.ResponseWriter, r *http.Request) {
-	cinemaID := chi.URLParam(r, "cinemaId")
-	id, err := strconv.Atoi(cinemaID)
+	screeningID := chi.URLParam(r, "screeningId")
+	id, err := strconv.Atoi(screeningID)
 	if err != nil {
-		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		writeError(w, "Invalid screening ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get query parameters
-	date := r.URL.Query().Get("date")
-	time := r.URL.Query().Get("time")
+	// Get seat availability
+	response, err := h.seatService.GetSeatAvailability(r.Context(), id)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get seat availability", zap.Error(err), zap.Int("screening_id", id))
+		writeError(w, "Failed to get seat availability", http.StatusInternalServerError)
+		return
+	}
 
-	if date == "" || time == "" {
-		writeError(w, "Missing date or time parameter", http.StatusBadRequest)
+	if response == nil {
+		writeError(w, "Screening not found", http.StatusNotFound)
 		return
 	}
 
-	// Get seat availability
-	response, err := h.seatService.GetSeatAvailability(r.Context(), id, date, time)
+	tracing.Logger(r.Context(), h.logger).Info("seat availability retrieved successfully", zap.Int("screening_id", id))
+	writeJSON(w, response, http.StatusOK)
+}
+
+// GetSeatMap handles getting the seat map of an auditorium
+func (h *SeatHandler) GetSeatMap(w http.ResponseWriter, r *http.Request) {
+	cinemaID, auditoriumID, ok := auditoriumParams(w, r)
+	if !ok {
+		return
+	}
+
+	seatMap, err := h.seatService.GetSeatMap(r.Context(), cinemaID, auditoriumID)
 	if err != nil {
-		h.logger.Error("failed to get seat availability", zap.Error(err), zap.Int("cinema_id", id))
-		writeError(w, err.Error(), http.StatusBadRequest)
+		tracing.Logger(r.Context(), h.logger).Error("failed to get seat map", zap.Error(err), zap.Int("auditorium_id", auditoriumID))
+		writeError(w, "Failed to get seat map", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Info("seat availability retrieved successfully", zap.Int("cinema_id", id), zap.String("date", date), zap.String("time", time))
-	writeJSON(w, response, http.StatusOK)
+	if seatMap == nil {
+		writeError(w, "Auditorium not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("seat map retrieved successfully", zap.Int("auditorium_id", auditoriumID))
+	writeJSON(w, seatMap, http.StatusOK)
+}
+
+// UpdateSeatMap handles uploading the seat layout of an auditorium
+func (h *SeatHandler) UpdateSeatMap(w http.ResponseWriter, r *http.Request) {
+	cinemaID, auditoriumID, ok := auditoriumParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SeatMapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	seatMap, err := h.seatService.UpdateSeatMap(r.Context(), cinemaID, auditoriumID, &req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSeatMap) {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to update seat map", zap.Error(err), zap.Int("auditorium_id", auditoriumID))
+		writeError(w, "Failed to update seat map", http.StatusInternalServerError)
+		return
+	}
+
+	if seatMap == nil {
+		writeError(w, "Auditorium not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("seat map updated successfully", zap.Int("auditorium_id", auditoriumID), zap.Int("total_seats", seatMap.TotalSeats))
+	writeJSON(w, seatMap, http.StatusOK)
+}
+
+// auditoriumParams reads the cinema and auditorium IDs from the URL, writing a 400 if either is invalid
+func auditoriumParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	cinemaID, err := strconv.Atoi(chi.URLParam(r, "cinemaId"))
+	if err != nil {
+		writeError(w, "Invalid cinema ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	auditoriumID, err := strconv.Atoi(chi.URLParam(r, "auditoriumId"))
+	if err != nil {
+		writeError(w, "Invalid auditorium ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return cinemaID, auditoriumID, true
 }
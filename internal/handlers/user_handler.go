@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/andre/project-app-bioskop-golang/internal/middleware"
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 )
@@ -31,14 +36,14 @@ func NewUserHandler(userService *services.UserService, validator *validator.Vali
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.UserRegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("validation error", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
 		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -46,12 +51,12 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Register user
 	user, err := h.userService.RegisterUser(r.Context(), &req)
 	if err != nil {
-		h.logger.Error("failed to register user", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to register user", zap.Error(err))
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	h.logger.Info("user registered successfully", zap.Int("user_id", user.ID))
+	tracing.Logger(r.Context(), h.logger).Info("user registered successfully", zap.Int("user_id", user.ID))
 	writeJSON(w, user, http.StatusCreated)
 }
 
@@ -59,27 +64,61 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.UserLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
 		writeError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate request
 	if err := h.validator.Struct(req); err != nil {
-		h.logger.Error("validation error", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
 		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Login user
-	response, err := h.userService.LoginUser(r.Context(), &req)
+	response, err := h.userService.LoginUser(r.Context(), &req, clientInfo(r))
 	if err != nil {
-		h.logger.Error("failed to login user", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to login user", zap.Error(err))
 		writeError(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	h.logger.Info("user logged in successfully", zap.Int("user_id", response.ID))
+	tracing.Logger(r.Context(), h.logger).Info("user logged in successfully", zap.Int("user_id", response.ID))
+	writeJSON(w, response, http.StatusOK)
+}
+
+// RefreshToken handles exchanging a refresh token for new tokens
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.TokenRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.userService.RefreshToken(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRefreshTokenReused):
+			tracing.Logger(r.Context(), h.logger).Warn("refresh token reused, session revoked")
+			writeError(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, models.ErrInvalidRefreshToken):
+			writeError(w, err.Error(), http.StatusUnauthorized)
+		default:
+			tracing.Logger(r.Context(), h.logger).Error("failed to refresh token", zap.Error(err))
+			writeError(w, "Failed to refresh token", http.StatusInternalServerError)
+		}
+		return
+	}
+
 	writeJSON(w, response, http.StatusOK)
 }
 
@@ -88,7 +127,7 @@ func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	// Get token from context
 	token, err := middleware.GetTokenFromContext(r)
 	if err != nil {
-		h.logger.Error("failed to get token from context", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get token from context", zap.Error(err))
 		writeError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -96,12 +135,12 @@ func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	// Logout user
 	err = h.userService.LogoutUser(r.Context(), token)
 	if err != nil {
-		h.logger.Error("failed to logout user", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to logout user", zap.Error(err))
 		writeError(w, "Failed to logout", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Info("user logged out successfully")
+	tracing.Logger(r.Context(), h.logger).Info("user logged out successfully")
 	writeJSON(w, map[string]string{"message": "Logout successful"}, http.StatusOK)
 }
 
@@ -110,7 +149,7 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, err := middleware.GetUserIDFromContext(r)
 	if err != nil {
-		h.logger.Error("failed to get user id from context", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
 		writeError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -118,7 +157,7 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	// Get user
 	user, err := h.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
-		h.logger.Error("failed to get user", zap.Error(err))
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user", zap.Error(err))
 		writeError(w, "Failed to get user", http.StatusInternalServerError)
 		return
 	}
@@ -130,3 +169,125 @@ func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, user, http.StatusOK)
 }
+
+// GetSessions handles listing the user's active sessions
+func (h *UserHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	identity, err := middleware.GetIdentityFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get identity from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(r.Context(), identity.UserID, identity.SessionID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to list sessions", zap.Error(err), zap.Int("user_id", identity.UserID))
+		writeError(w, "Failed to get sessions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, sessions, http.StatusOK)
+}
+
+// RevokeSession handles logging the user out of one of their sessions
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		writeError(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.userService.RevokeSession(r.Context(), userID, sessionID)
+	if err != nil {
+		if errors.Is(err, models.ErrSessionNotFound) {
+			writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to revoke session", zap.Error(err), zap.Int("user_id", userID))
+		writeError(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("session revoked", zap.Int("user_id", userID), zap.Int("session_id", sessionID))
+	writeJSON(w, map[string]string{"message": "Session revoked"}, http.StatusOK)
+}
+
+// RevokeAllSessions handles logging the user out everywhere
+func (h *UserHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserIDFromContext(r)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to get user id from context", zap.Error(err))
+		writeError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := h.userService.RevokeAllSessions(r.Context(), userID)
+	if err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to revoke sessions", zap.Error(err), zap.Int("user_id", userID))
+		writeError(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("all sessions revoked", zap.Int("user_id", userID), zap.Int("revoked", revoked))
+	writeJSON(w, map[string]any{"message": "Logged out everywhere", "revoked": revoked}, http.StatusOK)
+}
+
+// ChangeUserRole handles giving a user a new role (super admins only)
+func (h *UserHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userId"))
+	if err != nil {
+		writeError(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("failed to decode request", zap.Error(err))
+		writeError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		tracing.Logger(r.Context(), h.logger).Error("validation error", zap.Error(err))
+		writeError(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Change role
+	user, err := h.userService.ChangeUserRole(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCinemaAssignment) || errors.Is(err, models.ErrCinemaNotFound) {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tracing.Logger(r.Context(), h.logger).Error("failed to change user role", zap.Error(err), zap.Int("user_id", userID))
+		writeError(w, "Failed to change user role", http.StatusInternalServerError)
+		return
+	}
+
+	if user == nil {
+		writeError(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	tracing.Logger(r.Context(), h.logger).Info("user role changed", zap.Int("user_id", userID), zap.String("role", user.Role))
+	writeJSON(w, user, http.StatusOK)
+}
+
+// clientInfo describes the device a request comes from. Behind a trusted proxy, RealIP
+// has already replaced the remote address with the client's address.
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.ClientInfo{UserAgent: r.UserAgent(), IPAddress: ip}
+}
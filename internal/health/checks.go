@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andre/project-app-bioskop-golang/internal/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseCheck pings the database through the pool and reports its connections
+func DatabaseCheck(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		stat := pool.Stat()
+		details := map[string]any{
+			"total_conns": stat.TotalConns(),
+			"idle_conns":  stat.IdleConns(),
+			"max_conns":   stat.MaxConns(),
+		}
+		return details, pool.Ping(ctx)
+	}
+}
+
+// MigrationsCheck fails while the database has pending migrations or was migrated by another build
+func MigrationsCheck(migrator *migrations.Migrator) CheckFunc {
+	return func(ctx context.Context) (map[string]any, error) {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]any{"pending": len(pending)}
+		if len(pending) > 0 {
+			return details, fmt.Errorf("%d migrations pending", len(pending))
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,138 @@
+// Package health serves the liveness and readiness probes of the server
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Component statuses
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc checks a dependency, returning details worth reporting and an error while it is down
+type CheckFunc func(ctx context.Context) (map[string]any, error)
+
+// ComponentReport is the outcome of checking one dependency
+type ComponentReport struct {
+	Name      string         `json:"name"`
+	Status    string         `json:"status"`
+	Critical  bool           `json:"critical"` // readiness fails while a critical component is down
+	LatencyMS float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report is the body of a probe response
+type Report struct {
+	Status     string             `json:"status"` // alive, ready, not_ready or shutting_down
+	Components []*ComponentReport `json:"components,omitempty"`
+}
+
+type component struct {
+	name     string
+	critical bool
+	check    CheckFunc
+}
+
+// Probe checks the dependencies of the server
+type Probe struct {
+	timeout      time.Duration
+	components   []component
+	shuttingDown atomic.Bool
+}
+
+// NewProbe creates a new Probe whose checks each get timeout to answer
+func NewProbe(timeout time.Duration) *Probe {
+	return &Probe{timeout: timeout}
+}
+
+// Add checks a component on readiness; while a critical one is down the server is not ready,
+// while another is down it is only reported
+func (p *Probe) Add(name string, critical bool, check CheckFunc) {
+	p.components = append(p.components, component{name: name, critical: critical, check: check})
+}
+
+// Shutdown makes readiness fail from now on, so load balancers drain the server before it stops
+func (p *Probe) Shutdown() {
+	p.shuttingDown.Store(true)
+}
+
+// Ready checks every component concurrently and reports whether the server can take traffic
+func (p *Probe) Ready(ctx context.Context) *Report {
+	report := &Report{Status: "ready", Components: make([]*ComponentReport, len(p.components))}
+
+	var wg sync.WaitGroup
+	for i, c := range p.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[i] = p.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range report.Components {
+		if c.Critical && c.Status == StatusDown {
+			report.Status = "not_ready"
+		}
+	}
+	if p.shuttingDown.Load() {
+		report.Status = "shutting_down"
+	}
+	return report
+}
+
+// run checks one component within the probe's timeout
+func (p *Probe) run(ctx context.Context, c component) *ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := c.check(ctx)
+	report := &ComponentReport{
+		Name:      c.name,
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		report.Status = StatusDown
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// LiveHandler answers whether the process is running; it does not check dependencies,
+// so an orchestrator does not restart the server for an outage elsewhere
+func (p *Probe) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, &Report{Status: "alive"}, http.StatusOK)
+	})
+}
+
+// ReadyHandler answers whether the server can take traffic: 200 when ready, 503 otherwise
+func (p *Probe) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := p.Ready(r.Context())
+		statusCode := http.StatusOK
+		if report.Status != "ready" {
+			statusCode = http.StatusServiceUnavailable
+		}
+		writeReport(w, report, statusCode)
+	})
+}
+
+func writeReport(w http.ResponseWriter, report *Report, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(report)
+}
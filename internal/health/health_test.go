@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(ctx context.Context) (map[string]any, error) {
+	return map[string]any{"ok": true}, nil
+}
+
+func down(ctx context.Context) (map[string]any, error) {
+	return nil, errors.New("connection refused")
+}
+
+func serveReady(t *testing.T, probe *Probe) (int, Report) {
+	rec := httptest.NewRecorder()
+	probe.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestReady_AllUp(t *testing.T) {
+	probe := NewProbe(time.Second)
+	probe.Add("database", true, up)
+	probe.Add("email_api", false, up)
+
+	code, report := serveReady(t, probe)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", report.Status)
+	require.Len(t, report.Components, 2)
+	assert.Equal(t, "database", report.Components[0].Name)
+	assert.Equal(t, StatusUp, report.Components[0].Status)
+	assert.Equal(t, true, report.Components[0].Details["ok"])
+}
+
+func TestReady_CriticalDown(t *testing.T) {
+	probe := NewProbe(time.Second)
+	probe.Add("database", true, down)
+	probe.Add("email_api", false, up)
+
+	code, report := serveReady(t, probe)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", report.Status)
+	assert.Equal(t, StatusDown, report.Components[0].Status)
+	assert.Equal(t, "connection refused", report.Components[0].Error)
+}
+
+func TestReady_NonCriticalDownStaysReady(t *testing.T) {
+	probe := NewProbe(time.Second)
+	probe.Add("database", true, up)
+	probe.Add("payment_gateway", false, down)
+
+	code, report := serveReady(t, probe)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", report.Status)
+	assert.Equal(t, StatusDown, report.Components[1].Status)
+}
+
+func TestReady_CheckTimesOut(t *testing.T) {
+	probe := NewProbe(10 * time.Millisecond)
+	probe.Add("database", true, func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	report := probe.Ready(context.Background())
+
+	assert.Equal(t, "not_ready", report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components[0].Error)
+}
+
+func TestReady_ShuttingDown(t *testing.T) {
+	probe := NewProbe(time.Second)
+	probe.Add("database", true, up)
+	probe.Shutdown()
+
+	code, report := serveReady(t, probe)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting_down", report.Status)
+}
+
+func TestLive_IgnoresDependencies(t *testing.T) {
+	probe := NewProbe(time.Second)
+	probe.Add("database", true, down)
+
+	rec := httptest.NewRecorder()
+	probe.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"alive"}`, rec.Body.String())
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter_Write(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("requests_total", "Requests handled.", "method", "status")
+	registry.NewCounter("errors_total", "Errors.")
+
+	requests.Inc("GET", "200")
+	requests.Inc("GET", "200")
+	requests.Add(3, "POST", "201")
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+
+	assert.Equal(t, `# HELP errors_total Errors.
+# TYPE errors_total counter
+errors_total 0
+# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{method="GET",status="200"} 2
+requests_total{method="POST",status="201"} 3
+`, out.String())
+}
+
+func TestHistogram_Write(t *testing.T) {
+	registry := NewRegistry()
+	latency := registry.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(2, "/a")
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 2.55
+latency_seconds_count{route="/a"} 3
+`, out.String())
+}
+
+func TestWrite_EscapesLabelValues(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("events_total", "Events with \\ and\nnewline.", "name")
+
+	counter.Inc("say \"hi\"\n")
+
+	var out strings.Builder
+	require.NoError(t, registry.Write(&out))
+
+	assert.Contains(t, out.String(), `# HELP events_total Events with \\ and\nnewline.`)
+	assert.Contains(t, out.String(), `events_total{name="say \"hi\"\n"} 1`)
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("requests_total", "Requests.")
+
+	assert.Panics(t, func() { registry.NewCounter("requests_total", "Requests.") })
+}
+
+func TestCounter_WrongLabelCountPanics(t *testing.T) {
+	counter := NewRegistry().NewCounter("requests_total", "Requests.", "method")
+
+	assert.Panics(t, func() { counter.Inc() })
+}
+
+func TestHandler_ServesTextFormat(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("requests_total", "Requests.").Inc()
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "requests_total 1\n")
+}
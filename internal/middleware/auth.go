@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/services"
 )
 
@@ -30,14 +31,15 @@ func AuthMiddleware(userService *services.UserService) func(http.Handler) http.H
 			token := parts[1]
 
 			// Verify token
-			userID, err := userService.VerifyToken(r.Context(), token)
+			identity, err := userService.VerifyToken(r.Context(), token)
 			if err != nil {
 				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 				return
 			}
 
-			// Add user ID to context
-			ctx := context.WithValue(r.Context(), "userID", userID)
+			// Add user ID and identity to context
+			ctx := context.WithValue(r.Context(), "userID", identity.UserID)
+			ctx = context.WithValue(ctx, "identity", identity)
 			ctx = context.WithValue(ctx, "token", token)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -53,6 +55,15 @@ func GetUserIDFromContext(r *http.Request) (int, error) {
 	return userID, nil
 }
 
+// GetIdentityFromContext extracts the authenticated user's identity from context
+func GetIdentityFromContext(r *http.Request) (*models.Identity, error) {
+	identity, ok := r.Context().Value("identity").(*models.Identity)
+	if !ok {
+		return nil, errors.New("identity not found in context")
+	}
+	return identity, nil
+}
+
 // GetTokenFromContext extracts token from context
 func GetTokenFromContext(r *http.Request) (string, error) {
 	token, ok := r.Context().Value("token").(string)
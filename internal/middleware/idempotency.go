@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxIdempotentBodySize limits the size of a request body kept for idempotency checks
+const maxIdempotentBodySize = 1 << 20
+
+// IdempotencyStore keeps the requests made with an Idempotency-Key and their responses
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, staleBefore time.Time) (*models.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, id int, lockedAt time.Time, statusCode int, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, id int, lockedAt time.Time) error
+}
+
+// IdempotencyMiddleware makes POSTs sent with an Idempotency-Key header safe to retry.
+// The first request with a key runs normally and its response is stored; retries with
+// the same key and body get that response replayed, and reusing the key for a different
+// request is rejected with 422. Keys are scoped per user, so it must run after AuthMiddleware.
+// Responses with a 5xx status or a panicking handler are not stored, so the request can be
+// retried with the same key. A key still reserved lockTimeout after its request started is
+// considered abandoned and can be claimed by a retry; the abandoned request then neither
+// stores its response nor frees the key. Stored responses are kept for ttl.
+func IdempotencyMiddleware(store IdempotencyStore, lockTimeout, ttl time.Duration, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+				return
+			}
+
+			userID, err := GetUserIDFromContext(r)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			record, reserved, err := store.ReserveIdempotencyKey(r.Context(), &models.IdempotencyKey{
+				UserID:      userID,
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: hashRequest(r, body),
+				LockedAt:    &now,
+				ExpiresAt:   now.Add(ttl),
+			}, now.Add(-lockTimeout))
+			if err != nil {
+				logger.Error("failed to reserve idempotency key", zap.Error(err), zap.Int("user_id", userID))
+				http.Error(w, "Failed to process request", http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				replayResponse(w, r, record, body)
+				return
+			}
+
+			// Server errors and panics are not final; free the key so the client can retry it.
+			// The release also runs while a panic unwinds, before the recoverer answers.
+			lockedAt := *record.LockedAt
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				err := store.ReleaseIdempotencyKey(context.WithoutCancel(r.Context()), record.ID, lockedAt)
+				if errors.Is(err, models.ErrIdempotencyKeyTakenOver) {
+					logger.Warn("idempotency key was reclaimed before its request failed", zap.Int("id", record.ID))
+				} else if err != nil {
+					logger.Error("failed to release idempotency key", zap.Error(err), zap.Int("id", record.ID))
+				}
+			}()
+
+			recorder := &recordingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.statusCode >= http.StatusInternalServerError {
+				return
+			}
+
+			completed = true
+			err = store.CompleteIdempotencyKey(r.Context(), record.ID, lockedAt, recorder.statusCode, recorder.body.Bytes())
+			if errors.Is(err, models.ErrIdempotencyKeyTakenOver) {
+				logger.Warn("idempotency key was reclaimed before its request completed", zap.Int("id", record.ID))
+			} else if err != nil {
+				logger.Error("failed to store idempotent response", zap.Error(err), zap.Int("id", record.ID))
+			}
+		})
+	}
+}
+
+// replayResponse answers a retry from the response stored for its key
+func replayResponse(w http.ResponseWriter, r *http.Request, record *models.IdempotencyKey, body []byte) {
+	if record.RequestHash != hashRequest(r, body) {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	if record.StatusCode == nil {
+		http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(*record.StatusCode)
+	w.Write(record.ResponseBody)
+}
+
+// hashRequest identifies a request by its method, path and body
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingResponseWriter wraps http.ResponseWriter to keep a copy of the response
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -19,7 +20,7 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 			next.ServeHTTP(wrapped, r)
 
 			duration := time.Since(start)
-			logger.Info(
+			tracing.Logger(r.Context(), logger).Info(
 				"HTTP request completed",
 				zap.String("method", r.Method),
 				zap.String("path", r.RequestURI),
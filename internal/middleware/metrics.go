@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	httpRequests = metrics.NewCounter("http_requests_total",
+		"HTTP requests handled, by method, route pattern and status code.",
+		"method", "route", "status")
+	httpRequestDuration = metrics.NewHistogram("http_request_duration_seconds",
+		"Time taken to handle HTTP requests, by method, route pattern and status code.",
+		metrics.DefBuckets, "method", "route", "status")
+)
+
+// MetricsMiddleware records the count and latency of HTTP requests by route pattern,
+// e.g. /api/bookings/{id}, so series do not grow with every ID requested
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r)
+
+		route := routePattern(r)
+		status := strconv.Itoa(wrapped.statusCode)
+		httpRequests.Inc(r.Method, route, status)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
+
+// routePattern returns the pattern of the route that handled r, e.g. /api/bookings/{id};
+// requests no route matched share the pattern "unmatched"
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return "unmatched"
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit allows each client IP limit requests per window and answers 429 Too Many
+// Requests beyond that. Counts are kept in memory, per server instance, and start over
+// every window. Behind a proxy, put it after chi's RealIP so clients are told apart; RealIP
+// trusts headers any client can set, so use it only when the proxy overwrites them.
+func RateLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	var (
+		mu          sync.Mutex
+		counts      = make(map[string]int)
+		windowStart = time.Now()
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+
+			mu.Lock()
+			now := time.Now()
+			if now.Sub(windowStart) >= window {
+				counts = make(map[string]int)
+				windowStart = now
+			}
+			counts[ip]++
+			exceeded := counts[ip] > limit
+			retryAfter := windowStart.Add(window).Sub(now)
+			mu.Unlock()
+
+			if exceeded {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
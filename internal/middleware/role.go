@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequireRole is a middleware that only lets through users with one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := GetIdentityFromContext(r)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if !identity.HasRole(roles...) {
+				http.Error(w, "Insufficient role", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireCinemaAccess is a middleware that only lets through users who may manage the
+// cinema named by the URL parameter param, e.g. "cinemaId". Super admins may manage every
+// cinema; cinema staff and admins only the cinemas they are assigned to.
+// It must run after AuthMiddleware.
+func RequireCinemaAccess(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := GetIdentityFromContext(r)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			cinemaID, err := strconv.Atoi(chi.URLParam(r, param))
+			if err != nil {
+				http.Error(w, "Invalid cinema ID", http.StatusBadRequest)
+				return
+			}
+
+			if !identity.CanManageCinema(cinemaID) {
+				http.Error(w, "Not allowed to manage this cinema", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+)
+
+// TracingMiddleware starts the root span of each request, continuing the caller's trace
+// when the request has a traceparent header, and returns the span's traceparent so
+// clients can look the request up
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if parent, ok := tracing.ParseTraceparent(r.Header.Get(tracing.TraceparentHeader)); ok {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+		}
+		ctx, span := tracing.Start(ctx, r.Method+" "+r.URL.Path)
+		defer span.End()
+
+		w.Header().Set(tracing.TraceparentHeader, span.SpanContext().Traceparent())
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		span.SetAttribute("http.status_code", wrapped.statusCode)
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(wrapped.statusCode)))
+		}
+	})
+}
@@ -0,0 +1,347 @@
+// Package migrations applies and reverts the numbered schema migrations and records them
+// with their checksums in the schema_migrations table
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrChecksumMismatch is returned when a migration was edited after it was applied
+var ErrChecksumMismatch = errors.New("applied migration has been modified")
+
+// ErrUnknownMigration is returned when the database has a migration applied that this build does not have
+var ErrUnknownMigration = errors.New("applied migration is unknown to this build")
+
+// ErrUnknownVersion is returned when migrating to a version that does not exist
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+// fileName matches migration files, e.g. 0001_initial_schema.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Database interface for the operations migrations need
+type Database interface {
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migration is a numbered schema change with the SQL that applies and reverts it
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of Up, to detect a migration edited after it was applied
+}
+
+// Status is the state of a migration in the database
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Modified  bool       // the migration changed since it was applied
+	Unknown   bool       // applied, but not part of this build
+}
+
+// Load reads the migrations of fsys ordered by version; each must have both an up and a down file
+func Load(fsys fs.FS) ([]*Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		match := fileName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version == 0 {
+			return nil, fmt.Errorf("invalid migration file name %q: versions start at 1", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations. Only one migrator should run against a database at a time.
+type Migrator struct {
+	db         Database
+	migrations []*Migration
+}
+
+// NewMigrator creates a new Migrator for the migrations of fsys
+func NewMigrator(db Database, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// createTable creates schema_migrations if needed; only migrating writes to the database
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// applied returns the applied migrations by version. A database without schema_migrations
+// has none applied.
+func (m *Migrator) applied(ctx context.Context) (map[int]*appliedMigration, error) {
+	rows, err := m.db.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if isUndefinedTable(err) {
+		return map[int]*appliedMigration{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]*appliedMigration)
+	for rows.Next() {
+		var version int
+		row := &appliedMigration{}
+		err := rows.Scan(&version, &row.name, &row.checksum, &row.appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		if isUndefinedTable(err) {
+			return map[int]*appliedMigration{}, nil
+		}
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// isUndefinedTable reports whether err is a PostgreSQL undefined table error
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
+
+// Status returns the state of every migration ordered by version, including applied
+// migrations this build does not know
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.appliedAt
+			status.Modified = row.checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range applied {
+		statuses = append(statuses, &Status{Version: version, Name: row.name, AppliedAt: &row.appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet. It fails if an applied migration was
+// modified or is unknown to this build, as the schema then differs from what the code expects.
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	statuses, err := m.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for i, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, m.migrations[i])
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the latest applied migration and returns it, or nil if none is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	statuses, err := m.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].AppliedAt != nil {
+			migration := m.migrations[i]
+			if err := m.run(ctx, migration, false); err != nil {
+				return nil, err
+			}
+			return migration, nil
+		}
+	}
+	return nil, nil
+}
+
+// To applies or reverts migrations until exactly those up to version are applied and
+// returns the migrations it ran in order. Version 0 reverts every migration.
+func (m *Migrator) To(ctx context.Context, version int) ([]*Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	statuses, err := m.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []*Migration
+	for i, migration := range m.migrations {
+		if migration.Version <= version && statuses[i].AppliedAt == nil {
+			if err := m.run(ctx, migration, true); err != nil {
+				return ran, err
+			}
+			ran = append(ran, migration)
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version && statuses[i].AppliedAt != nil {
+			if err := m.run(ctx, migration, false); err != nil {
+				return ran, err
+			}
+			ran = append(ran, migration)
+		}
+	}
+	return ran, nil
+}
+
+// check returns the status of every migration of this build, in the order of
+// m.migrations, and fails if the database was migrated by a different build
+func (m *Migrator) check(ctx context.Context) ([]*Status, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make([]*Status, 0, len(m.migrations))
+	for _, status := range statuses {
+		if status.Modified {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, status.Version, status.Name)
+		}
+		if status.Unknown {
+			return nil, fmt.Errorf("%w: %d_%s", ErrUnknownMigration, status.Version, status.Name)
+		}
+		known = append(known, status)
+	}
+	return known, nil
+}
+
+// find returns the migration with version, or nil
+func (m *Migrator) find(version int) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// run applies or reverts a migration and records it in one transaction
+func (m *Migrator) run(ctx context.Context, migration *Migration, up bool) error {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if up {
+		_, err = tx.Exec(ctx, migration.Up)
+		if err == nil {
+			_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, migration.Checksum)
+		}
+	} else {
+		_, err = tx.Exec(ctx, migration.Down)
+		if err == nil {
+			_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_users.up.sql":     {Data: []byte("CREATE TABLE users (id SERIAL PRIMARY KEY);")},
+		"0001_create_users.down.sql":   {Data: []byte("DROP TABLE users;")},
+		"0002_add_user_email.up.sql":   {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+		"0002_add_user_email.down.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+	}
+}
+
+func checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+func expectCreateTable(mock pgxmock.PgxPoolIface) {
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+}
+
+func expectApplied(mock pgxmock.PgxPoolIface, versions ...int) {
+	rows := pgxmock.NewRows([]string{"version", "name", "checksum", "applied_at"})
+	for _, version := range versions {
+		switch version {
+		case 1:
+			rows.AddRow(1, "create_users", checksum("CREATE TABLE users (id SERIAL PRIMARY KEY);"), time.Now())
+		case 2:
+			rows.AddRow(2, "add_user_email", checksum("ALTER TABLE users ADD COLUMN email TEXT;"), time.Now())
+		}
+	}
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").WillReturnRows(rows)
+}
+
+func TestLoad_OrdersByVersion(t *testing.T) {
+	migrations, err := Load(testFS())
+
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE users;", migrations[0].Down)
+	assert.Equal(t, checksum(migrations[0].Up), migrations[0].Checksum)
+	assert.Equal(t, 2, migrations[1].Version)
+}
+
+func TestLoad_EmbeddedMigrations(t *testing.T) {
+	migrations, err := Load(db.Migrations())
+
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, 1, migrations[0].Version)
+}
+
+func TestLoad_MissingDownFile(t *testing.T) {
+	fsys := testFS()
+	delete(fsys, "0002_add_user_email.down.sql")
+
+	_, err := Load(fsys)
+
+	assert.ErrorContains(t, err, "needs both an up and a down file")
+}
+
+func TestLoad_InvalidFileName(t *testing.T) {
+	fsys := testFS()
+	fsys["add_index.sql"] = &fstest.MapFile{Data: []byte("CREATE INDEX ...")}
+
+	_, err := Load(fsys)
+
+	assert.ErrorContains(t, err, "invalid migration file name")
+}
+
+func TestMigrator_Up_AppliesPendingInOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	migrator, err := NewMigrator(mock, testFS())
+	require.NoError(t, err)
+
+	expectCreateTable(mock)
+	expectApplied(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users ADD COLUMN email TEXT").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(2, "add_user_email", checksum("ALTER TABLE users ADD COLUMN email TEXT;")).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	ran, err := migrator.Up(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, ran, 1)
+	assert.Equal(t, 2, ran[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_To_RevertsNewerMigrations(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	migrator, err := NewMigrator(mock, testFS())
+	require.NoError(t, err)
+
+	expectCreateTable(mock)
+	expectApplied(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users DROP COLUMN email").WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version = \\$1").
+		WithArgs(2).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+
+	ran, err := migrator.To(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, ran, 1)
+	assert.Equal(t, 2, ran[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_To_UnknownVersion(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	migrator, err := NewMigrator(mock, testFS())
+	require.NoError(t, err)
+
+	_, err = migrator.To(context.Background(), 7)
+
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Pending(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	migrator, err := NewMigrator(mock, testFS())
+	require.NoError(t, err)
+
+	expectApplied(mock, 1)
+
+	pending, err := migrator.Pending(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "add_user_email", pending[0].Name)
+}
+
+func TestMigrator_Pending_WithoutMigrationsTable(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	migrator, err := NewMigrator(mock, testFS())
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+		WillReturnError(&pgconn.PgError{Code: "42P01", Message: `relation "schema_migrations" does not exist`})
+
+	pending, err := migrator.Pending(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Pending_ModifiedMigration(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	fsys := testFS()
+	fsys["0001_create_users.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE users (id BIGSERIAL PRIMARY KEY);")}
+	migrator, err := NewMigrator(mock, fsys)
+	require.NoError(t, err)
+
+	expectApplied(mock, 1)
+
+	_, err = migrator.Pending(context.Background())
+
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestMigrator_Pending_UnknownMigration(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	fsys := testFS()
+	delete(fsys, "0002_add_user_email.up.sql")
+	delete(fsys, "0002_add_user_email.down.sql")
+	migrator, err := NewMigrator(mock, fsys)
+	require.NoError(t, err)
+
+	expectApplied(mock, 1, 2)
+
+	_, err = migrator.Pending(context.Background())
+
+	assert.ErrorIs(t, err, ErrUnknownMigration)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Auditorium represents a studio (screen) inside a cinema
+type Auditorium struct {
+	ID        int       `db:"id" json:"id"`
+	CinemaID  int       `db:"cinema_id" json:"cinema_id"`
+	Name      string    `db:"name" json:"name"`
+	Format    string    `db:"format" json:"format"` // 2D, 3D, IMAX, 4DX
+	Capacity  int       `db:"capacity" json:"capacity"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -2,56 +2,71 @@ package models
 
 import "time"
 
-// Booking represents a seat booking
+// Booking represents a booking header; the booked seats are its line items
 type Booking struct {
-	ID            int       `db:"id" json:"id"`
-	UserID        int       `db:"user_id" json:"user_id"`
-	CinemaID      int       `db:"cinema_id" json:"cinema_id"`
-	SeatID        int       `db:"seat_id" json:"seat_id"`
-	ShowDate      time.Time `db:"show_date" json:"show_date"`
-	ShowTime      string    `db:"show_time" json:"show_time"`
-	BookingDate   time.Time `db:"booking_date" json:"booking_date"`
-	Status        string    `db:"status" json:"status"` // pending, confirmed, cancelled
-	TotalPrice    float64   `db:"total_price" json:"total_price"`
-	PaymentMethod string    `db:"payment_method" json:"payment_method"`
-	PaymentStatus string    `db:"payment_status" json:"payment_status"` // pending, paid, failed
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
-	Cinema        *Cinema   `json:"cinema,omitempty"`
-	Seat          *Seat     `json:"seat,omitempty"`
+	ID            int            `db:"id" json:"id"`
+	Reference     string         `db:"reference" json:"reference"`
+	UserID        int            `db:"user_id" json:"user_id"`
+	ScreeningID   int            `db:"screening_id" json:"screening_id"`
+	BookingDate   time.Time      `db:"booking_date" json:"booking_date"`
+	Status        string         `db:"status" json:"status"` // pending, confirmed, cancelled, expired
+	TotalPrice    Money          `db:"total_price" json:"total_price"`
+	PaymentMethod string         `db:"payment_method" json:"payment_method"`
+	PaymentStatus string         `db:"payment_status" json:"payment_status"` // pending, processing, paid, failed, partially_refunded, refunded
+	HoldExpiresAt *time.Time     `db:"hold_expires_at" json:"hold_expires_at,omitempty"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+	Seats         []*BookingSeat `json:"seats,omitempty"`
+	Screening     *Screening     `json:"screening,omitempty"`
+	Cinema        *Cinema        `json:"cinema,omitempty"`
+	Movie         *Movie         `json:"movie,omitempty"`
+}
+
+// BookingSeat represents a single seat line item of a booking
+type BookingSeat struct {
+	ID          int       `db:"id" json:"id"`
+	BookingID   int       `db:"booking_id" json:"booking_id"`
+	ScreeningID int       `db:"screening_id" json:"screening_id"`
+	SeatID      int       `db:"seat_id" json:"seat_id"`
+	Price       Money     `db:"price" json:"price"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	Seat        *Seat     `json:"seat,omitempty"`
 }
 
 // BookingRequest represents the request body for creating a booking
 type BookingRequest struct {
-	CinemaID      int    `json:"cinema_id" validate:"required"`
-	SeatID        int    `json:"seat_id" validate:"required"`
-	Date          string `json:"date" validate:"required"`
-	Time          string `json:"time" validate:"required"`
+	ScreeningID   int    `json:"screening_id" validate:"required"`
+	SeatIDs       []int  `json:"seat_ids" validate:"required,min=1,max=10,unique,dive,gt=0"`
 	PaymentMethod string `json:"payment_method" validate:"required"`
 }
 
 // BookingResponse represents a booking response
 type BookingResponse struct {
-	ID            int       `json:"id"`
-	CinemaID      int       `json:"cinema_id"`
-	SeatID        int       `json:"seat_id"`
-	ShowDate      time.Time `json:"show_date"`
-	ShowTime      string    `json:"show_time"`
-	TotalPrice    float64   `json:"total_price"`
-	PaymentMethod string    `json:"payment_method"`
-	Status        string    `json:"status"`
-	PaymentStatus string    `json:"payment_status"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int        `json:"id"`
+	Reference     string     `json:"reference"`
+	ScreeningID   int        `json:"screening_id"`
+	CinemaID      int        `json:"cinema_id"`
+	AuditoriumID  int        `json:"auditorium_id"`
+	MovieID       int        `json:"movie_id"`
+	MovieTitle    string     `json:"movie_title"`
+	SeatIDs       []int      `json:"seat_ids"`
+	StartTime     time.Time  `json:"start_time"`
+	TotalPrice    Money      `json:"total_price"`
+	PaymentMethod string     `json:"payment_method"`
+	Status        string     `json:"status"`
+	PaymentStatus string     `json:"payment_status"`
+	HoldExpiresAt *time.Time `json:"hold_expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
 }
 
 // UserBookingHistory represents booking history for a user
 type UserBookingHistory struct {
 	ID            int       `json:"id"`
 	CinemaName    string    `json:"cinema_name"`
-	SeatNumber    string    `json:"seat_number"`
-	ShowDate      time.Time `json:"show_date"`
-	ShowTime      string    `json:"show_time"`
-	TotalPrice    float64   `json:"total_price"`
+	MovieTitle    string    `json:"movie_title"`
+	SeatNumbers   []string  `json:"seat_numbers"`
+	StartTime     time.Time `json:"start_time"`
+	TotalPrice    Money     `json:"total_price"`
 	Status        string    `json:"status"`
 	PaymentStatus string    `json:"payment_status"`
 	BookingDate   time.Time `json:"booking_date"`
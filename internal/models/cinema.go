@@ -4,15 +4,35 @@ import "time"
 
 // Cinema represents a cinema venue
 type Cinema struct {
-	ID         int       `db:"id" json:"id"`
-	Name       string    `db:"name" json:"name"`
-	Location   string    `db:"location" json:"location"`
-	City       string    `db:"city" json:"city"`
-	Address    string    `db:"address" json:"address"`
-	TotalSeats int       `db:"total_seats" json:"total_seats"`
-	ImageURL   string    `db:"image_url" json:"image_url"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	ID         int        `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Location   string     `db:"location" json:"location"`
+	City       string     `db:"city" json:"city"`
+	Address    string     `db:"address" json:"address"`
+	TotalSeats int        `db:"total_seats" json:"total_seats"` // sum of auditorium capacities
+	ImageURL   string     `db:"image_url" json:"image_url"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	ArchivedAt *time.Time `db:"archived_at" json:"archived_at,omitempty"` // set once the cinema is deleted
+}
+
+// CinemaRequest represents the request body for creating or replacing a cinema
+type CinemaRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Location string `json:"location" validate:"required,max=100"`
+	City     string `json:"city" validate:"required,max=50"`
+	Address  string `json:"address" validate:"required,max=255"`
+	ImageURL string `json:"image_url" validate:"omitempty,url,max=255"`
+}
+
+// CinemaPatchRequest represents the request body for partially updating a cinema;
+// omitted fields are left unchanged and an empty image_url removes the image
+type CinemaPatchRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Location *string `json:"location" validate:"omitempty,min=1,max=100"`
+	City     *string `json:"city" validate:"omitempty,min=1,max=50"`
+	Address  *string `json:"address" validate:"omitempty,min=1,max=255"`
+	ImageURL *string `json:"image_url" validate:"omitempty,url|eq=,max=255"`
 }
 
 // CinemaFilters represents filters for cinema listing
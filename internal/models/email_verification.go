@@ -2,14 +2,22 @@ package models
 
 import "time"
 
+// OTP purposes, telling what an emailed OTP may be used for
+const (
+	OTPPurposeEmailVerification = "email_verification"
+	OTPPurposePasswordReset     = "password_reset"
+)
+
 // EmailVerification represents an email verification record with OTP
 type EmailVerification struct {
 	ID         int       `json:"id"`
 	UserID     int       `json:"user_id"`
 	Email      string    `json:"email"`
+	Purpose    string    `json:"purpose"`
 	OTPCode    string    `json:"-"` // Hidden from JSON responses
 	ExpiresAt  time.Time `json:"expires_at"`
-	IsVerified bool      `json:"is_verified"`
+	IsVerified bool      `json:"is_verified"` // for password resets: the code was used
+	Attempts   int       `json:"attempts"`    // codes entered
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -29,3 +37,15 @@ type OTPResponse struct {
 	Message    string `json:"message"`
 	IsVerified bool   `json:"is_verified"`
 }
+
+// ForgotPasswordRequest represents request to email a password reset OTP
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents request to set a new password with a password reset OTP
+type ResetPasswordRequest struct {
+	Email       string `json:"email" validate:"required,email"`
+	OTPCode     string `json:"otp_code" validate:"required,len=6"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import "errors"
+
+// ErrSeatAlreadyBooked is returned when one or more requested seats are already booked for a screening
+var ErrSeatAlreadyBooked = errors.New("one or more seats are already booked for this screening")
+
+// ErrBookingNotOwned is returned when a user acts on a booking that belongs to someone else
+var ErrBookingNotOwned = errors.New("booking does not belong to this user")
+
+// ErrBookingNotCancellable is returned when a booking is no longer pending or confirmed
+var ErrBookingNotCancellable = errors.New("booking can no longer be cancelled")
+
+// ErrBookingPaymentInProgress is returned when a booking is cancelled while the provider is still settling its payment
+var ErrBookingPaymentInProgress = errors.New("booking has a payment in progress, try again once it is settled")
+
+// ErrBookingNotPayable is returned when a booking is paid for that is no longer pending, e.g. because it was cancelled
+var ErrBookingNotPayable = errors.New("booking is no longer awaiting payment")
+
+// ErrCancellationWindowClosed is returned when a booking is cancelled too close to showtime
+var ErrCancellationWindowClosed = errors.New("cancellation window for this screening has closed")
+
+// ErrPaymentNotRefundable is returned when a payment did not succeed or has already been fully refunded
+var ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+
+// ErrRefundExceedsPayment is returned when a refund is larger than what is left to refund on a payment
+var ErrRefundExceedsPayment = errors.New("refund amount exceeds the refundable amount of the payment")
+
+// ErrPaymentDeclined is returned when the payment gateway declines a charge
+var ErrPaymentDeclined = errors.New("payment was declined")
+
+// ErrGatewayTimeout is returned when the payment gateway does not answer in time
+var ErrGatewayTimeout = errors.New("payment gateway timed out")
+
+// ErrGatewayUnavailable is returned while the payment gateway's circuit breaker is open after repeated failures
+var ErrGatewayUnavailable = errors.New("payment gateway is temporarily unavailable")
+
+// ErrPaymentNotFound is returned when no payment matches a provider transaction
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// ErrUnknownWebhookProvider is returned for webhooks from a provider without a configured secret
+var ErrUnknownWebhookProvider = errors.New("unknown payment provider")
+
+// ErrInvalidWebhookSignature is returned when a webhook signature does not match its body
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrInvalidWebhookPayload is returned when a signed webhook body is malformed or reports an unsupported status
+var ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+
+// ErrPaymentAlreadyExists is returned when a booking already has a payment that is in progress or succeeded
+var ErrPaymentAlreadyExists = errors.New("booking already has a payment in progress or completed")
+
+// ErrCinemaNotFound is returned when a request refers to a cinema that does not exist
+var ErrCinemaNotFound = errors.New("cinema not found")
+
+// ErrCinemaAccessDenied is returned when a user acts on a cinema they are not assigned to
+var ErrCinemaAccessDenied = errors.New("not allowed to manage this cinema")
+
+// ErrInvalidCinemaAssignment is returned when cinema staff or admins get no cinemas, or another role gets some
+var ErrInvalidCinemaAssignment = errors.New("cinema staff and admins need at least one cinema, other roles none")
+
+// ErrCinemaHasFutureBookings is returned when deleting a cinema that still has paid bookings for upcoming screenings
+var ErrCinemaHasFutureBookings = errors.New("cinema has paid bookings for upcoming screenings")
+
+// ErrCinemaArchived is returned when screenings are scheduled at a cinema that has been deleted
+var ErrCinemaArchived = errors.New("cinema has been archived")
+
+// ErrAuditoriumNotFound is returned when a request refers to an auditorium that does not exist
+var ErrAuditoriumNotFound = errors.New("auditorium not found")
+
+// ErrInvalidSeatMap is returned when a seat layout is not a proper grid of seats
+var ErrInvalidSeatMap = errors.New("invalid seat map")
+
+// ErrMovieNotFound is returned when a request refers to a movie that does not exist
+var ErrMovieNotFound = errors.New("movie not found")
+
+// ErrInvalidSchedule is returned when a schedule pattern yields no screenings or screenings that cannot be shown
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrScheduleConflict is returned when screenings of a schedule overlap screenings already in the auditorium
+var ErrScheduleConflict = errors.New("schedule overlaps existing screenings")
+
+// ErrInvalidRefreshToken is returned for a refresh token that is unknown, expired or of an ended session
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// ErrRefreshTokenReused is returned when a refresh token is presented again after it was used;
+// its session is revoked, since the token has leaked
+var ErrRefreshTokenReused = errors.New("refresh token was already used, session revoked")
+
+// ErrSessionNotFound is returned when a user revokes a session that is not theirs or already ended
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrIdempotencyKeyTakenOver is returned when a request finishes after a retry reclaimed its
+// stale idempotency key; the key now belongs to the retry
+var ErrIdempotencyKeyTakenOver = errors.New("idempotency key was reclaimed by another request")
+
+// ErrInvalidResetCode is returned for a password reset code that is wrong, expired, used or
+// guessed at too often; the cases are not told apart
+var ErrInvalidResetCode = errors.New("invalid or expired password reset code")
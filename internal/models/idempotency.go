@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyKey records a request sent with an Idempotency-Key header and, once it
+// completed, its response so retries of the same request can be answered from it
+type IdempotencyKey struct {
+	ID           int        `db:"id"`
+	UserID       int        `db:"user_id"`
+	Key          string     `db:"idempotency_key"`
+	Method       string     `db:"method"`
+	Path         string     `db:"path"`
+	RequestHash  string     `db:"request_hash"`
+	StatusCode   *int       `db:"status_code"` // nil while the first request is still being processed
+	ResponseBody []byte     `db:"response_body"`
+	LockedAt     *time.Time `db:"locked_at"` // when the request holding the key started; nil once it completed
+	ExpiresAt    time.Time  `db:"expires_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	CompletedAt  *time.Time `db:"completed_at"`
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MoneyCurrency is the ISO 4217 currency of every amount; prices and payments are in rupiah
+const MoneyCurrency = "IDR"
+
+// moneyScale is the number of minor units in a major unit; prices are stored as DECIMAL(10, 2)
+const moneyScale = 100
+
+// Money is an exact monetary amount in MoneyCurrency, in integer minor units (1/100 of
+// the major unit). The zero value is zero.
+//
+// Money is stored in DECIMAL columns and encoded in JSON as a decimal string such as
+// "70000.10", so amounts never go through float64.
+type Money struct {
+	minor int64
+}
+
+// NewMoney returns an amount of minor units
+func NewMoney(minor int64) Money {
+	return Money{minor: minor}
+}
+
+// ParseMoney parses a decimal amount such as "70000.10". Digits beyond the second
+// decimal place must be zero, so parsing never rounds. Prices and payments are never
+// negative, so neither are parsed amounts.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return Money{}, fmt.Errorf("amount %q is negative", s)
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return Money{}, fmt.Errorf("amount %q has more than two decimal places", s)
+		}
+		frac = frac[:2]
+	}
+	frac = (frac + "00")[:2]
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/moneyScale {
+		return Money{}, fmt.Errorf("amount %q is out of range", s)
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+
+	return Money{minor: units*moneyScale + cents}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics if s is not a valid amount. It is meant
+// for amounts written in code, such as seed data.
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// Cmp compares two amounts and returns -1, 0 or +1
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.minor < other.minor:
+		return -1
+	case m.minor > other.minor:
+		return 1
+	}
+	return 0
+}
+
+// Add returns m + other
+func (m Money) Add(other Money) Money {
+	return Money{minor: m.minor + other.minor}
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) Money {
+	return Money{minor: m.minor - other.minor}
+}
+
+// Percent returns percent of m, rounded half away from zero to the nearest minor unit
+func (m Money) Percent(percent int) Money {
+	product := m.minor * int64(percent)
+	rounded := (abs(product) + 50) / 100
+	if product < 0 {
+		rounded = -rounded
+	}
+	return Money{minor: rounded}
+}
+
+// String formats the amount as a decimal such as "70000.10"
+func (m Money) String() string {
+	sign := ""
+	if m.minor < 0 {
+		sign = "-"
+	}
+	minor := abs(m.minor)
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyScale, minor%moneyScale)
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes an amount from a decimal string or, for older clients, a JSON number
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return m.scanText(v)
+	case []byte:
+		return m.scanText(string(v))
+	case int64:
+		*m = Money{minor: v * moneyScale}
+		return nil
+	case float64:
+		// Only reached through drivers that hand over floats; the shortest
+		// representation of an amount read from a DECIMAL(10, 2) is exact
+		return m.scanText(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Money")
+	}
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+func (m *Money) scanText(text string) error {
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount to a DECIMAL column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// MoneyValue lets a validator check amounts by their minor units, e.g. with gt=0.
+// Register it with validate.RegisterCustomTypeFunc(models.MoneyValue, models.Money{}).
+func MoneyValue(field reflect.Value) any {
+	if m, ok := field.Interface().(Money); ok {
+		return m.minor
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Movie represents a film in the catalog
+type Movie struct {
+	ID          int        `db:"id" json:"id"`
+	Title       string     `db:"title" json:"title"`
+	Synopsis    string     `db:"synopsis" json:"synopsis"`
+	Duration    int        `db:"duration_minutes" json:"duration_minutes"`
+	Genre       string     `db:"genre" json:"genre"`
+	AgeRating   string     `db:"age_rating" json:"age_rating"` // SU, 13+, 17+, 21+
+	PosterURL   string     `db:"poster_url" json:"poster_url"`
+	ReleaseDate time.Time  `db:"release_date" json:"release_date"`
+	EndDate     *time.Time `db:"end_date" json:"end_date,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// MovieFilters represents filters for movie listing
+type MovieFilters struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Title      string `json:"title"`
+	Genre      string `json:"genre"`
+	NowShowing bool   `json:"now_showing"`
+}
@@ -15,11 +15,12 @@ type PaymentMethod struct {
 // Payment represents a payment transaction
 type Payment struct {
 	ID            int       `db:"id" json:"id"`
+	Reference     string    `db:"reference" json:"reference"`
 	BookingID     int       `db:"booking_id" json:"booking_id"`
 	UserID        int       `db:"user_id" json:"user_id"`
-	Amount        float64   `db:"amount" json:"amount"`
+	Amount        Money     `db:"amount" json:"amount"`
 	PaymentMethod string    `db:"payment_method" json:"payment_method"`
-	Status        string    `db:"status" json:"status"` // pending, success, failed
+	Status        string    `db:"status" json:"status"` // pending, success, failed, partially_refunded, refunded
 	TransactionID string    `db:"transaction_id" json:"transaction_id"`
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
@@ -27,18 +28,45 @@ type Payment struct {
 
 // PaymentRequest represents the request for payment processing
 type PaymentRequest struct {
-	BookingID     int     `json:"booking_id" validate:"required"`
-	PaymentMethod string  `json:"payment_method" validate:"required"`
-	Amount        float64 `json:"amount" validate:"required,gt=0"`
+	BookingID     int    `json:"booking_id" validate:"required"`
+	PaymentMethod string `json:"payment_method" validate:"required"`
+	Amount        Money  `json:"amount" validate:"required,gt=0"`
 }
 
 // PaymentResponse represents a payment response
 type PaymentResponse struct {
-	ID            int       `json:"id"`
-	BookingID     int       `json:"booking_id"`
-	Amount        float64   `json:"amount"`
-	PaymentMethod string    `json:"payment_method"`
-	Status        string    `json:"status"`
-	TransactionID string    `json:"transaction_id"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int                `json:"id"`
+	Reference     string             `json:"reference"`
+	BookingID     int                `json:"booking_id"`
+	Amount        Money              `json:"amount"`
+	PaymentMethod string             `json:"payment_method"`
+	Status        string             `json:"status"`
+	TransactionID string             `json:"transaction_id"`
+	NextAction    *PaymentNextAction `json:"next_action,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// PaymentNextAction tells the client how to finish a pending payment with the provider
+type PaymentNextAction struct {
+	Type string `json:"type"` // redirect
+	URL  string `json:"url"`
+}
+
+// PaymentWebhookPayload is the body a payment provider posts about a transaction
+type PaymentWebhookPayload struct {
+	EventID       string `json:"event_id" validate:"required"`
+	TransactionID string `json:"transaction_id" validate:"required"`
+	Status        string `json:"status" validate:"required"` // captured, declined, failed, expired
+}
+
+// PaymentWebhookEvent is a processed provider callback; providers may deliver an event more than once
+type PaymentWebhookEvent struct {
+	ID            int       `db:"id" json:"id"`
+	Provider      string    `db:"provider" json:"provider"`
+	EventID       string    `db:"event_id" json:"event_id"`
+	TransactionID string    `db:"transaction_id" json:"transaction_id"`
+	Status        string    `db:"status" json:"status"`
+	PaymentID     int       `db:"payment_id" json:"payment_id"`
+	PaymentStatus string    `json:"payment_status"` // payment status the event moves the payment to
+	ReceivedAt    time.Time `db:"received_at" json:"received_at"`
 }
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Refund represents money returned against a payment; a payment can have several partial refunds
+type Refund struct {
+	ID            int       `db:"id" json:"id"`
+	Reference     string    `db:"reference" json:"reference"`
+	PaymentID     int       `db:"payment_id" json:"payment_id"`
+	BookingID     int       `db:"booking_id" json:"booking_id"`
+	Amount        Money     `db:"amount" json:"amount"`
+	Reason        string    `db:"reason" json:"reason"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	PaymentStatus string    `json:"payment_status"` // payment status after this refund: partially_refunded, refunded
+}
+
+// RefundRequest represents the request for refunding a payment.
+// Amount is optional; when omitted the remaining refundable amount is refunded.
+type RefundRequest struct {
+	Amount *Money `json:"amount" validate:"omitempty,gt=0"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// RefundTier grants Percent of the paid amount when a booking is cancelled
+// at least MinHoursBefore hours before the screening starts
+type RefundTier struct {
+	MinHoursBefore int
+	Percent        int
+}
+
+// RefundPolicy is a set of refund tiers ordered from the earliest cancellation to the latest
+type RefundPolicy []RefundTier
+
+// ParseRefundPolicy parses a policy like "48:100,24:50", meaning a full refund
+// from 48 hours before the show, half from 24 hours and nothing after that
+func ParseRefundPolicy(s string) (RefundPolicy, error) {
+	policy := RefundPolicy{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		hours, percent, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid refund tier %q, expected hours:percent", part)
+		}
+		h, err := strconv.Atoi(strings.TrimSpace(hours))
+		if err != nil || h < 0 {
+			return nil, fmt.Errorf("invalid hours in refund tier %q", part)
+		}
+		p, err := strconv.Atoi(strings.TrimSpace(percent))
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percent in refund tier %q", part)
+		}
+		policy = append(policy, RefundTier{MinHoursBefore: h, Percent: p})
+	}
+
+	sort.Slice(policy, func(i, j int) bool { return policy[i].MinHoursBefore > policy[j].MinHoursBefore })
+	return policy, nil
+}
+
+// Percent returns the refund percentage for a cancellation made timeBefore the show
+func (p RefundPolicy) Percent(timeBefore time.Duration) int {
+	for _, tier := range p {
+		if timeBefore >= time.Duration(tier.MinHoursBefore)*time.Hour {
+			return tier.Percent
+		}
+	}
+	return 0
+}
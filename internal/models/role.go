@@ -0,0 +1,56 @@
+package models
+
+// User roles
+const (
+	RoleCustomer    = "customer"     // books and pays for tickets
+	RoleCinemaStaff = "cinema_staff" // works at the cinemas they are assigned to
+	RoleCinemaAdmin = "cinema_admin" // manages the cinemas they are assigned to
+	RoleSuperAdmin  = "super_admin"  // manages every cinema and user
+)
+
+// IsCinemaScopedRole reports whether a role only applies to the cinemas a user is assigned to
+func IsCinemaScopedRole(role string) bool {
+	return role == RoleCinemaStaff || role == RoleCinemaAdmin
+}
+
+// Identity is the authenticated user behind a request, as carried in the access token
+type Identity struct {
+	UserID    int
+	SessionID int
+	Role      string
+	CinemaIDs []int // cinemas of cinema staff and admins
+}
+
+// HasRole reports whether the user has one of roles
+func (i *Identity) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if i.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageCinema reports whether the user may act on a cinema: super admins may act on
+// every cinema, cinema staff and admins only on the cinemas they are assigned to
+func (i *Identity) CanManageCinema(cinemaID int) bool {
+	if i.Role == RoleSuperAdmin {
+		return true
+	}
+	if !IsCinemaScopedRole(i.Role) {
+		return false
+	}
+	for _, id := range i.CinemaIDs {
+		if id == cinemaID {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRoleRequest represents the request body for changing a user's role.
+// Cinema staff and admins need the cinemas they work at; other roles take none.
+type UserRoleRequest struct {
+	Role      string `json:"role" validate:"required,oneof=customer cinema_staff cinema_admin super_admin"`
+	CinemaIDs []int  `json:"cinema_ids" validate:"unique,dive,gt=0"`
+}
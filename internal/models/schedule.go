@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ScheduleRequest represents the request body for scheduling a movie in an auditorium on a
+// recurring pattern: at each of times on the given days of the week, from start_date to
+// end_date inclusive. Dates and times are local to the cinema.
+type ScheduleRequest struct {
+	AuditoriumID int      `json:"auditorium_id" validate:"required,gt=0"`
+	MovieID      int      `json:"movie_id" validate:"required,gt=0"`
+	StartDate    string   `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate      string   `json:"end_date" validate:"required,datetime=2006-01-02"`
+	DaysOfWeek   []string `json:"days_of_week" validate:"required,min=1,max=7,unique,dive,oneof=mon tue wed thu fri sat sun"`
+	Times        []string `json:"times" validate:"required,min=1,max=12,unique,dive,datetime=15:04"`
+	BasePrice    Money    `json:"base_price" validate:"required,gt=0"`
+}
+
+// ScheduleConflict is a screening of a schedule that overlaps a screening already in the auditorium
+type ScheduleConflict struct {
+	StartTime time.Time  `json:"start_time"`
+	EndTime   time.Time  `json:"end_time"`
+	Existing  *Screening `json:"existing_screening"`
+}
+
+// ScheduleResponse lists the screenings a schedule created, or would create when previewed
+type ScheduleResponse struct {
+	Preview    bool                `json:"preview"`
+	Total      int                 `json:"total"`
+	Screenings []*Screening        `json:"screenings"`
+	Conflicts  []*ScheduleConflict `json:"conflicts"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Screening represents a single showing of a movie in a cinema auditorium
+type Screening struct {
+	ID           int       `db:"id" json:"id"`
+	CinemaID     int       `db:"cinema_id" json:"cinema_id"`
+	AuditoriumID int       `db:"auditorium_id" json:"auditorium_id"`
+	MovieID      int       `db:"movie_id" json:"movie_id"`
+	StartTime    time.Time `db:"start_time" json:"start_time"`
+	EndTime      time.Time `db:"end_time" json:"end_time"`
+	BasePrice    Money     `db:"base_price" json:"base_price"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	Movie        *Movie    `json:"movie,omitempty"`
+}
+
+// ScreeningRequest represents the request body for creating a screening
+type ScreeningRequest struct {
+	AuditoriumID int       `json:"auditorium_id" validate:"required"`
+	MovieID      int       `json:"movie_id" validate:"required"`
+	StartTime    time.Time `json:"start_time" validate:"required"`
+	BasePrice    Money     `json:"base_price" validate:"required,gt=0"`
+}
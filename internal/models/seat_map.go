@@ -0,0 +1,59 @@
+package models
+
+// Seat types
+const (
+	SeatTypeStandard = "standard"
+	SeatTypePremium  = "premium"
+	SeatTypeVIP      = "vip"
+)
+
+// Seat map cell types
+const (
+	SeatMapCellSeat  = "seat"  // a seat; its details are in the cell's seat
+	SeatMapCellAisle = "aisle" // a walkway between seats
+	SeatMapCellGap   = "gap"   // an empty spot, e.g. where a pillar stands
+)
+
+// IsSeatType reports whether a seat map cell holds a seat of that type rather than an aisle or gap
+func IsSeatType(cell string) bool {
+	return cell == SeatTypeStandard || cell == SeatTypePremium || cell == SeatTypeVIP
+}
+
+// SeatMapRowLayout is one row of an auditorium's seat layout: its label and its cells
+// from left to right, each a seat type, "aisle" or "gap". Seats are numbered from the
+// left skipping aisles and gaps, so the seats of row "A" are A1, A2, ...
+type SeatMapRowLayout struct {
+	Label string   `json:"label" validate:"required,max=3,alphanum"`
+	Cells []string `json:"cells" validate:"required,min=1,max=60,dive,oneof=standard premium vip aisle gap"`
+}
+
+// SeatMapRequest represents the request body for uploading the seat layout of an auditorium.
+// Rows are listed from the screen to the back and must all have the same number of cells;
+// prices gives the price of every seat type used.
+type SeatMapRequest struct {
+	Rows   []SeatMapRowLayout `json:"rows" validate:"required,min=1,max=40,dive"`
+	Prices map[string]Money   `json:"prices" validate:"required,dive,keys,oneof=standard premium vip,endkeys,gt=0"`
+}
+
+// SeatMap is the seat layout of an auditorium as a grid for rendering
+type SeatMap struct {
+	AuditoriumID int           `json:"auditorium_id"`
+	Columns      int           `json:"columns"`
+	TotalSeats   int           `json:"total_seats"`
+	Rows         []*SeatMapRow `json:"rows"`
+}
+
+// SeatMapRow is a row of a seat map grid
+type SeatMapRow struct {
+	Row   int            `json:"row"` // counted from 1 at the screen
+	Label string         `json:"label"`
+	Cells []*SeatMapCell `json:"cells"`
+}
+
+// SeatMapCell is a cell of a seat map grid at its row and column, both counted from 1
+type SeatMapCell struct {
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+	Type   string `json:"type"` // seat, aisle, gap
+	Seat   *Seat  `json:"seat,omitempty"`
+}
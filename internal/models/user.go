@@ -9,8 +9,10 @@ type User struct {
 	Email      string    `db:"email" json:"email"`
 	Password   string    `db:"password" json:"-"`
 	IsVerified bool      `db:"is_verified" json:"is_verified"`
+	Role       string    `db:"role" json:"role"` // customer, cinema_staff, cinema_admin, super_admin
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	CinemaIDs  []int     `json:"cinema_ids,omitempty"` // cinemas of cinema staff and admins
 }
 
 // UserRegisterRequest represents the request body for user registration
@@ -31,14 +33,48 @@ type UserLoginResponse struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
-	Token    string `json:"token"`
+	Role     string `json:"role"`
+	TokenResponse
 }
 
-// UserSession represents an active user session
+// TokenRefreshRequest represents the request body for getting new tokens with a refresh token
+type TokenRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse carries a short-lived access token and the single-use refresh token that replaces it
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+}
+
+// UserSession represents one login of a user. Its access tokens carry its ID, and it
+// lasts as long as its refresh tokens keep being rotated or until it is revoked.
 type UserSession struct {
-	ID        int       `db:"id" json:"id"`
-	UserID    int       `db:"user_id" json:"user_id"`
-	Token     string    `db:"token" json:"token"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	ID         int        `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"user_id"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	IPAddress  string     `db:"ip_address" json:"ip_address"` // where the user logged in from
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at" json:"last_used_at"` // when the session last made a request, to the minute
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"` // the session of the request's token
+}
+
+// ClientInfo describes the device a user logs in from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// RefreshToken is a single-use token of a session; only its SHA-256 hash is stored
+type RefreshToken struct {
+	ID        int        `db:"id" json:"id"`
+	SessionID int        `db:"session_id" json:"session_id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"`
 }
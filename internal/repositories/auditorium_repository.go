@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditoriumRepository handles auditorium-related database operations
+type AuditoriumRepository struct {
+	db Database
+}
+
+// NewAuditoriumRepository creates a new AuditoriumRepository
+func NewAuditoriumRepository(db Database) *AuditoriumRepository {
+	return &AuditoriumRepository{db: db}
+}
+
+// GetAuditoriumsByCinema retrieves all auditoriums of a cinema
+func (r *AuditoriumRepository) GetAuditoriumsByCinema(ctx context.Context, cinemaID int) ([]*models.Auditorium, error) {
+	query := `SELECT id, cinema_id, name, format, capacity, created_at, updated_at 
+	FROM auditoriums WHERE cinema_id = $1 ORDER BY name`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auditoriums: %w", err)
+	}
+	defer rows.Close()
+
+	auditoriums := []*models.Auditorium{}
+	for rows.Next() {
+		auditorium := &models.Auditorium{}
+		err := rows.Scan(&auditorium.ID, &auditorium.CinemaID, &auditorium.Name, &auditorium.Format, &auditorium.Capacity, &auditorium.CreatedAt, &auditorium.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan auditorium: %w", err)
+		}
+		auditoriums = append(auditoriums, auditorium)
+	}
+
+	return auditoriums, nil
+}
+
+// GetAuditoriumByID retrieves an auditorium by ID
+func (r *AuditoriumRepository) GetAuditoriumByID(ctx context.Context, id int) (*models.Auditorium, error) {
+	auditorium := &models.Auditorium{}
+	query := `SELECT id, cinema_id, name, format, capacity, created_at, updated_at 
+	FROM auditoriums WHERE id = $1`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&auditorium.ID, &auditorium.CinemaID, &auditorium.Name, &auditorium.Format, &auditorium.Capacity, &auditorium.CreatedAt, &auditorium.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get auditorium: %w", err)
+	}
+
+	return auditorium, nil
+}
+
+// CreateAuditorium creates a new auditorium (for admin/seeding)
+func (r *AuditoriumRepository) CreateAuditorium(ctx context.Context, auditorium *models.Auditorium) error {
+	query := `INSERT INTO auditoriums (cinema_id, name, format, capacity) 
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, auditorium.CinemaID, auditorium.Name, auditorium.Format, auditorium.Capacity).
+		Scan(&auditorium.ID, &auditorium.CreatedAt, &auditorium.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create auditorium: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditoriumRepository_GetAuditoriumsByCinema_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewAuditoriumRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "cinema_id", "name", "format", "capacity", "created_at", "updated_at"}).
+		AddRow(1, 1, "Studio 1", "2D", 100, now, now).
+		AddRow(2, 1, "Studio 2", "IMAX", 60, now, now)
+
+	mock.ExpectQuery("SELECT id, cinema_id, name, format, capacity").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	// Execute
+	auditoriums, err := repo.GetAuditoriumsByCinema(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, auditoriums, 2)
+	assert.Equal(t, "Studio 1", auditoriums[0].Name)
+	assert.Equal(t, "IMAX", auditoriums[1].Format)
+	assert.Equal(t, 60, auditoriums[1].Capacity)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditoriumRepository_GetAuditoriumByID_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewAuditoriumRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT id, cinema_id, name, format, capacity").
+		WithArgs(999).
+		WillReturnError(pgx.ErrNoRows)
+
+	// Execute
+	auditorium, err := repo.GetAuditoriumByID(context.Background(), 999)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, auditorium)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditoriumRepository_CreateAuditorium_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewAuditoriumRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO auditoriums").
+		WithArgs(1, "Studio 1", "2D", 100).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, now, now))
+
+	// Execute
+	auditorium := &models.Auditorium{CinemaID: 1, Name: "Studio 1", Format: "2D", Capacity: 100}
+	err = repo.CreateAuditorium(context.Background(), auditorium)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, auditorium.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateBooking_ConcurrentBookingsForOneSeat fires many parallel bookings that all
+// include the same seat against a real database; exactly one of them may succeed.
+// It runs only when TEST_DATABASE_URL points at a database with the migrations applied.
+func TestCreateBooking_ConcurrentBookingsForOneSeat(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping database concurrency test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	suffix := time.Now().UnixNano()
+	var userID, cinemaID, auditoriumID, movieID, screeningID, seatA, seatB int
+
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO users (username, email, password) VALUES ($1, $2, 'x') RETURNING id`,
+		fmt.Sprintf("race_%d", suffix), fmt.Sprintf("race_%d@example.com", suffix)).Scan(&userID))
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO cinemas (name, location, city, address) VALUES ('Race Cinema', 'Test', 'Test', 'Test') RETURNING id`).Scan(&cinemaID))
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO auditoriums (cinema_id, name, format, capacity) VALUES ($1, 'Studio 1', '2D', 2) RETURNING id`, cinemaID).Scan(&auditoriumID))
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO movies (title, duration_minutes, genre, release_date) VALUES ('Race', 90, 'Test', CURRENT_DATE) RETURNING id`).Scan(&movieID))
+	defer func() {
+		pool.Exec(ctx, `DELETE FROM cinemas WHERE id = $1`, cinemaID)
+		pool.Exec(ctx, `DELETE FROM movies WHERE id = $1`, movieID)
+		pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	}()
+
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO seats (auditorium_id, seat_number, row_number, price) VALUES ($1, '1A', 1, 50000) RETURNING id`, auditoriumID).Scan(&seatA))
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO seats (auditorium_id, seat_number, row_number, price) VALUES ($1, '1B', 1, 50000) RETURNING id`, auditoriumID).Scan(&seatB))
+	start := time.Now().Add(24 * time.Hour)
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO screenings (auditorium_id, movie_id, start_time, end_time, base_price) VALUES ($1, $2, $3, $4, 50000) RETURNING id`,
+		auditoriumID, movieID, start, start.Add(90*time.Minute)).Scan(&screeningID))
+
+	repo := NewBookingRepository(pool)
+	require.NoError(t, NewSeatRepository(pool).CreateSeatAvailability(ctx, screeningID, auditoriumID))
+
+	// Every request contains seatB; the seat order alternates so overlapping
+	// multi-seat bookings would deadlock without ordered locking
+	const workers = 30
+	seatSets := [][]int{{seatB}, {seatA, seatB}, {seatB, seatA}}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, rejected := 0, 0
+	var unexpected []error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(seatIDs []int) {
+			defer wg.Done()
+
+			booking := &models.Booking{
+				UserID:        userID,
+				ScreeningID:   screeningID,
+				Status:        "pending",
+				TotalPrice:    models.NewMoney(int64(len(seatIDs)) * 5000000),
+				PaymentMethod: "cash",
+				PaymentStatus: "pending",
+			}
+			for _, id := range seatIDs {
+				booking.Seats = append(booking.Seats, &models.BookingSeat{SeatID: id, Price: models.MustParseMoney("50000")})
+			}
+
+			err := repo.CreateBooking(ctx, booking)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, models.ErrSeatAlreadyBooked):
+				rejected++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(seatSets[i%len(seatSets)])
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, workers-1, rejected)
+
+	var items int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT COUNT(*) FROM booking_seats WHERE screening_id = $1 AND seat_id = $2`, screeningID, seatB).Scan(&items))
+	assert.Equal(t, 1, items)
+}
@@ -2,11 +2,13 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // BookingRepository handles booking-related database operations
@@ -19,31 +21,145 @@ func NewBookingRepository(db Database) *BookingRepository {
 	return &BookingRepository{db: db}
 }
 
-// CreateBooking creates a new booking
+// CreateBooking creates a booking header with one line item per seat in a single transaction.
+// The requested seat_availability rows are locked with SELECT ... FOR UPDATE before being marked
+// unavailable, so concurrent bookings for the same seat are serialized; if any seat is already
+// taken nothing is written and models.ErrSeatAlreadyBooked is returned.
+// The screening's cinema is share-locked so it cannot be archived while the booking is made;
+// if it already is archived, models.ErrCinemaNotFound is returned.
 func (r *BookingRepository) CreateBooking(ctx context.Context, booking *models.Booking) error {
-	query := `INSERT INTO bookings (user_id, cinema_id, seat_id, show_date, show_time, status, total_price, payment_method, payment_status) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, booking_date, created_at, updated_at`
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	err := r.db.QueryRow(ctx, query, booking.UserID, booking.CinemaID, booking.SeatID, booking.ShowDate, booking.ShowTime,
-		booking.Status, booking.TotalPrice, booking.PaymentMethod, booking.PaymentStatus).
-		Scan(&booking.ID, &booking.BookingDate, &booking.CreatedAt, &booking.UpdatedAt)
+	cinemaQuery := `SELECT c.id FROM screenings sc 
+	JOIN auditoriums a ON sc.auditorium_id = a.id 
+	JOIN cinemas c ON a.cinema_id = c.id 
+	WHERE sc.id = $1 AND c.archived_at IS NULL FOR SHARE OF c`
 
+	var cinemaID int
+	err = tx.QueryRow(ctx, cinemaQuery, booking.ScreeningID).Scan(&cinemaID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrCinemaNotFound
+		}
+		return fmt.Errorf("failed to lock cinema: %w", err)
+	}
+
+	seatIDs := make([]int, 0, len(booking.Seats))
+	for _, item := range booking.Seats {
+		seatIDs = append(seatIDs, item.SeatID)
+	}
+
+	// Lock the seats in a fixed order so concurrent multi-seat bookings cannot deadlock.
+	// Seats removed from the seat map count as taken.
+	lockQuery := `SELECT sa.seat_id, sa.is_available AND s.is_active FROM seat_availability sa 
+	JOIN seats s ON sa.seat_id = s.id 
+	WHERE sa.screening_id = $1 AND sa.seat_id = ANY($2) ORDER BY sa.seat_id FOR UPDATE OF sa`
+
+	rows, err := tx.Query(ctx, lockQuery, booking.ScreeningID, seatIDs)
+	if err != nil {
+		return fmt.Errorf("failed to lock seats: %w", err)
+	}
+
+	locked := 0
+	allAvailable := true
+	for rows.Next() {
+		var seatID int
+		var isAvailable bool
+		if err := rows.Scan(&seatID, &isAvailable); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan seat lock: %w", err)
+		}
+		if !isAvailable {
+			allAvailable = false
+		}
+		locked++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock seats: %w", err)
+	}
+	if !allAvailable || locked != len(seatIDs) {
+		return models.ErrSeatAlreadyBooked
+	}
+
+	reserveQuery := `UPDATE seat_availability SET is_available = FALSE, updated_at = CURRENT_TIMESTAMP 
+	WHERE screening_id = $1 AND seat_id = ANY($2)`
+
+	_, err = tx.Exec(ctx, reserveQuery, booking.ScreeningID, seatIDs)
+	if err != nil {
+		return fmt.Errorf("failed to reserve seats: %w", err)
+	}
+
+	headerQuery := `INSERT INTO bookings (reference, user_id, screening_id, status, total_price, payment_method, payment_status, hold_expires_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, booking_date, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, headerQuery, booking.Reference, booking.UserID, booking.ScreeningID,
+		booking.Status, booking.TotalPrice, booking.PaymentMethod, booking.PaymentStatus, booking.HoldExpiresAt).
+		Scan(&booking.ID, &booking.BookingDate, &booking.CreatedAt, &booking.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create booking: %w", err)
 	}
+
+	// The partial unique index on active booking seats is the last line of defence
+	// against a double booking that slipped past the row locks
+	itemQuery := `INSERT INTO booking_seats (booking_id, screening_id, seat_id, price) 
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	for _, item := range booking.Seats {
+		item.BookingID = booking.ID
+		item.ScreeningID = booking.ScreeningID
+		err = tx.QueryRow(ctx, itemQuery, item.BookingID, item.ScreeningID, item.SeatID, item.Price).
+			Scan(&item.ID, &item.CreatedAt)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return models.ErrSeatAlreadyBooked
+			}
+			return fmt.Errorf("failed to create booking seat: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit booking: %w", err)
+	}
 	return nil
 }
 
 // GetBookingByID retrieves a booking by ID
 func (r *BookingRepository) GetBookingByID(ctx context.Context, id int) (*models.Booking, error) {
 	booking := &models.Booking{}
-	query := `SELECT id, user_id, cinema_id, seat_id, show_date, show_time, booking_date, status, total_price, 
-	payment_method, payment_status, created_at, updated_at FROM bookings WHERE id = $1`
+	query := `SELECT id, reference, user_id, screening_id, booking_date, status, total_price, 
+	payment_method, payment_status, hold_expires_at, created_at, updated_at FROM bookings WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&booking.ID, &booking.UserID, &booking.CinemaID, &booking.SeatID, &booking.ShowDate, &booking.ShowTime,
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&booking.ID, &booking.Reference, &booking.UserID, &booking.ScreeningID,
 			&booking.BookingDate, &booking.Status, &booking.TotalPrice, &booking.PaymentMethod, &booking.PaymentStatus,
-			&booking.CreatedAt, &booking.UpdatedAt)
+			&booking.HoldExpiresAt, &booking.CreatedAt, &booking.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	return booking, nil
+}
+
+// GetBookingByIDForUpdate retrieves a booking by ID and locks its row until the end of the
+// transaction in ctx, so its status cannot change before the caller acts on it
+func (r *BookingRepository) GetBookingByIDForUpdate(ctx context.Context, id int) (*models.Booking, error) {
+	booking := &models.Booking{}
+	query := `SELECT id, reference, user_id, screening_id, booking_date, status, total_price, 
+	payment_method, payment_status, hold_expires_at, created_at, updated_at FROM bookings WHERE id = $1 FOR UPDATE`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&booking.ID, &booking.Reference, &booking.UserID, &booking.ScreeningID,
+			&booking.BookingDate, &booking.Status, &booking.TotalPrice, &booking.PaymentMethod, &booking.PaymentStatus,
+			&booking.HoldExpiresAt, &booking.CreatedAt, &booking.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -62,17 +178,17 @@ func (r *BookingRepository) GetUserBookings(ctx context.Context, userID int, pag
 	// Get total count
 	var total int
 	countQuery := "SELECT COUNT(*) FROM bookings WHERE user_id = $1"
-	err := r.db.QueryRow(ctx, countQuery, userID).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, userID).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count bookings: %w", err)
 	}
 
 	// Get paginated data
-	query := `SELECT id, user_id, cinema_id, seat_id, show_date, show_time, booking_date, status, total_price, 
-	payment_method, payment_status, created_at, updated_at FROM bookings 
+	query := `SELECT id, reference, user_id, screening_id, booking_date, status, total_price, 
+	payment_method, payment_status, hold_expires_at, created_at, updated_at FROM bookings 
 	WHERE user_id = $1 ORDER BY booking_date DESC LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get user bookings: %w", err)
 	}
@@ -81,9 +197,9 @@ func (r *BookingRepository) GetUserBookings(ctx context.Context, userID int, pag
 	bookings := []*models.Booking{}
 	for rows.Next() {
 		booking := &models.Booking{}
-		err := rows.Scan(&booking.ID, &booking.UserID, &booking.CinemaID, &booking.SeatID, &booking.ShowDate,
-			&booking.ShowTime, &booking.BookingDate, &booking.Status, &booking.TotalPrice, &booking.PaymentMethod,
-			&booking.PaymentStatus, &booking.CreatedAt, &booking.UpdatedAt)
+		err := rows.Scan(&booking.ID, &booking.Reference, &booking.UserID, &booking.ScreeningID,
+			&booking.BookingDate, &booking.Status, &booking.TotalPrice, &booking.PaymentMethod,
+			&booking.PaymentStatus, &booking.HoldExpiresAt, &booking.CreatedAt, &booking.UpdatedAt)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan booking: %w", err)
 		}
@@ -96,7 +212,7 @@ func (r *BookingRepository) GetUserBookings(ctx context.Context, userID int, pag
 // UpdateBookingStatus updates the status of a booking
 func (r *BookingRepository) UpdateBookingStatus(ctx context.Context, id int, status string) error {
 	query := `UPDATE bookings SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, status, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, status, id)
 	if err != nil {
 		return fmt.Errorf("failed to update booking status: %w", err)
 	}
@@ -106,48 +222,43 @@ func (r *BookingRepository) UpdateBookingStatus(ctx context.Context, id int, sta
 // UpdateBookingPaymentStatus updates the payment status of a booking
 func (r *BookingRepository) UpdateBookingPaymentStatus(ctx context.Context, id int, paymentStatus string) error {
 	query := `UPDATE bookings SET payment_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, paymentStatus, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, paymentStatus, id)
 	if err != nil {
 		return fmt.Errorf("failed to update booking payment status: %w", err)
 	}
 	return nil
 }
 
-// CheckSeatBooked checks if a seat is already booked for the given date and time
-func (r *BookingRepository) CheckSeatBooked(ctx context.Context, seatID int, showDate time.Time, showTime string) (bool, error) {
-	query := `SELECT COUNT(*) FROM bookings WHERE seat_id = $1 AND show_date = $2 AND show_time = $3 AND status != 'cancelled'`
-
-	var count int
-	err := r.db.QueryRow(ctx, query, seatID, showDate.Format("2006-01-02"), showTime).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("failed to check seat booking: %w", err)
-	}
-
-	return count > 0, nil
-}
-
-// GetBookingWithDetails retrieves booking with cinema and seat details
+// GetBookingWithDetails retrieves booking with screening, cinema, movie and seat details
 func (r *BookingRepository) GetBookingWithDetails(ctx context.Context, id int) (*models.Booking, error) {
 	booking := &models.Booking{}
+	screening := &models.Screening{}
 	cinema := &models.Cinema{}
-	seat := &models.Seat{}
+	movie := &models.Movie{}
 
-	query := `SELECT b.id, b.user_id, b.cinema_id, b.seat_id, b.show_date, b.show_time, b.booking_date, b.status, b.total_price, 
-	b.payment_method, b.payment_status, b.created_at, b.updated_at,
-	c.id, c.name, c.location, c.city, c.address, c.total_seats, c.image_url, c.created_at, c.updated_at,
-	s.id, s.cinema_id, s.seat_number, s.row_number, s.seat_type, s.price, s.created_at, s.updated_at
+	query := `SELECT b.id, b.reference, b.user_id, b.screening_id, b.booking_date, b.status, b.total_price, 
+	b.payment_method, b.payment_status, b.hold_expires_at, b.created_at, b.updated_at,
+	sc.id, a.cinema_id, sc.auditorium_id, sc.movie_id, sc.start_time, sc.end_time, sc.base_price, sc.created_at, sc.updated_at,
+	c.id, c.name, c.location, c.city, c.address, (SELECT COALESCE(SUM(capacity), 0) FROM auditoriums WHERE cinema_id = c.id), 
+	c.image_url, c.created_at, c.updated_at,
+	m.id, m.title, m.synopsis, m.duration_minutes, m.genre, m.age_rating, m.poster_url, m.release_date, m.end_date, m.created_at, m.updated_at
 	FROM bookings b
-	JOIN cinemas c ON b.cinema_id = c.id
-	JOIN seats s ON b.seat_id = s.id
+	JOIN screenings sc ON b.screening_id = sc.id
+	JOIN auditoriums a ON sc.auditorium_id = a.id
+	JOIN cinemas c ON a.cinema_id = c.id
+	JOIN movies m ON sc.movie_id = m.id
 	WHERE b.id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&booking.ID, &booking.UserID, &booking.CinemaID, &booking.SeatID, &booking.ShowDate, &booking.ShowTime,
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&booking.ID, &booking.Reference, &booking.UserID, &booking.ScreeningID,
 			&booking.BookingDate, &booking.Status, &booking.TotalPrice, &booking.PaymentMethod, &booking.PaymentStatus,
-			&booking.CreatedAt, &booking.UpdatedAt,
+			&booking.HoldExpiresAt, &booking.CreatedAt, &booking.UpdatedAt,
+			&screening.ID, &screening.CinemaID, &screening.AuditoriumID, &screening.MovieID, &screening.StartTime, &screening.EndTime,
+			&screening.BasePrice, &screening.CreatedAt, &screening.UpdatedAt,
 			&cinema.ID, &cinema.Name, &cinema.Location, &cinema.City, &cinema.Address, &cinema.TotalSeats, &cinema.ImageURL,
 			&cinema.CreatedAt, &cinema.UpdatedAt,
-			&seat.ID, &seat.CinemaID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt)
+			&movie.ID, &movie.Title, &movie.Synopsis, &movie.Duration, &movie.Genre, &movie.AgeRating, &movie.PosterURL,
+			&movie.ReleaseDate, &movie.EndDate, &movie.CreatedAt, &movie.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -156,7 +267,164 @@ func (r *BookingRepository) GetBookingWithDetails(ctx context.Context, id int) (
 		return nil, fmt.Errorf("failed to get booking with details: %w", err)
 	}
 
+	seats, err := r.GetBookingSeats(ctx, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	booking.Seats = seats
+	booking.Screening = screening
 	booking.Cinema = cinema
-	booking.Seat = seat
+	booking.Movie = movie
 	return booking, nil
 }
+
+// GetBookingSeats retrieves the seat line items of a booking
+func (r *BookingRepository) GetBookingSeats(ctx context.Context, bookingID int) ([]*models.BookingSeat, error) {
+	query := `SELECT bs.id, bs.booking_id, bs.screening_id, bs.seat_id, bs.price, bs.created_at,
+	s.id, s.auditorium_id, s.seat_number, s.row_number, s.seat_type, s.price, s.is_active, s.created_at, s.updated_at
+	FROM booking_seats bs
+	JOIN seats s ON bs.seat_id = s.id
+	WHERE bs.booking_id = $1
+	ORDER BY s.row_number, s.seat_number`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking seats: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.BookingSeat{}
+	for rows.Next() {
+		item := &models.BookingSeat{}
+		seat := &models.Seat{}
+		err := rows.Scan(&item.ID, &item.BookingID, &item.ScreeningID, &item.SeatID, &item.Price, &item.CreatedAt,
+			&seat.ID, &seat.AuditoriumID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.IsActive, &seat.CreatedAt, &seat.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking seat: %w", err)
+		}
+		item.Seat = seat
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// ExpireHolds expires every pending, unpaid booking whose seat hold ended before now.
+// In a single transaction the bookings are marked expired, their seat line items are
+// deactivated and the seats are made available again. Bookings locked by a concurrent
+// transaction (e.g. a payment in flight) are skipped and picked up by a later sweep.
+// It returns the number of bookings expired.
+func (r *BookingRepository) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	expireQuery := `UPDATE bookings SET status = 'expired', updated_at = CURRENT_TIMESTAMP 
+	WHERE id IN (
+		SELECT id FROM bookings 
+		WHERE status = 'pending' AND payment_status = 'pending' AND hold_expires_at <= $1 
+		ORDER BY id FOR UPDATE SKIP LOCKED
+	) RETURNING id`
+
+	rows, err := tx.Query(ctx, expireQuery, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	bookingIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired booking: %w", err)
+		}
+		bookingIDs = append(bookingIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	if len(bookingIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := releaseBookingSeats(ctx, tx, bookingIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit expired holds: %w", err)
+	}
+	return len(bookingIDs), nil
+}
+
+// CancelBooking marks a pending or confirmed booking as cancelled and releases its seats
+// in a single transaction. If the booking is no longer pending or confirmed, e.g. because
+// it expired or was cancelled concurrently, or a payment for it is still being settled by
+// the provider, models.ErrBookingNotCancellable is returned.
+func (r *BookingRepository) CancelBooking(ctx context.Context, bookingID int) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	cancelQuery := `UPDATE bookings SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP 
+	WHERE id = $1 AND status IN ('pending', 'confirmed') AND payment_status <> 'processing' RETURNING id`
+
+	var id int
+	err = tx.QueryRow(ctx, cancelQuery, bookingID).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrBookingNotCancellable
+		}
+		return fmt.Errorf("failed to cancel booking: %w", err)
+	}
+
+	if err := releaseBookingSeats(ctx, tx, []int{bookingID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit booking cancellation: %w", err)
+	}
+	return nil
+}
+
+// releaseBookingSeats makes the seats of the given bookings available again and
+// deactivates their line items so the seats can be booked by someone else. Seats
+// removed from the seat map while booked stay unavailable.
+func releaseBookingSeats(ctx context.Context, tx pgx.Tx, bookingIDs []int) error {
+	releaseQuery := `UPDATE seat_availability sa SET is_available = s.is_active, updated_at = CURRENT_TIMESTAMP 
+	FROM booking_seats bs, seats s 
+	WHERE bs.booking_id = ANY($1) AND bs.is_active 
+	AND sa.screening_id = bs.screening_id AND sa.seat_id = bs.seat_id AND s.id = bs.seat_id`
+
+	_, err := tx.Exec(ctx, releaseQuery, bookingIDs)
+	if err != nil {
+		return fmt.Errorf("failed to release booked seats: %w", err)
+	}
+
+	deactivateQuery := `UPDATE booking_seats SET is_active = FALSE WHERE booking_id = ANY($1)`
+
+	_, err = tx.Exec(ctx, deactivateQuery, bookingIDs)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate booking seats: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key constraint violation
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
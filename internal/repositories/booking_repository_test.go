@@ -16,11 +16,6 @@ type mockDB struct {
 	pool pgxmock.PgxPoolIface
 }
 
-func (m *mockDB) Close(ctx context.Context) error {
-	m.pool.Close()
-	return nil
-}
-
 func (m *mockDB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
 	return m.pool.Query(ctx, query, args...)
 }
@@ -46,43 +41,141 @@ func TestCreateBooking_Success(t *testing.T) {
 
 	booking := &models.Booking{
 		UserID:        1,
-		CinemaID:      1,
-		SeatID:        1,
-		ShowDate:      time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
-		ShowTime:      "19:00",
+		ScreeningID:   3,
 		Status:        "pending",
-		TotalPrice:    50000,
+		TotalPrice:    models.MustParseMoney("150000"),
 		PaymentMethod: "cash",
 		PaymentStatus: "pending",
+		Seats: []*models.BookingSeat{
+			{SeatID: 1, Price: models.MustParseMoney("50000")},
+			{SeatID: 2, Price: models.MustParseMoney("100000")},
+		},
 	}
 
+	now := time.Now()
+	pool.ExpectBegin()
+	pool.ExpectQuery("FOR SHARE OF c").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	pool.ExpectQuery(`SELECT sa.seat_id, sa.is_available AND s.is_active FROM seat_availability sa .* FOR UPDATE OF sa`).
+		WithArgs(3, []int{1, 2}).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_id", "is_available"}).AddRow(1, true).AddRow(2, true))
+	pool.ExpectExec("UPDATE seat_availability SET is_available = FALSE").
+		WithArgs(3, []int{1, 2}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
 	pool.ExpectQuery("INSERT INTO bookings").
-		WithArgs(booking.UserID, booking.CinemaID, booking.SeatID, booking.ShowDate, booking.ShowTime, booking.Status, booking.TotalPrice, booking.PaymentMethod, booking.PaymentStatus).
-		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_date", "created_at", "updated_at"}).AddRow(1, time.Now(), time.Now(), time.Now()))
+		WithArgs(booking.Reference, booking.UserID, booking.ScreeningID, booking.Status, booking.TotalPrice, booking.PaymentMethod, booking.PaymentStatus, booking.HoldExpiresAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_date", "created_at", "updated_at"}).AddRow(7, now, now, now))
+	pool.ExpectQuery("INSERT INTO booking_seats").
+		WithArgs(7, 3, 1, models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(11, now))
+	pool.ExpectQuery("INSERT INTO booking_seats").
+		WithArgs(7, 3, 2, models.MustParseMoney("100000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(12, now))
+	pool.ExpectCommit()
 
 	err = repo.CreateBooking(context.Background(), booking)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 1, booking.ID)
+	assert.Equal(t, 7, booking.ID)
+	assert.Equal(t, 7, booking.Seats[1].BookingID)
+	assert.Equal(t, 12, booking.Seats[1].ID)
 	assert.NoError(t, pool.ExpectationsWereMet())
 }
 
-func TestCheckSeatBooked(t *testing.T) {
+func TestCreateBooking_SeatUnavailableRollsBack(t *testing.T) {
 	pool, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer pool.Close()
 
 	repo := NewBookingRepository(&mockDB{pool: pool})
-	showDate := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
 
-	pool.ExpectQuery(`SELECT COUNT\(\*\) FROM bookings`).
-		WithArgs(1, showDate.Format("2006-01-02"), "19:00").
-		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+	booking := &models.Booking{
+		UserID:      1,
+		ScreeningID: 3,
+		Seats: []*models.BookingSeat{
+			{SeatID: 1, Price: models.MustParseMoney("50000")},
+			{SeatID: 2, Price: models.MustParseMoney("50000")},
+		},
+	}
+
+	// Seat 2 was taken by another booking
+	pool.ExpectBegin()
+	pool.ExpectQuery("FOR SHARE OF c").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	pool.ExpectQuery(`SELECT sa.seat_id, sa.is_available AND s.is_active FROM seat_availability sa .* FOR UPDATE OF sa`).
+		WithArgs(3, []int{1, 2}).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_id", "is_available"}).AddRow(1, true).AddRow(2, false))
+	pool.ExpectRollback()
 
-	booked, err := repo.CheckSeatBooked(context.Background(), 1, showDate, "19:00")
+	err = repo.CreateBooking(context.Background(), booking)
+
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyBooked)
+	assert.Equal(t, 0, booking.ID)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
 
+func TestCreateBooking_ArchivedCinema(t *testing.T) {
+	pool, err := pgxmock.NewPool()
 	assert.NoError(t, err)
-	assert.True(t, booked)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	booking := &models.Booking{
+		UserID:      1,
+		ScreeningID: 3,
+		Seats:       []*models.BookingSeat{{SeatID: 1, Price: models.MustParseMoney("50000")}},
+	}
+
+	pool.ExpectBegin()
+	pool.ExpectQuery("WHERE sc.id = \\$1 AND c.archived_at IS NULL FOR SHARE OF c").
+		WithArgs(3).
+		WillReturnError(pgx.ErrNoRows)
+	pool.ExpectRollback()
+
+	err = repo.CreateBooking(context.Background(), booking)
+
+	assert.ErrorIs(t, err, models.ErrCinemaNotFound)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestCreateBooking_UniqueViolationMapsToSeatAlreadyBooked(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	booking := &models.Booking{
+		UserID:      1,
+		ScreeningID: 3,
+		Seats:       []*models.BookingSeat{{SeatID: 1, Price: models.MustParseMoney("50000")}},
+	}
+
+	now := time.Now()
+	pool.ExpectBegin()
+	pool.ExpectQuery("FOR SHARE OF c").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	pool.ExpectQuery(`SELECT sa.seat_id, sa.is_available AND s.is_active FROM seat_availability sa .* FOR UPDATE OF sa`).
+		WithArgs(3, []int{1}).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_id", "is_available"}).AddRow(1, true))
+	pool.ExpectExec("UPDATE seat_availability SET is_available = FALSE").
+		WithArgs(3, []int{1}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	pool.ExpectQuery("INSERT INTO bookings").
+		WithArgs("", 1, 3, "", models.Money{}, "", "", (*time.Time)(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_date", "created_at", "updated_at"}).AddRow(7, now, now, now))
+	pool.ExpectQuery("INSERT INTO booking_seats").
+		WithArgs(7, 3, 1, models.MustParseMoney("50000")).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_booking_seats_active_seat"})
+	pool.ExpectRollback()
+
+	err = repo.CreateBooking(context.Background(), booking)
+
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyBooked)
 	assert.NoError(t, pool.ExpectationsWereMet())
 }
 
@@ -93,7 +186,7 @@ func TestGetBookingByID_NoRows(t *testing.T) {
 
 	repo := NewBookingRepository(&mockDB{pool: pool})
 
-	pool.ExpectQuery("SELECT id, user_id").WithArgs(99).WillReturnError(pgx.ErrNoRows)
+	pool.ExpectQuery("SELECT id, reference, user_id").WithArgs(99).WillReturnError(pgx.ErrNoRows)
 
 	booking, err := repo.GetBookingByID(context.Background(), 99)
 
@@ -101,3 +194,216 @@ func TestGetBookingByID_NoRows(t *testing.T) {
 	assert.Nil(t, booking)
 	assert.NoError(t, pool.ExpectationsWereMet())
 }
+
+func TestGetBookingByIDForUpdate_LocksRow(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "reference", "user_id", "screening_id", "booking_date", "status", "total_price",
+		"payment_method", "payment_status", "hold_expires_at", "created_at", "updated_at"}).
+		AddRow(1, "BKG-TEST", 1, 2, now, "cancelled", models.MustParseMoney("100000"), nil, "pending", &now, now, now)
+	pool.ExpectQuery("FROM bookings WHERE id = \\$1 FOR UPDATE").WithArgs(1).WillReturnRows(rows)
+
+	booking, err := repo.GetBookingByIDForUpdate(context.Background(), 1)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, booking) {
+		assert.Equal(t, "cancelled", booking.Status)
+	}
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestGetBookingSeats(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{
+		"bs_id", "bs_booking_id", "bs_screening_id", "bs_seat_id", "bs_price", "bs_created_at",
+		"s_id", "s_auditorium_id", "s_seat_number", "s_row_number", "s_seat_type", "s_price", "s_is_active", "s_created_at", "s_updated_at",
+	}).
+		AddRow(11, 7, 3, 1, 50000.0, now, 1, 4, "1A", 1, "standard", 50000.0, true, now, now).
+		AddRow(12, 7, 3, 2, 50000.0, now, 2, 4, "1B", 1, "standard", 50000.0, true, now, now)
+
+	pool.ExpectQuery("SELECT bs.id, bs.booking_id").WithArgs(7).WillReturnRows(rows)
+
+	seats, err := repo.GetBookingSeats(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, seats, 2)
+	assert.Equal(t, "1B", seats[1].Seat.SeatNumber)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestExpireHolds_ReleasesSeats(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	now := time.Now()
+	pool.ExpectBegin()
+	pool.ExpectQuery("UPDATE bookings SET status = 'expired'").
+		WithArgs(now).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7).AddRow(9))
+	pool.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active").
+		WithArgs([]int{7, 9}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	pool.ExpectExec("UPDATE booking_seats SET is_active = FALSE").
+		WithArgs([]int{7, 9}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	pool.ExpectCommit()
+
+	expired, err := repo.ExpireHolds(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, expired)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestExpireHolds_NothingToExpire(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	now := time.Now()
+	pool.ExpectBegin()
+	pool.ExpectQuery("UPDATE bookings SET status = 'expired'").
+		WithArgs(now).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	pool.ExpectRollback()
+
+	expired, err := repo.ExpireHolds(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestCancelBooking_ReleasesSeats(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	pool.ExpectBegin()
+	pool.ExpectQuery("UPDATE bookings SET status = 'cancelled'").
+		WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+	pool.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active").
+		WithArgs([]int{7}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	pool.ExpectExec("UPDATE booking_seats SET is_active = FALSE").
+		WithArgs([]int{7}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	pool.ExpectCommit()
+
+	err = repo.CancelBooking(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestCancelBooking_SeatRemovedFromSeatMapStaysUnbookable(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	seatRepo := NewSeatRepository(&mockDB{pool: pool})
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	// The seat map is uploaded without seat 2 (A2), which booking 7 holds: the seat is
+	// deactivated but its availability is left alone while it is booked
+	now := time.Now()
+	layout := []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard"}}}
+	seats := []*models.Seat{{SeatNumber: "A1", RowNumber: 1, SeatType: "standard", Price: models.MustParseMoney("50000")}}
+
+	pool.ExpectBegin()
+	pool.ExpectQuery("SELECT id FROM auditoriums .* FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(4))
+	pool.ExpectExec("UPDATE auditoriums SET seat_map").
+		WithArgs([]byte(`[{"label":"A","cells":["standard"]}]`), 1, 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	pool.ExpectQuery("INSERT INTO seats .* ON CONFLICT").
+		WithArgs(4, "A1", 1, "standard", models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, now, now))
+	pool.ExpectExec("UPDATE seats SET is_active = FALSE").
+		WithArgs(4, []string{"A1"}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	pool.ExpectExec("INSERT INTO seat_availability").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	pool.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active.* AND NOT EXISTS").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	pool.ExpectCommit()
+
+	assert.NoError(t, seatRepo.SaveSeatMap(context.Background(), 4, layout, seats))
+
+	// Cancelling the booking releases the seat to its active flag, not to available
+	pool.ExpectBegin()
+	pool.ExpectQuery("UPDATE bookings SET status = 'cancelled'").
+		WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+	pool.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active.* AND s.id = bs.seat_id").
+		WithArgs([]int{7}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	pool.ExpectExec("UPDATE booking_seats SET is_active = FALSE").
+		WithArgs([]int{7}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	pool.ExpectCommit()
+
+	assert.NoError(t, repo.CancelBooking(context.Background(), 7))
+
+	// So booking the removed seat again is refused
+	booking := &models.Booking{
+		UserID:      1,
+		ScreeningID: 3,
+		Seats:       []*models.BookingSeat{{SeatID: 2, Price: models.MustParseMoney("50000")}},
+	}
+
+	pool.ExpectBegin()
+	pool.ExpectQuery("FOR SHARE OF c").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	pool.ExpectQuery(`SELECT sa.seat_id, sa.is_available AND s.is_active FROM seat_availability sa .* FOR UPDATE OF sa`).
+		WithArgs(3, []int{2}).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_id", "is_available"}).AddRow(2, false))
+	pool.ExpectRollback()
+
+	err = repo.CreateBooking(context.Background(), booking)
+
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyBooked)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
+
+func TestCancelBooking_NotCancellable(t *testing.T) {
+	pool, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	repo := NewBookingRepository(&mockDB{pool: pool})
+
+	pool.ExpectBegin()
+	pool.ExpectQuery("UPDATE bookings SET status = 'cancelled'").
+		WithArgs(7).
+		WillReturnError(pgx.ErrNoRows)
+	pool.ExpectRollback()
+
+	err = repo.CancelBooking(context.Background(), 7)
+
+	assert.ErrorIs(t, err, models.ErrBookingNotCancellable)
+	assert.NoError(t, pool.ExpectationsWereMet())
+}
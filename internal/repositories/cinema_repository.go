@@ -8,6 +8,9 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// cinemaTotalSeatsColumn computes a cinema's seat count from its auditoriums
+const cinemaTotalSeatsColumn = `(SELECT COALESCE(SUM(capacity), 0) FROM auditoriums WHERE auditoriums.cinema_id = cinemas.id) AS total_seats`
+
 // CinemaRepository handles cinema-related database operations
 type CinemaRepository struct {
 	db Database
@@ -22,23 +25,19 @@ func NewCinemaRepository(db Database) *CinemaRepository {
 func (r *CinemaRepository) GetAllCinemas(ctx context.Context, page, limit int, filters *models.CinemaFilters) ([]*models.Cinema, int, error) {
 	offset := (page - 1) * limit
 
-	// Build WHERE clause
-	whereClause := ""
+	// Build WHERE clause; archived cinemas are not listed
+	whereClause := " WHERE archived_at IS NULL"
 	args := []interface{}{}
 	argIndex := 1
 
 	if filters.City != "" {
-		whereClause += fmt.Sprintf(" WHERE city ILIKE $%d", argIndex)
+		whereClause += fmt.Sprintf(" AND city ILIKE $%d", argIndex)
 		args = append(args, "%"+filters.City+"%")
 		argIndex++
 	}
 
 	if filters.Name != "" {
-		if whereClause == "" {
-			whereClause += fmt.Sprintf(" WHERE name ILIKE $%d", argIndex)
-		} else {
-			whereClause += fmt.Sprintf(" AND name ILIKE $%d", argIndex)
-		}
+		whereClause += fmt.Sprintf(" AND name ILIKE $%d", argIndex)
 		args = append(args, "%"+filters.Name+"%")
 		argIndex++
 	}
@@ -46,17 +45,17 @@ func (r *CinemaRepository) GetAllCinemas(ctx context.Context, page, limit int, f
 	// Get total count
 	countQuery := "SELECT COUNT(*) FROM cinemas" + whereClause
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count cinemas: %w", err)
 	}
 
 	// Get paginated data
-	query := fmt.Sprintf("SELECT id, name, location, city, address, total_seats, image_url, created_at, updated_at "+
-		"FROM cinemas%s ORDER BY name ASC LIMIT $%d OFFSET $%d", whereClause, argIndex, argIndex+1)
+	query := fmt.Sprintf("SELECT id, name, location, city, address, %s, image_url, created_at, updated_at "+
+		"FROM cinemas%s ORDER BY name ASC LIMIT $%d OFFSET $%d", cinemaTotalSeatsColumn, whereClause, argIndex, argIndex+1)
 	args = append(args, limit, offset)
 
-	rows, err := r.db.Query(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get cinemas: %w", err)
 	}
@@ -75,14 +74,14 @@ func (r *CinemaRepository) GetAllCinemas(ctx context.Context, page, limit int, f
 	return cinemas, total, nil
 }
 
-// GetCinemaByID retrieves a cinema by ID
+// GetCinemaByID retrieves a cinema by ID, including archived cinemas so booking history keeps resolving
 func (r *CinemaRepository) GetCinemaByID(ctx context.Context, id int) (*models.Cinema, error) {
 	cinema := &models.Cinema{}
-	query := `SELECT id, name, location, city, address, total_seats, image_url, created_at, updated_at 
+	query := `SELECT id, name, location, city, address, ` + cinemaTotalSeatsColumn + `, image_url, created_at, updated_at, archived_at 
 	FROM cinemas WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&cinema.ID, &cinema.Name, &cinema.Location, &cinema.City, &cinema.Address, &cinema.TotalSeats, &cinema.ImageURL, &cinema.CreatedAt, &cinema.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&cinema.ID, &cinema.Name, &cinema.Location, &cinema.City, &cinema.Address, &cinema.TotalSeats, &cinema.ImageURL, &cinema.CreatedAt, &cinema.UpdatedAt, &cinema.ArchivedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -94,12 +93,12 @@ func (r *CinemaRepository) GetCinemaByID(ctx context.Context, id int) (*models.C
 	return cinema, nil
 }
 
-// CreateCinema creates a new cinema (for admin/seeding)
+// CreateCinema creates a new cinema
 func (r *CinemaRepository) CreateCinema(ctx context.Context, cinema *models.Cinema) error {
-	query := `INSERT INTO cinemas (name, location, city, address, total_seats, image_url) 
-	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO cinemas (name, location, city, address, image_url) 
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, cinema.Name, cinema.Location, cinema.City, cinema.Address, cinema.TotalSeats, cinema.ImageURL).
+	err := conn(ctx, r.db).QueryRow(ctx, query, cinema.Name, cinema.Location, cinema.City, cinema.Address, cinema.ImageURL).
 		Scan(&cinema.ID, &cinema.CreatedAt, &cinema.UpdatedAt)
 
 	if err != nil {
@@ -107,3 +106,105 @@ func (r *CinemaRepository) CreateCinema(ctx context.Context, cinema *models.Cine
 	}
 	return nil
 }
+
+// UpdateCinema saves the editable fields of a cinema. It returns models.ErrCinemaNotFound
+// if the cinema does not exist or is archived.
+func (r *CinemaRepository) UpdateCinema(ctx context.Context, cinema *models.Cinema) error {
+	query := `UPDATE cinemas SET name = $1, location = $2, city = $3, address = $4, image_url = $5, updated_at = CURRENT_TIMESTAMP 
+	WHERE id = $6 AND archived_at IS NULL RETURNING updated_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, cinema.Name, cinema.Location, cinema.City, cinema.Address, cinema.ImageURL, cinema.ID).
+		Scan(&cinema.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrCinemaNotFound
+		}
+		return fmt.Errorf("failed to update cinema: %w", err)
+	}
+	return nil
+}
+
+// ArchiveCinema soft-deletes a cinema: it is no longer listed but stays linked to its
+// bookings. Unpaid bookings for its upcoming screenings are expired and their seats
+// released, so they cannot be paid any more. It returns models.ErrCinemaHasFutureBookings
+// while paid bookings for upcoming screenings exist, and models.ErrCinemaNotFound if the
+// cinema does not exist or is already archived.
+func (r *CinemaRepository) ArchiveCinema(ctx context.Context, id int) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the cinema so concurrent deletes are serialized
+	var cinemaID int
+	err = tx.QueryRow(ctx, `SELECT id FROM cinemas WHERE id = $1 AND archived_at IS NULL FOR UPDATE`, id).Scan(&cinemaID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrCinemaNotFound
+		}
+		return fmt.Errorf("failed to lock cinema: %w", err)
+	}
+
+	futureBookingsQuery := `SELECT EXISTS (
+		SELECT 1 FROM bookings b
+		JOIN screenings sc ON b.screening_id = sc.id
+		JOIN auditoriums a ON sc.auditorium_id = a.id
+		WHERE a.cinema_id = $1 AND sc.start_time > NOW()
+		AND b.status IN ('pending', 'confirmed') AND b.payment_status IN ('processing', 'paid')
+	)`
+
+	var hasFutureBookings bool
+	err = tx.QueryRow(ctx, futureBookingsQuery, id).Scan(&hasFutureBookings)
+	if err != nil {
+		return fmt.Errorf("failed to check future bookings: %w", err)
+	}
+	if hasFutureBookings {
+		return models.ErrCinemaHasFutureBookings
+	}
+
+	// A payment in flight for one of these bookings is refunded once it sees the booking expired
+	expireQuery := `UPDATE bookings SET status = 'expired', updated_at = CURRENT_TIMESTAMP 
+	WHERE id IN (
+		SELECT b.id FROM bookings b 
+		JOIN screenings sc ON b.screening_id = sc.id 
+		JOIN auditoriums a ON sc.auditorium_id = a.id 
+		WHERE a.cinema_id = $1 AND sc.start_time > NOW() 
+		AND b.status = 'pending' AND b.payment_status = 'pending' 
+		ORDER BY b.id FOR UPDATE OF b
+	) RETURNING id`
+
+	rows, err := tx.Query(ctx, expireQuery, id)
+	if err != nil {
+		return fmt.Errorf("failed to expire pending bookings: %w", err)
+	}
+	bookingIDs := []int{}
+	for rows.Next() {
+		var bookingID int
+		if err := rows.Scan(&bookingID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan expired booking: %w", err)
+		}
+		bookingIDs = append(bookingIDs, bookingID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to expire pending bookings: %w", err)
+	}
+	if len(bookingIDs) > 0 {
+		if err := releaseBookingSeats(ctx, tx, bookingIDs); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE cinemas SET archived_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to archive cinema: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -20,7 +20,7 @@ func TestCinemaRepository_GetAllCinemas_Success(t *testing.T) {
 
 	// Mock count query
 	countRows := pgxmock.NewRows([]string{"count"}).AddRow(2)
-	mock.ExpectQuery("SELECT COUNT").
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM cinemas WHERE archived_at IS NULL").
 		WillReturnRows(countRows)
 
 	// Mock data query
@@ -88,8 +88,8 @@ func TestCinemaRepository_GetCinemaByID_Success(t *testing.T) {
 	repo := NewCinemaRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "name", "location", "city", "address", "total_seats", "image_url", "created_at", "updated_at"}).
-		AddRow(1, "Cinema XXI Plaza", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", 100, "cinema1.jpg", now, now)
+	rows := pgxmock.NewRows([]string{"id", "name", "location", "city", "address", "total_seats", "image_url", "created_at", "updated_at", "archived_at"}).
+		AddRow(1, "Cinema XXI Plaza", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", 100, "cinema1.jpg", now, now, nil)
 
 	mock.ExpectQuery("SELECT id, name, location").
 		WithArgs(1).
@@ -103,6 +103,7 @@ func TestCinemaRepository_GetCinemaByID_Success(t *testing.T) {
 	assert.NotNil(t, cinema)
 	assert.Equal(t, 1, cinema.ID)
 	assert.Equal(t, "Cinema XXI Plaza", cinema.Name)
+	assert.Nil(t, cinema.ArchivedAt)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -138,17 +139,16 @@ func TestCinemaRepository_CreateCinema_Success(t *testing.T) {
 		AddRow(1, now, now)
 
 	mock.ExpectQuery("INSERT INTO cinemas").
-		WithArgs("Cinema XXI Plaza", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", 100, "cinema1.jpg").
+		WithArgs("Cinema XXI Plaza", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", "cinema1.jpg").
 		WillReturnRows(rows)
 
 	// Execute
 	cinema := &models.Cinema{
-		Name:       "Cinema XXI Plaza",
-		Location:   "Jakarta",
-		City:       "Jakarta",
-		Address:    "Jl. Sudirman No. 1",
-		TotalSeats: 100,
-		ImageURL:   "cinema1.jpg",
+		Name:     "Cinema XXI Plaza",
+		Location: "Jakarta",
+		City:     "Jakarta",
+		Address:  "Jl. Sudirman No. 1",
+		ImageURL: "cinema1.jpg",
 	}
 	err = repo.CreateCinema(context.Background(), cinema)
 
@@ -157,3 +157,150 @@ func TestCinemaRepository_CreateCinema_Success(t *testing.T) {
 	assert.Equal(t, 1, cinema.ID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestCinemaRepository_UpdateCinema_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectQuery("UPDATE cinemas SET name .* WHERE id = \\$6 AND archived_at IS NULL").
+		WithArgs("Cinema XXI Plaza Senayan", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", "cinema1.jpg", 1).
+		WillReturnRows(pgxmock.NewRows([]string{"updated_at"}).AddRow(now))
+
+	cinema := &models.Cinema{
+		ID:       1,
+		Name:     "Cinema XXI Plaza Senayan",
+		Location: "Jakarta",
+		City:     "Jakarta",
+		Address:  "Jl. Sudirman No. 1",
+		ImageURL: "cinema1.jpg",
+	}
+	err = repo.UpdateCinema(context.Background(), cinema)
+
+	assert.NoError(t, err)
+	assert.Equal(t, now, cinema.UpdatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCinemaRepository_UpdateCinema_Archived(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("UPDATE cinemas SET name").
+		WithArgs("Closed", "Jakarta", "Jakarta", "Jl. Sudirman No. 1", "", 1).
+		WillReturnError(pgx.ErrNoRows)
+
+	err = repo.UpdateCinema(context.Background(), &models.Cinema{ID: 1, Name: "Closed", Location: "Jakarta", City: "Jakarta", Address: "Jl. Sudirman No. 1"})
+
+	assert.ErrorIs(t, err, models.ErrCinemaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCinemaRepository_ArchiveCinema_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM cinemas .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("UPDATE bookings SET status = 'expired'").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mock.ExpectExec("UPDATE cinemas SET archived_at").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = repo.ArchiveCinema(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCinemaRepository_ArchiveCinema_ExpiresUnpaidBookings(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM cinemas .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("UPDATE bookings SET status = 'expired'.* b.status = 'pending' AND b.payment_status = 'pending'").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(4).AddRow(9))
+	mock.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active").
+		WithArgs([]int{4, 9}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	mock.ExpectExec("UPDATE booking_seats SET is_active = FALSE").
+		WithArgs([]int{4, 9}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	mock.ExpectExec("UPDATE cinemas SET archived_at").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = repo.ArchiveCinema(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCinemaRepository_ArchiveCinema_FuturePaidBookings(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM cinemas .* FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.ArchiveCinema(context.Background(), 1)
+
+	assert.ErrorIs(t, err, models.ErrCinemaHasFutureBookings)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCinemaRepository_ArchiveCinema_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCinemaRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM cinemas .* FOR UPDATE").
+		WithArgs(9).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.ArchiveCinema(context.Background(), 9)
+
+	assert.ErrorIs(t, err, models.ErrCinemaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/jackc/pgx/v5"
@@ -9,24 +10,25 @@ import (
 
 // EmailVerificationRepository handles email verification data operations
 type EmailVerificationRepository struct {
-	conn *pgx.Conn
+	db Database
 }
 
 // NewEmailVerificationRepository creates a new repository
-func NewEmailVerificationRepository(conn *pgx.Conn) *EmailVerificationRepository {
-	return &EmailVerificationRepository{conn: conn}
+func NewEmailVerificationRepository(db Database) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
 }
 
 // Create saves a new email verification record
 func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
 	query := `
-		INSERT INTO email_verifications (user_id, email, otp_code, expires_at, is_verified)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO email_verifications (user_id, email, purpose, otp_code, expires_at, is_verified)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
-	err := r.conn.QueryRow(ctx, query,
+	err := conn(ctx, r.db).QueryRow(ctx, query,
 		verification.UserID,
 		verification.Email,
+		verification.Purpose,
 		verification.OTPCode,
 		verification.ExpiresAt,
 		false,
@@ -35,23 +37,25 @@ func (r *EmailVerificationRepository) Create(ctx context.Context, verification *
 	return err
 }
 
-// GetByEmail retrieves the latest verification record by email
-func (r *EmailVerificationRepository) GetByEmail(ctx context.Context, email string) (*models.EmailVerification, error) {
+// GetByEmail retrieves the latest verification record of a purpose by email
+func (r *EmailVerificationRepository) GetByEmail(ctx context.Context, email, purpose string) (*models.EmailVerification, error) {
 	query := `
-		SELECT id, user_id, email, otp_code, expires_at, is_verified, created_at
+		SELECT id, user_id, email, purpose, otp_code, expires_at, is_verified, attempts, created_at
 		FROM email_verifications
-		WHERE email = $1
+		WHERE email = $1 AND purpose = $2
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 	verification := &models.EmailVerification{}
-	err := r.conn.QueryRow(ctx, query, email).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, email, purpose).Scan(
 		&verification.ID,
 		&verification.UserID,
 		&verification.Email,
+		&verification.Purpose,
 		&verification.OTPCode,
 		&verification.ExpiresAt,
 		&verification.IsVerified,
+		&verification.Attempts,
 		&verification.CreatedAt,
 	)
 
@@ -64,20 +68,59 @@ func (r *EmailVerificationRepository) GetByEmail(ctx context.Context, email stri
 // MarkAsVerified marks an email verification as verified
 func (r *EmailVerificationRepository) MarkAsVerified(ctx context.Context, id int) error {
 	query := `UPDATE email_verifications SET is_verified = true WHERE id = $1`
-	_, err := r.conn.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	return err
 }
 
+// CountSince counts the records of a purpose created for an email since a time
+func (r *EmailVerificationRepository) CountSince(ctx context.Context, email, purpose string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM email_verifications WHERE email = $1 AND purpose = $2 AND created_at >= $3`
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, email, purpose, since).Scan(&count)
+	return count, err
+}
+
+// UseAttempt counts a code entered for an unused verification record. It returns false
+// without counting once maxAttempts codes have been entered or the record was used; the
+// check and the count are one statement so concurrent guesses cannot exceed the limit.
+func (r *EmailVerificationRepository) UseAttempt(ctx context.Context, id, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE email_verifications SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND is_verified = false
+		RETURNING id
+	`
+	var usedID int
+	err := conn(ctx, r.db).QueryRow(ctx, query, id, maxAttempts).Scan(&usedID)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Redeem marks an unused verification record as used. It returns false when the record
+// was already used, so a code is redeemed once even by concurrent requests.
+func (r *EmailVerificationRepository) Redeem(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE email_verifications SET is_verified = true WHERE id = $1 AND is_verified = false`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 // UpdateUserVerification updates user's is_verified status
 func (r *EmailVerificationRepository) UpdateUserVerification(ctx context.Context, userID int) error {
 	query := `UPDATE users SET is_verified = true WHERE id = $1`
-	_, err := r.conn.Exec(ctx, query, userID)
+	_, err := conn(ctx, r.db).Exec(ctx, query, userID)
 	return err
 }
 
 // DeleteExpired deletes expired OTP records (cleanup)
 func (r *EmailVerificationRepository) DeleteExpired(ctx context.Context) error {
 	query := `DELETE FROM email_verifications WHERE expires_at < NOW() AND is_verified = false`
-	_, err := r.conn.Exec(ctx, query)
+	_, err := conn(ctx, r.db).Exec(ctx, query)
 	return err
 }
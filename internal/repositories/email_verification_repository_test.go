@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerificationRepository_Create_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	expiresAt := now.Add(15 * time.Minute)
+	mock.ExpectQuery("INSERT INTO email_verifications").
+		WithArgs(1, "john@example.com", models.OTPPurposePasswordReset, "123456", expiresAt, false).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+
+	// Execute
+	verification := &models.EmailVerification{
+		UserID:    1,
+		Email:     "john@example.com",
+		Purpose:   models.OTPPurposePasswordReset,
+		OTPCode:   "123456",
+		ExpiresAt: expiresAt,
+	}
+	err = repo.Create(context.Background(), verification)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, verification.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_GetByEmail_FiltersPurpose(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "user_id", "email", "purpose", "otp_code", "expires_at", "is_verified", "attempts", "created_at"}).
+		AddRow(3, 1, "john@example.com", models.OTPPurposePasswordReset, "123456", now.Add(15*time.Minute), false, 2, now)
+
+	mock.ExpectQuery("WHERE email = \\$1 AND purpose = \\$2").
+		WithArgs("john@example.com", models.OTPPurposePasswordReset).
+		WillReturnRows(rows)
+
+	// Execute
+	verification, err := repo.GetByEmail(context.Background(), "john@example.com", models.OTPPurposePasswordReset)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, verification.ID)
+	assert.Equal(t, 2, verification.Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_GetByEmail_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("FROM email_verifications").
+		WithArgs("nobody@example.com", models.OTPPurposeEmailVerification).
+		WillReturnError(pgx.ErrNoRows)
+
+	// Execute
+	verification, err := repo.GetByEmail(context.Background(), "nobody@example.com", models.OTPPurposeEmailVerification)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, verification)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_CountSince_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	since := time.Now().Add(-time.Hour)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM email_verifications").
+		WithArgs("john@example.com", models.OTPPurposePasswordReset, since).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+
+	// Execute
+	count, err := repo.CountSince(context.Background(), "john@example.com", models.OTPPurposePasswordReset, since)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_UseAttempt_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("UPDATE email_verifications SET attempts = attempts \\+ 1").
+		WithArgs(3, 5).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+
+	// Execute
+	allowed, err := repo.UseAttempt(context.Background(), 3, 5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_UseAttempt_LimitReached(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("WHERE id = \\$1 AND attempts < \\$2 AND is_verified = false").
+		WithArgs(3, 5).
+		WillReturnError(pgx.ErrNoRows)
+
+	// Execute
+	allowed, err := repo.UseAttempt(context.Background(), 3, 5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerificationRepository_Redeem_AlreadyUsed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewEmailVerificationRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE email_verifications SET is_verified = true WHERE id = \\$1 AND is_verified = false").
+		WithArgs(3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// Execute
+	redeemed, err := repo.Redeem(context.Background(), 3)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, redeemed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
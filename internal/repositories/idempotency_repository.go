@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// IdempotencyRepository handles idempotency key database operations
+type IdempotencyRepository struct {
+	db Database
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository
+func NewIdempotencyRepository(db Database) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey claims key for a new request locked at key.LockedAt. A key the user
+// already used is claimed again once it expired, or when the same request reserved it before
+// staleBefore and never completed, e.g. because the server crashed. Otherwise it returns the
+// existing record and false. The returned key's LockedAt, as stored, identifies this
+// reservation to CompleteIdempotencyKey and ReleaseIdempotencyKey.
+func (r *IdempotencyRepository) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, staleBefore time.Time) (*models.IdempotencyKey, bool, error) {
+	insertQuery := `INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash, locked_at, expires_at) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7) 
+	ON CONFLICT (user_id, idempotency_key) DO UPDATE SET 
+		method = EXCLUDED.method, path = EXCLUDED.path, request_hash = EXCLUDED.request_hash, 
+		status_code = NULL, response_body = NULL, completed_at = NULL, 
+		locked_at = EXCLUDED.locked_at, expires_at = EXCLUDED.expires_at, created_at = CURRENT_TIMESTAMP 
+	WHERE idempotency_keys.expires_at <= EXCLUDED.locked_at 
+		OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_at <= $8 
+			AND idempotency_keys.request_hash = EXCLUDED.request_hash) 
+	RETURNING id, locked_at, created_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, insertQuery, key.UserID, key.Key, key.Method, key.Path, key.RequestHash,
+		key.LockedAt, key.ExpiresAt, staleBefore).
+		Scan(&key.ID, &key.LockedAt, &key.CreatedAt)
+	if err == nil {
+		return key, true, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	existing := &models.IdempotencyKey{}
+	selectQuery := `SELECT id, user_id, idempotency_key, method, path, request_hash, status_code, response_body, 
+	locked_at, expires_at, created_at, completed_at 
+	FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+
+	err = conn(ctx, r.db).QueryRow(ctx, selectQuery, key.UserID, key.Key).
+		Scan(&existing.ID, &existing.UserID, &existing.Key, &existing.Method, &existing.Path, &existing.RequestHash,
+			&existing.StatusCode, &existing.ResponseBody, &existing.LockedAt, &existing.ExpiresAt,
+			&existing.CreatedAt, &existing.CompletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// The first request failed and released the key in between; let the caller retry
+			return nil, false, fmt.Errorf("idempotency key %q was released concurrently", key.Key)
+		}
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that reserved a key at lockedAt.
+// It returns models.ErrIdempotencyKeyTakenOver if a retry has reclaimed the key since.
+func (r *IdempotencyRepository) CompleteIdempotencyKey(ctx context.Context, id int, lockedAt time.Time, statusCode int, body []byte) error {
+	query := `UPDATE idempotency_keys SET status_code = $1, response_body = $2, locked_at = NULL, 
+	completed_at = CURRENT_TIMESTAMP WHERE id = $3 AND locked_at = $4`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, statusCode, body, id, lockedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrIdempotencyKeyTakenOver
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a key reserved at lockedAt whose request failed so it can be
+// retried. It returns models.ErrIdempotencyKeyTakenOver if a retry has reclaimed the key since.
+func (r *IdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, id int, lockedAt time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE id = $1 AND locked_at = $2`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, lockedAt)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrIdempotencyKeyTakenOver
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes every key that expired before now and returns how many were deleted
+func (r *IdempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
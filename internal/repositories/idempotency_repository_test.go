@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository_ReserveIdempotencyKey_New(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO idempotency_keys .* ON CONFLICT").
+		WithArgs(1, "key-1", "POST", "/api/pay", "hash", &now, now.Add(24*time.Hour), now.Add(-time.Minute)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "locked_at", "created_at"}).AddRow(5, &now, time.Now()))
+
+	key := &models.IdempotencyKey{UserID: 1, Key: "key-1", Method: "POST", Path: "/api/pay", RequestHash: "hash", LockedAt: &now, ExpiresAt: now.Add(24 * time.Hour)}
+	record, reserved, err := repo.ReserveIdempotencyKey(context.Background(), key, now.Add(-time.Minute))
+
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, 5, record.ID)
+	assert.Equal(t, now, *record.LockedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_ReserveIdempotencyKey_Existing(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	status := 201
+	mock.ExpectQuery("INSERT INTO idempotency_keys .* ON CONFLICT").
+		WithArgs(1, "key-1", "POST", "/api/pay", "hash", &now, now.Add(24*time.Hour), now.Add(-time.Minute)).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery("SELECT .* FROM idempotency_keys WHERE user_id = \\$1 AND idempotency_key = \\$2").
+		WithArgs(1, "key-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "user_id", "idempotency_key", "method", "path", "request_hash",
+			"status_code", "response_body", "locked_at", "expires_at", "created_at", "completed_at"}).
+			AddRow(5, 1, "key-1", "POST", "/api/pay", "hash", &status, []byte(`{"id":1}`), nil, now.Add(24*time.Hour), now, &now))
+
+	key := &models.IdempotencyKey{UserID: 1, Key: "key-1", Method: "POST", Path: "/api/pay", RequestHash: "hash", LockedAt: &now, ExpiresAt: now.Add(24 * time.Hour)}
+	record, reserved, err := repo.ReserveIdempotencyKey(context.Background(), key, now.Add(-time.Minute))
+
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, 201, *record.StatusCode)
+	assert.Equal(t, []byte(`{"id":1}`), record.ResponseBody)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_CompleteIdempotencyKey(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	lockedAt := time.Now()
+	mock.ExpectExec("UPDATE idempotency_keys SET status_code .* WHERE id = \\$3 AND locked_at = \\$4").
+		WithArgs(201, []byte(`{"id":1}`), 5, lockedAt).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = repo.CompleteIdempotencyKey(context.Background(), 5, lockedAt, 201, []byte(`{"id":1}`))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_CompleteIdempotencyKey_TakenOver(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	// A retry reclaimed the stale key, so it is locked at a later time now
+	lockedAt := time.Now().Add(-2 * time.Minute)
+	mock.ExpectExec("UPDATE idempotency_keys SET status_code").
+		WithArgs(201, []byte(`{"id":1}`), 5, lockedAt).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err = repo.CompleteIdempotencyKey(context.Background(), 5, lockedAt, 201, []byte(`{"id":1}`))
+
+	assert.ErrorIs(t, err, models.ErrIdempotencyKeyTakenOver)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_ReleaseIdempotencyKey(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	lockedAt := time.Now()
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE id = \\$1 AND locked_at = \\$2").
+		WithArgs(5, lockedAt).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	err = repo.ReleaseIdempotencyKey(context.Background(), 5, lockedAt)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_ReleaseIdempotencyKey_TakenOver(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	lockedAt := time.Now().Add(-2 * time.Minute)
+	mock.ExpectExec("DELETE FROM idempotency_keys").
+		WithArgs(5, lockedAt).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err = repo.ReleaseIdempotencyKey(context.Background(), 5, lockedAt)
+
+	assert.ErrorIs(t, err, models.ErrIdempotencyKeyTakenOver)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_DeleteExpiredIdempotencyKeys(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= \\$1").
+		WithArgs(now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	deleted, err := repo.DeleteExpiredIdempotencyKeys(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
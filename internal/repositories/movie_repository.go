@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// MovieRepository handles movie-related database operations
+type MovieRepository struct {
+	db Database
+}
+
+// NewMovieRepository creates a new MovieRepository
+func NewMovieRepository(db Database) *MovieRepository {
+	return &MovieRepository{db: db}
+}
+
+// GetAllMovies retrieves all movies with pagination
+func (r *MovieRepository) GetAllMovies(ctx context.Context, page, limit int, filters *models.MovieFilters) ([]*models.Movie, int, error) {
+	offset := (page - 1) * limit
+
+	// Build WHERE clause
+	conditions := []string{}
+	args := []interface{}{}
+	argIndex := 1
+
+	if filters.Title != "" {
+		conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", argIndex))
+		args = append(args, "%"+filters.Title+"%")
+		argIndex++
+	}
+
+	if filters.Genre != "" {
+		conditions = append(conditions, fmt.Sprintf("genre ILIKE $%d", argIndex))
+		args = append(args, "%"+filters.Genre+"%")
+		argIndex++
+	}
+
+	if filters.NowShowing {
+		conditions = append(conditions, "release_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)")
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Get total count
+	countQuery := "SELECT COUNT(*) FROM movies" + whereClause
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count movies: %w", err)
+	}
+
+	// Get paginated data
+	query := fmt.Sprintf("SELECT id, title, synopsis, duration_minutes, genre, age_rating, poster_url, release_date, end_date, created_at, updated_at "+
+		"FROM movies%s ORDER BY release_date DESC, title ASC LIMIT $%d OFFSET $%d", whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get movies: %w", err)
+	}
+	defer rows.Close()
+
+	movies := []*models.Movie{}
+	for rows.Next() {
+		movie := &models.Movie{}
+		err := rows.Scan(&movie.ID, &movie.Title, &movie.Synopsis, &movie.Duration, &movie.Genre, &movie.AgeRating,
+			&movie.PosterURL, &movie.ReleaseDate, &movie.EndDate, &movie.CreatedAt, &movie.UpdatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan movie: %w", err)
+		}
+		movies = append(movies, movie)
+	}
+
+	return movies, total, nil
+}
+
+// GetMovieByID retrieves a movie by ID
+func (r *MovieRepository) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	movie := &models.Movie{}
+	query := `SELECT id, title, synopsis, duration_minutes, genre, age_rating, poster_url, release_date, end_date, created_at, updated_at 
+	FROM movies WHERE id = $1`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&movie.ID, &movie.Title, &movie.Synopsis, &movie.Duration, &movie.Genre, &movie.AgeRating,
+			&movie.PosterURL, &movie.ReleaseDate, &movie.EndDate, &movie.CreatedAt, &movie.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+
+	return movie, nil
+}
+
+// CreateMovie creates a new movie (for admin/seeding)
+func (r *MovieRepository) CreateMovie(ctx context.Context, movie *models.Movie) error {
+	query := `INSERT INTO movies (title, synopsis, duration_minutes, genre, age_rating, poster_url, release_date, end_date) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, updated_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, movie.Title, movie.Synopsis, movie.Duration, movie.Genre, movie.AgeRating,
+		movie.PosterURL, movie.ReleaseDate, movie.EndDate).
+		Scan(&movie.ID, &movie.CreatedAt, &movie.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create movie: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var movieColumns = []string{"id", "title", "synopsis", "duration_minutes", "genre", "age_rating", "poster_url", "release_date", "end_date", "created_at", "updated_at"}
+
+func TestMovieRepository_GetAllMovies_WithFilters(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMovieRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM movies WHERE genre ILIKE \$1 AND release_date <= CURRENT_DATE`).
+		WithArgs("%Horror%").
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+
+	now := time.Now()
+	var endDate *time.Time
+	rows := pgxmock.NewRows(movieColumns).
+		AddRow(1, "Pengabdi Setan 2", "Sekuel horor", 119, "Horror", "17+", "poster.jpg", now, endDate, now, now)
+
+	mock.ExpectQuery("SELECT id, title, synopsis").
+		WithArgs("%Horror%", 10, 0).
+		WillReturnRows(rows)
+
+	filters := &models.MovieFilters{Genre: "Horror", NowShowing: true}
+	movies, total, err := repo.GetAllMovies(context.Background(), 1, 10, filters)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, movies, 1)
+	assert.Equal(t, 119, movies[0].Duration)
+	assert.Nil(t, movies[0].EndDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMovieRepository_GetMovieByID_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMovieRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT id, title").WithArgs(42).WillReturnError(pgx.ErrNoRows)
+
+	movie, err := repo.GetMovieByID(context.Background(), 42)
+
+	assert.NoError(t, err)
+	assert.Nil(t, movie)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMovieRepository_CreateMovie_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMovieRepository(&mockDB{pool: mock})
+
+	movie := &models.Movie{
+		Title:       "Agak Laen",
+		Duration:    119,
+		Genre:       "Comedy",
+		AgeRating:   "13+",
+		ReleaseDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO movies").
+		WithArgs(movie.Title, movie.Synopsis, movie.Duration, movie.Genre, movie.AgeRating, movie.PosterURL, movie.ReleaseDate, movie.EndDate).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, now, now))
+
+	err = repo.CreateMovie(context.Background(), movie)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, movie.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -20,14 +20,17 @@ func NewPaymentRepository(db Database) *PaymentRepository {
 
 // CreatePayment creates a new payment
 func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
-	query := `INSERT INTO payments (booking_id, user_id, amount, payment_method, status, transaction_id) 
-	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO payments (reference, booking_id, user_id, amount, payment_method, status, transaction_id) 
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')) RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, payment.BookingID, payment.UserID, payment.Amount, payment.PaymentMethod,
-		payment.Status, payment.TransactionID).
+	err := conn(ctx, r.db).QueryRow(ctx, query, payment.Reference, payment.BookingID, payment.UserID, payment.Amount,
+		payment.PaymentMethod, payment.Status, payment.TransactionID).
 		Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return models.ErrPaymentAlreadyExists
+		}
 		return fmt.Errorf("failed to create payment: %w", err)
 	}
 	return nil
@@ -36,11 +39,11 @@ func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *models.P
 // GetPaymentByID retrieves a payment by ID
 func (r *PaymentRepository) GetPaymentByID(ctx context.Context, id int) (*models.Payment, error) {
 	payment := &models.Payment{}
-	query := `SELECT id, booking_id, user_id, amount, payment_method, status, transaction_id, created_at, updated_at 
+	query := `SELECT id, reference, booking_id, user_id, amount, payment_method, status, COALESCE(transaction_id, ''), created_at, updated_at 
 	FROM payments WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&payment.ID, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&payment.ID, &payment.Reference, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
 			&payment.Status, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt)
 
 	if err != nil {
@@ -53,14 +56,15 @@ func (r *PaymentRepository) GetPaymentByID(ctx context.Context, id int) (*models
 	return payment, nil
 }
 
-// GetPaymentByBookingID retrieves a payment by booking ID
+// GetPaymentByBookingID retrieves the payment of a booking. A booking has at most one
+// payment that did not fail; the latest failed attempt is returned when there is none.
 func (r *PaymentRepository) GetPaymentByBookingID(ctx context.Context, bookingID int) (*models.Payment, error) {
 	payment := &models.Payment{}
-	query := `SELECT id, booking_id, user_id, amount, payment_method, status, transaction_id, created_at, updated_at 
-	FROM payments WHERE booking_id = $1`
+	query := `SELECT id, reference, booking_id, user_id, amount, payment_method, status, COALESCE(transaction_id, ''), created_at, updated_at 
+	FROM payments WHERE booking_id = $1 ORDER BY status = 'failed', id DESC LIMIT 1`
 
-	err := r.db.QueryRow(ctx, query, bookingID).
-		Scan(&payment.ID, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
+	err := conn(ctx, r.db).QueryRow(ctx, query, bookingID).
+		Scan(&payment.ID, &payment.Reference, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
 			&payment.Status, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt)
 
 	if err != nil {
@@ -73,21 +77,51 @@ func (r *PaymentRepository) GetPaymentByBookingID(ctx context.Context, bookingID
 	return payment, nil
 }
 
+// GetPaymentByReference retrieves a payment by its reference
+func (r *PaymentRepository) GetPaymentByReference(ctx context.Context, reference string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `SELECT id, reference, booking_id, user_id, amount, payment_method, status, COALESCE(transaction_id, ''), created_at, updated_at 
+	FROM payments WHERE reference = $1`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, reference).
+		Scan(&payment.ID, &payment.Reference, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
+			&payment.Status, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get payment by reference: %w", err)
+	}
+
+	return payment, nil
+}
+
 // UpdatePaymentStatus updates the status of a payment
 func (r *PaymentRepository) UpdatePaymentStatus(ctx context.Context, id int, status string) error {
 	query := `UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, status, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, status, id)
 	if err != nil {
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 	return nil
 }
 
+// UpdatePaymentResult stores the outcome of a gateway charge on a payment
+func (r *PaymentRepository) UpdatePaymentResult(ctx context.Context, id int, status, transactionID string) error {
+	query := `UPDATE payments SET status = $1, transaction_id = NULLIF($2, ''), updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	_, err := conn(ctx, r.db).Exec(ctx, query, status, transactionID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update payment result: %w", err)
+	}
+	return nil
+}
+
 // GetPaymentMethods retrieves all active payment methods
 func (r *PaymentRepository) GetPaymentMethods(ctx context.Context) ([]*models.PaymentMethod, error) {
 	query := `SELECT id, name, type, is_active, created_at, updated_at FROM payment_methods WHERE is_active = TRUE ORDER BY name`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment methods: %w", err)
 	}
@@ -111,7 +145,7 @@ func (r *PaymentRepository) GetPaymentMethodByName(ctx context.Context, name str
 	method := &models.PaymentMethod{}
 	query := `SELECT id, name, type, is_active, created_at, updated_at FROM payment_methods WHERE name = $1 AND is_active = TRUE`
 
-	err := r.db.QueryRow(ctx, query, name).
+	err := conn(ctx, r.db).QueryRow(ctx, query, name).
 		Scan(&method.ID, &method.Name, &method.Type, &method.IsActive, &method.CreatedAt, &method.UpdatedAt)
 
 	if err != nil {
@@ -126,10 +160,10 @@ func (r *PaymentRepository) GetPaymentMethodByName(ctx context.Context, name str
 
 // GetUserPayments retrieves all payments for a user
 func (r *PaymentRepository) GetUserPayments(ctx context.Context, userID int) ([]*models.Payment, error) {
-	query := `SELECT id, booking_id, user_id, amount, payment_method, status, transaction_id, created_at, updated_at 
+	query := `SELECT id, reference, booking_id, user_id, amount, payment_method, status, COALESCE(transaction_id, ''), created_at, updated_at 
 	FROM payments WHERE user_id = $1 ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user payments: %w", err)
 	}
@@ -138,7 +172,7 @@ func (r *PaymentRepository) GetUserPayments(ctx context.Context, userID int) ([]
 	payments := []*models.Payment{}
 	for rows.Next() {
 		payment := &models.Payment{}
-		err := rows.Scan(&payment.ID, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
+		err := rows.Scan(&payment.ID, &payment.Reference, &payment.BookingID, &payment.UserID, &payment.Amount, &payment.PaymentMethod,
 			&payment.Status, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan payment: %w", err)
@@ -148,3 +182,145 @@ func (r *PaymentRepository) GetUserPayments(ctx context.Context, userID int) ([]
 
 	return payments, nil
 }
+
+// GetRefundedAmount returns the total amount already refunded on a payment
+func (r *PaymentRepository) GetRefundedAmount(ctx context.Context, paymentID int) (models.Money, error) {
+	var refunded models.Money
+	query := `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, paymentID).Scan(&refunded)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	return refunded, nil
+}
+
+// CreateRefund records a refund against a payment in a single transaction. The payment row is
+// locked while the remaining refundable amount is checked, so concurrent refunds cannot exceed
+// the paid amount. The payment status and the booking payment status move to
+// partially_refunded, or refunded once nothing is left to refund.
+func (r *PaymentRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var amount models.Money
+	lockQuery := `SELECT booking_id, amount FROM payments 
+	WHERE id = $1 AND status IN ('success', 'partially_refunded') FOR UPDATE`
+
+	err = tx.QueryRow(ctx, lockQuery, refund.PaymentID).Scan(&refund.BookingID, &amount)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrPaymentNotRefundable
+		}
+		return fmt.Errorf("failed to lock payment: %w", err)
+	}
+
+	var refunded models.Money
+	err = tx.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1`, refund.PaymentID).
+		Scan(&refunded)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+
+	remaining := amount.Sub(refunded)
+	if refund.Amount.Cmp(remaining) > 0 {
+		return models.ErrRefundExceedsPayment
+	}
+
+	insertQuery := `INSERT INTO refunds (reference, payment_id, booking_id, amount, reason) 
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, insertQuery, refund.Reference, refund.PaymentID, refund.BookingID, refund.Amount, refund.Reason).
+		Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	refund.PaymentStatus = "partially_refunded"
+	if !remaining.Sub(refund.Amount).IsPositive() {
+		refund.PaymentStatus = "refunded"
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		refund.PaymentStatus, refund.PaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE bookings SET payment_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		refund.PaymentStatus, refund.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to update booking payment status: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit refund: %w", err)
+	}
+	return nil
+}
+
+// ApplyPaymentWebhook records a provider event and moves the payment it refers to from pending
+// to event.PaymentStatus in a single transaction. The booking follows: a successful payment
+// marks it paid and confirmed, a failed one puts it back to awaiting payment so it can be paid
+// again or expire with its seat hold. Only a pending booking is confirmed; a booking that is
+// no longer pending keeps its status and its captured payment stays refundable. It returns
+// false without changing anything if the provider already delivered this event; events for
+// payments that are no longer pending are recorded but do not change them.
+func (r *PaymentRepository) ApplyPaymentWebhook(ctx context.Context, event *models.PaymentWebhookEvent) (bool, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var paymentID, bookingID int
+	var status string
+	lockQuery := `SELECT id, booking_id, status FROM payments WHERE transaction_id = $1 FOR UPDATE`
+
+	err = tx.QueryRow(ctx, lockQuery, event.TransactionID).Scan(&paymentID, &bookingID, &status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, models.ErrPaymentNotFound
+		}
+		return false, fmt.Errorf("failed to lock payment: %w", err)
+	}
+	event.PaymentID = paymentID
+
+	insertQuery := `INSERT INTO payment_webhook_events (provider, event_id, transaction_id, status, payment_id) 
+	VALUES ($1, $2, $3, $4, $5) ON CONFLICT (provider, event_id) DO NOTHING RETURNING id, received_at`
+
+	err = tx.QueryRow(ctx, insertQuery, event.Provider, event.EventID, event.TransactionID, event.Status, paymentID).
+		Scan(&event.ID, &event.ReceivedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+
+	if status == "pending" {
+		_, err = tx.Exec(ctx, `UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+			event.PaymentStatus, paymentID)
+		if err != nil {
+			return false, fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		bookingQuery := `UPDATE bookings SET payment_status = 'pending', updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+		if event.PaymentStatus == "success" {
+			bookingQuery = `UPDATE bookings SET payment_status = 'paid', status = 'confirmed', updated_at = CURRENT_TIMESTAMP 
+			WHERE id = $1 AND status = 'pending'`
+		}
+		_, err = tx.Exec(ctx, bookingQuery, bookingID)
+		if err != nil {
+			return false, fmt.Errorf("failed to update booking payment status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit webhook event: %w", err)
+	}
+	return true, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,14 +24,15 @@ func TestPaymentRepository_CreatePayment_Success(t *testing.T) {
 		AddRow(1, now, now)
 
 	mock.ExpectQuery("INSERT INTO payments").
-		WithArgs(1, 1, 150000.0, "credit_card", "pending", "TXN123456").
+		WithArgs("PAY-TEST", 1, 1, models.MustParseMoney("150000"), "credit_card", "pending", "TXN123456").
 		WillReturnRows(rows)
 
 	// Execute
 	payment := &models.Payment{
+		Reference:     "PAY-TEST",
 		BookingID:     1,
 		UserID:        1,
-		Amount:        150000.0,
+		Amount:        models.MustParseMoney("150000"),
 		PaymentMethod: "credit_card",
 		Status:        "pending",
 		TransactionID: "TXN123456",
@@ -43,6 +45,24 @@ func TestPaymentRepository_CreatePayment_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestPaymentRepository_CreatePayment_BookingAlreadyHasPayment(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("INSERT INTO payments").
+		WithArgs("PAY-TEST", 1, 1, models.MustParseMoney("150000"), "credit_card", "pending", "").
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_payments_booking_attempt"})
+
+	payment := &models.Payment{Reference: "PAY-TEST", BookingID: 1, UserID: 1, Amount: models.MustParseMoney("150000"), PaymentMethod: "credit_card", Status: "pending"}
+	err = repo.CreatePayment(context.Background(), payment)
+
+	assert.ErrorIs(t, err, models.ErrPaymentAlreadyExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPaymentRepository_GetPaymentByID_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
@@ -51,10 +71,10 @@ func TestPaymentRepository_GetPaymentByID_Success(t *testing.T) {
 	repo := NewPaymentRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
-		AddRow(1, 1, 1, 150000.0, "credit_card", "completed", "TXN123456", now, now)
+	rows := pgxmock.NewRows([]string{"id", "reference", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
+		AddRow(1, "PAY-TEST1", 1, 1, 150000.0, "credit_card", "completed", "TXN123456", now, now)
 
-	mock.ExpectQuery("SELECT id, booking_id, user_id").
+	mock.ExpectQuery("SELECT id, reference, booking_id, user_id").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -70,6 +90,28 @@ func TestPaymentRepository_GetPaymentByID_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestPaymentRepository_GetPaymentByID_ScansDecimalExactly(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "reference", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
+		AddRow(1, "PAY-TEST1", 1, 1, "70000.10", "credit_card", "success", "TXN123456", now, now)
+
+	mock.ExpectQuery("SELECT id, reference, booking_id, user_id").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	payment, err := repo.GetPaymentByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7000010), payment.Amount.Minor())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPaymentRepository_GetPaymentByID_NotFound(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
@@ -77,7 +119,7 @@ func TestPaymentRepository_GetPaymentByID_NotFound(t *testing.T) {
 
 	repo := NewPaymentRepository(&mockDB{pool: mock})
 
-	mock.ExpectQuery("SELECT id, booking_id, user_id").
+	mock.ExpectQuery("SELECT id, reference, booking_id, user_id").
 		WithArgs(999).
 		WillReturnError(pgx.ErrNoRows)
 
@@ -98,10 +140,10 @@ func TestPaymentRepository_GetPaymentByBookingID_Success(t *testing.T) {
 	repo := NewPaymentRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
-		AddRow(1, 10, 1, 150000.0, "credit_card", "completed", "TXN123456", now, now)
+	rows := pgxmock.NewRows([]string{"id", "reference", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
+		AddRow(1, "PAY-TEST1", 10, 1, 150000.0, "credit_card", "completed", "TXN123456", now, now)
 
-	mock.ExpectQuery("SELECT id, booking_id, user_id").
+	mock.ExpectQuery("SELECT id, reference, booking_id, user_id").
 		WithArgs(10).
 		WillReturnRows(rows)
 
@@ -170,11 +212,11 @@ func TestPaymentRepository_GetUserPayments_Success(t *testing.T) {
 	repo := NewPaymentRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
-		AddRow(1, 1, 5, 150000.0, "credit_card", "completed", "TXN123456", now, now).
-		AddRow(2, 2, 5, 200000.0, "bank_transfer", "pending", "TXN123457", now, now)
+	rows := pgxmock.NewRows([]string{"id", "reference", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
+		AddRow(1, "PAY-TEST1", 1, 5, 150000.0, "credit_card", "completed", "TXN123456", now, now).
+		AddRow(2, "PAY-TEST2", 2, 5, 200000.0, "bank_transfer", "pending", "TXN123457", now, now)
 
-	mock.ExpectQuery("SELECT id, booking_id, user_id").
+	mock.ExpectQuery("SELECT id, reference, booking_id, user_id").
 		WithArgs(5).
 		WillReturnRows(rows)
 
@@ -188,3 +230,309 @@ func TestPaymentRepository_GetUserPayments_Success(t *testing.T) {
 	assert.Equal(t, "pending", payments[1].Status)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestPaymentRepository_CreateRefund_Partial(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT booking_id, amount FROM payments .* FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"booking_id", "amount"}).AddRow(7, 150000.0))
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(amount\\), 0\\) FROM refunds").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(50000.0))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs("RFD-TEST1", 3, 7, models.MustParseMoney("50000"), "customer request").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectExec("UPDATE payments SET status").
+		WithArgs("partially_refunded", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bookings SET payment_status").
+		WithArgs("partially_refunded", 7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	refund := &models.Refund{Reference: "RFD-TEST1", PaymentID: 3, Amount: models.MustParseMoney("50000"), Reason: "customer request"}
+	err = repo.CreateRefund(context.Background(), refund)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, refund.ID)
+	assert.Equal(t, 7, refund.BookingID)
+	assert.Equal(t, "partially_refunded", refund.PaymentStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_CreateRefund_RemainingAmountMarksRefunded(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT booking_id, amount FROM payments .* FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"booking_id", "amount"}).AddRow(7, 150000.0))
+	mock.ExpectQuery("FROM refunds").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(50000.0))
+	mock.ExpectQuery("INSERT INTO refunds").
+		WithArgs("RFD-TEST2", 3, 7, models.MustParseMoney("100000"), "show cancelled").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(2, now))
+	mock.ExpectExec("UPDATE payments SET status").
+		WithArgs("refunded", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bookings SET payment_status").
+		WithArgs("refunded", 7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	refund := &models.Refund{Reference: "RFD-TEST2", PaymentID: 3, Amount: models.MustParseMoney("100000"), Reason: "show cancelled"}
+	err = repo.CreateRefund(context.Background(), refund)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", refund.PaymentStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_CreateRefund_ExceedsRemaining(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT booking_id, amount FROM payments .* FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"booking_id", "amount"}).AddRow(7, 150000.0))
+	mock.ExpectQuery("FROM refunds").
+		WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(100000.0))
+	mock.ExpectRollback()
+
+	err = repo.CreateRefund(context.Background(), &models.Refund{PaymentID: 3, Amount: models.MustParseMoney("60000"), Reason: "too much"})
+
+	assert.ErrorIs(t, err, models.ErrRefundExceedsPayment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_CreateRefund_NotRefundable(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT booking_id, amount FROM payments .* FOR UPDATE").
+		WithArgs(3).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.CreateRefund(context.Background(), &models.Refund{PaymentID: 3, Amount: models.MustParseMoney("1000"), Reason: "refund"})
+
+	assert.ErrorIs(t, err, models.ErrPaymentNotRefundable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_UpdatePaymentResult_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE payments SET status = \\$1, transaction_id = NULLIF\\(\\$2, ''\\)").
+		WithArgs("failed", "SIM-B7-1", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = repo.UpdatePaymentResult(context.Background(), 3, "failed", "SIM-B7-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_ApplyPaymentWebhook_ConfirmsBooking(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, booking_id, status FROM payments .* FOR UPDATE").
+		WithArgs("SIM-B7-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_id", "status"}).AddRow(3, 7, "pending"))
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("simulator", "evt_1", "SIM-B7-1", "captured", 3).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "received_at"}).AddRow(1, now))
+	mock.ExpectExec("UPDATE payments SET status").
+		WithArgs("success", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bookings SET payment_status = 'paid', status = 'confirmed'").
+		WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	event := &models.PaymentWebhookEvent{Provider: "simulator", EventID: "evt_1", TransactionID: "SIM-B7-1", Status: "captured", PaymentStatus: "success"}
+	applied, err := repo.ApplyPaymentWebhook(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, 3, event.PaymentID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_ApplyPaymentWebhook_DoesNotConfirmCancelledBooking(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, booking_id, status FROM payments .* FOR UPDATE").
+		WithArgs("SIM-B7-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_id", "status"}).AddRow(3, 7, "pending"))
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("simulator", "evt_1", "SIM-B7-1", "captured", 3).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "received_at"}).AddRow(1, now))
+	mock.ExpectExec("UPDATE payments SET status").
+		WithArgs("success", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("WHERE id = \\$1 AND status = 'pending'").
+		WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectCommit()
+
+	event := &models.PaymentWebhookEvent{Provider: "simulator", EventID: "evt_1", TransactionID: "SIM-B7-1", Status: "captured", PaymentStatus: "success"}
+	applied, err := repo.ApplyPaymentWebhook(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_ApplyPaymentWebhook_DuplicateEvent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, booking_id, status FROM payments .* FOR UPDATE").
+		WithArgs("SIM-B7-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_id", "status"}).AddRow(3, 7, "success"))
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("simulator", "evt_1", "SIM-B7-1", "captured", 3).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	event := &models.PaymentWebhookEvent{Provider: "simulator", EventID: "evt_1", TransactionID: "SIM-B7-1", Status: "captured", PaymentStatus: "success"}
+	applied, err := repo.ApplyPaymentWebhook(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_ApplyPaymentWebhook_UnknownTransaction(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, booking_id, status FROM payments .* FOR UPDATE").
+		WithArgs("SIM-B9-1").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	event := &models.PaymentWebhookEvent{Provider: "simulator", EventID: "evt_2", TransactionID: "SIM-B9-1", Status: "captured", PaymentStatus: "success"}
+	_, err = repo.ApplyPaymentWebhook(context.Background(), event)
+
+	assert.ErrorIs(t, err, models.ErrPaymentNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_ApplyPaymentWebhook_FailedReopensBooking(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, booking_id, status FROM payments .* FOR UPDATE").
+		WithArgs("SIM-B7-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "booking_id", "status"}).AddRow(3, 7, "pending"))
+	mock.ExpectQuery("INSERT INTO payment_webhook_events").
+		WithArgs("simulator", "evt_3", "SIM-B7-1", "declined", 3).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "received_at"}).AddRow(2, time.Now()))
+	mock.ExpectExec("UPDATE payments SET status").
+		WithArgs("failed", 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bookings SET payment_status = 'pending'").
+		WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	event := &models.PaymentWebhookEvent{Provider: "simulator", EventID: "evt_3", TransactionID: "SIM-B7-1", Status: "declined", PaymentStatus: "failed"}
+	applied, err := repo.ApplyPaymentWebhook(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_GetPaymentByReference_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "reference", "booking_id", "user_id", "amount", "payment_method", "status", "transaction_id", "created_at", "updated_at"}).
+		AddRow(1, "PAY-TEST1", 10, 1, 150000.0, "credit_card", "success", "SIM-PAY-TEST1-1", now, now)
+
+	mock.ExpectQuery("SELECT id, reference, .* FROM payments WHERE reference = \\$1").
+		WithArgs("PAY-TEST1").
+		WillReturnRows(rows)
+
+	payment, err := repo.GetPaymentByReference(context.Background(), "PAY-TEST1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, payment.ID)
+	assert.Equal(t, "PAY-TEST1", payment.Reference)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRepository_GetPaymentByReference_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("FROM payments WHERE reference = \\$1").
+		WithArgs("PAY-NONE").
+		WillReturnError(pgx.ErrNoRows)
+
+	payment, err := repo.GetPaymentByReference(context.Background(), "PAY-NONE")
+
+	assert.NoError(t, err)
+	assert.Nil(t, payment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/metrics"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+var dbQueryDuration = metrics.NewHistogram("db_query_duration_seconds",
+	"Time taken by database queries, by statement (select, insert, update, delete, ...) and status: ok or error.",
+	metrics.DefBuckets, "statement", "status")
+
+// queryStartKey is the context key of the query a QueryTracer is timing
+type queryStartKey struct{}
+
+type queryStart struct {
+	at        time.Time
+	statement string
+	span      *tracing.Span
+}
+
+// QueryTracer records the duration of every query run on a connection, in a
+// transaction or not, and traces it as a span of the operation that ran it;
+// set it as the Tracer of the pool's connection config
+type QueryTracer struct{}
+
+// TraceQueryStart notes when a query starts
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	statement := statementKind(data.SQL)
+	ctx, span := tracing.Start(ctx, "db."+statement)
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.statement", strings.Join(strings.Fields(data.SQL), " "))
+	return context.WithValue(ctx, queryStartKey{}, queryStart{at: time.Now(), statement: statement, span: span})
+}
+
+// TraceQueryEnd records the duration of the query
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	status := "ok"
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		status = "error"
+		start.span.RecordError(data.Err)
+	}
+	start.span.End()
+	dbQueryDuration.Observe(time.Since(start.at).Seconds(), start.statement, status)
+}
+
+// statementKinds are the statements queries are told apart by; the rest count as other
+var statementKinds = map[string]bool{
+	"select": true, "insert": true, "update": true, "delete": true, "with": true,
+	"begin": true, "commit": true, "rollback": true, "savepoint": true, "release": true,
+}
+
+// statementKind returns the lowercased first keyword of a query, e.g. select
+func statementKind(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 || !statementKinds[strings.ToLower(fields[0])] {
+		return "other"
+	}
+	return strings.ToLower(fields[0])
+}
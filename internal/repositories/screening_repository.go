@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ScreeningRepository handles screening-related database operations
+type ScreeningRepository struct {
+	db Database
+}
+
+// NewScreeningRepository creates a new ScreeningRepository
+func NewScreeningRepository(db Database) *ScreeningRepository {
+	return &ScreeningRepository{db: db}
+}
+
+// CreateScreening creates a new screening
+func (r *ScreeningRepository) CreateScreening(ctx context.Context, screening *models.Screening) error {
+	query := `INSERT INTO screenings (auditorium_id, movie_id, start_time, end_time, base_price) 
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, screening.AuditoriumID, screening.MovieID, screening.StartTime, screening.EndTime, screening.BasePrice).
+		Scan(&screening.ID, &screening.CreatedAt, &screening.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create screening: %w", err)
+	}
+	return nil
+}
+
+// GetScreeningByID retrieves a screening with its movie by ID. Screenings of archived cinemas are not returned.
+func (r *ScreeningRepository) GetScreeningByID(ctx context.Context, id int) (*models.Screening, error) {
+	query := `SELECT sc.id, a.cinema_id, sc.auditorium_id, sc.movie_id, sc.start_time, sc.end_time, sc.base_price, sc.created_at, sc.updated_at,
+	m.id, m.title, m.synopsis, m.duration_minutes, m.genre, m.age_rating, m.poster_url, m.release_date, m.end_date, m.created_at, m.updated_at
+	FROM screenings sc
+	JOIN auditoriums a ON sc.auditorium_id = a.id
+	JOIN cinemas c ON a.cinema_id = c.id
+	JOIN movies m ON sc.movie_id = m.id
+	WHERE sc.id = $1 AND c.archived_at IS NULL`
+
+	screening, err := scanScreening(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get screening: %w", err)
+	}
+
+	return screening, nil
+}
+
+// GetScreeningsByCinema retrieves all screenings at a cinema that start on the given day; an archived cinema has none
+func (r *ScreeningRepository) GetScreeningsByCinema(ctx context.Context, cinemaID int, date time.Time) ([]*models.Screening, error) {
+	query := `SELECT sc.id, a.cinema_id, sc.auditorium_id, sc.movie_id, sc.start_time, sc.end_time, sc.base_price, sc.created_at, sc.updated_at,
+	m.id, m.title, m.synopsis, m.duration_minutes, m.genre, m.age_rating, m.poster_url, m.release_date, m.end_date, m.created_at, m.updated_at
+	FROM screenings sc
+	JOIN auditoriums a ON sc.auditorium_id = a.id
+	JOIN cinemas c ON a.cinema_id = c.id
+	JOIN movies m ON sc.movie_id = m.id
+	WHERE a.cinema_id = $1 AND c.archived_at IS NULL AND sc.start_time >= $2 AND sc.start_time < $3
+	ORDER BY sc.start_time, a.name`
+
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	rows, err := conn(ctx, r.db).Query(ctx, query, cinemaID, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screenings: %w", err)
+	}
+	defer rows.Close()
+
+	screenings := []*models.Screening{}
+	for rows.Next() {
+		screening, err := scanScreening(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan screening: %w", err)
+		}
+		screenings = append(screenings, screening)
+	}
+
+	return screenings, nil
+}
+
+// GetScreeningsByAuditorium retrieves the screenings of an auditorium that run at some point between from and to
+func (r *ScreeningRepository) GetScreeningsByAuditorium(ctx context.Context, auditoriumID int, from, to time.Time) ([]*models.Screening, error) {
+	query := `SELECT sc.id, a.cinema_id, sc.auditorium_id, sc.movie_id, sc.start_time, sc.end_time, sc.base_price, sc.created_at, sc.updated_at,
+	m.id, m.title, m.synopsis, m.duration_minutes, m.genre, m.age_rating, m.poster_url, m.release_date, m.end_date, m.created_at, m.updated_at
+	FROM screenings sc
+	JOIN auditoriums a ON sc.auditorium_id = a.id
+	JOIN movies m ON sc.movie_id = m.id
+	WHERE sc.auditorium_id = $1 AND sc.start_time < $3 AND sc.end_time > $2
+	ORDER BY sc.start_time`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, auditoriumID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screenings: %w", err)
+	}
+	defer rows.Close()
+
+	screenings := []*models.Screening{}
+	for rows.Next() {
+		screening, err := scanScreening(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan screening: %w", err)
+		}
+		screenings = append(screenings, screening)
+	}
+
+	return screenings, nil
+}
+
+// CreateScreenings creates screenings of an auditorium, each with availability for every
+// seat of the auditorium, in a single transaction. The auditorium is locked while the
+// screenings are checked against the ones it already has: if any of them starts or ends
+// within cleaningTime of an existing screening, nothing is written and
+// models.ErrScheduleConflict is returned. The cinema of the auditorium is locked against
+// archiving; models.ErrCinemaArchived is returned once it has been archived.
+func (r *ScreeningRepository) CreateScreenings(ctx context.Context, auditoriumID int, screenings []*models.Screening, cleaningTime time.Duration) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the auditorium so concurrent schedules are serialized, and its cinema so it
+	// cannot be archived before the screenings are written
+	var archived bool
+	err = tx.QueryRow(ctx, `SELECT c.archived_at IS NOT NULL FROM auditoriums a
+	JOIN cinemas c ON a.cinema_id = c.id
+	WHERE a.id = $1 FOR UPDATE OF a FOR SHARE OF c`, auditoriumID).Scan(&archived)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrAuditoriumNotFound
+		}
+		return fmt.Errorf("failed to lock auditorium: %w", err)
+	}
+	if archived {
+		return models.ErrCinemaArchived
+	}
+
+	overlapQuery := `SELECT EXISTS (
+		SELECT 1 FROM screenings WHERE auditorium_id = $1 AND start_time < $3 AND end_time > $2
+	)`
+
+	screeningQuery := `INSERT INTO screenings (auditorium_id, movie_id, start_time, end_time, base_price) 
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
+
+	availabilityQuery := `INSERT INTO seat_availability (screening_id, seat_id, is_available) 
+	SELECT $1, id, TRUE FROM seats WHERE auditorium_id = $2 AND is_active`
+
+	for _, screening := range screenings {
+		var overlaps bool
+		err := tx.QueryRow(ctx, overlapQuery, auditoriumID, screening.StartTime.Add(-cleaningTime), screening.EndTime.Add(cleaningTime)).
+			Scan(&overlaps)
+		if err != nil {
+			return fmt.Errorf("failed to check overlapping screenings: %w", err)
+		}
+		if overlaps {
+			return models.ErrScheduleConflict
+		}
+
+		err = tx.QueryRow(ctx, screeningQuery, auditoriumID, screening.MovieID, screening.StartTime, screening.EndTime, screening.BasePrice).
+			Scan(&screening.ID, &screening.CreatedAt, &screening.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create screening: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, availabilityQuery, screening.ID, auditoriumID)
+		if err != nil {
+			return fmt.Errorf("failed to create seat availability: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// scanScreening scans a screening row joined with its auditorium's cinema and its movie
+func scanScreening(row pgx.Row) (*models.Screening, error) {
+	screening := &models.Screening{}
+	movie := &models.Movie{}
+
+	err := row.Scan(&screening.ID, &screening.CinemaID, &screening.AuditoriumID, &screening.MovieID, &screening.StartTime, &screening.EndTime,
+		&screening.BasePrice, &screening.CreatedAt, &screening.UpdatedAt,
+		&movie.ID, &movie.Title, &movie.Synopsis, &movie.Duration, &movie.Genre, &movie.AgeRating, &movie.PosterURL,
+		&movie.ReleaseDate, &movie.EndDate, &movie.CreatedAt, &movie.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	screening.Movie = movie
+	return screening, nil
+}
@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var screeningColumns = append([]string{"id", "cinema_id", "auditorium_id", "movie_id", "start_time", "end_time", "base_price", "created_at", "updated_at"}, movieColumns...)
+
+func TestScreeningRepository_CreateScreening_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	start := time.Date(2026, 1, 20, 19, 0, 0, 0, time.Local)
+	screening := &models.Screening{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartTime:    start,
+		EndTime:      start.Add(119 * time.Minute),
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO screenings").
+		WithArgs(4, 2, screening.StartTime, screening.EndTime, models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(12, now, now))
+
+	err = repo.CreateScreening(context.Background(), screening)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12, screening.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_GetScreeningsByCinema_DayRange(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	date := time.Date(2026, 1, 20, 15, 30, 0, 0, time.Local)
+	dayStart := time.Date(2026, 1, 20, 0, 0, 0, 0, time.Local)
+	start := time.Date(2026, 1, 20, 19, 0, 0, 0, time.Local)
+	now := time.Now()
+	var endDate *time.Time
+
+	rows := pgxmock.NewRows(screeningColumns).
+		AddRow(12, 1, 4, 2, start, start.Add(119*time.Minute), 50000.0, now, now,
+			2, "Pengabdi Setan 2", "Sekuel horor", 119, "Horror", "17+", "poster.jpg", now, endDate, now, now)
+
+	mock.ExpectQuery("SELECT sc.id, a.cinema_id").
+		WithArgs(1, dayStart, dayStart.AddDate(0, 0, 1)).
+		WillReturnRows(rows)
+
+	screenings, err := repo.GetScreeningsByCinema(context.Background(), 1, date)
+
+	assert.NoError(t, err)
+	assert.Len(t, screenings, 1)
+	assert.Equal(t, 12, screenings[0].ID)
+	assert.Equal(t, 4, screenings[0].AuditoriumID)
+	assert.Equal(t, "Pengabdi Setan 2", screenings[0].Movie.Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_GetScreeningByID_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT sc.id, a.cinema_id").
+		WithArgs(99).
+		WillReturnError(pgx.ErrNoRows)
+
+	screening, err := repo.GetScreeningByID(context.Background(), 99)
+
+	assert.NoError(t, err)
+	assert.Nil(t, screening)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_GetScreeningByID_SkipsArchivedCinemas(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("WHERE sc.id = \\$1 AND c.archived_at IS NULL").
+		WithArgs(12).
+		WillReturnError(pgx.ErrNoRows)
+
+	screening, err := repo.GetScreeningByID(context.Background(), 12)
+
+	assert.NoError(t, err)
+	assert.Nil(t, screening)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_CreateScreenings_CreatesAvailability(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	start := time.Date(2026, 1, 20, 19, 0, 0, 0, time.Local)
+	screening := &models.Screening{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartTime:    start,
+		EndTime:      start.Add(119 * time.Minute),
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT c.archived_at IS NOT NULL FROM auditoriums a").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"archived"}).AddRow(false))
+	// The screening and the cleaning time around it must be free
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(4, start.Add(-15*time.Minute), start.Add(134*time.Minute)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO screenings").
+		WithArgs(4, 2, screening.StartTime, screening.EndTime, models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(12, now, now))
+	mock.ExpectExec("INSERT INTO seat_availability .* SELECT").
+		WithArgs(12, 4).
+		WillReturnResult(pgxmock.NewResult("INSERT", 100))
+	mock.ExpectCommit()
+
+	err = repo.CreateScreenings(context.Background(), 4, []*models.Screening{screening}, 15*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12, screening.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_CreateScreenings_Overlap(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	start := time.Date(2026, 1, 20, 19, 0, 0, 0, time.Local)
+	screening := &models.Screening{AuditoriumID: 4, MovieID: 2, StartTime: start, EndTime: start.Add(119 * time.Minute)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT c.archived_at IS NOT NULL FROM auditoriums a").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"archived"}).AddRow(false))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(4, start.Add(-15*time.Minute), start.Add(134*time.Minute)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.CreateScreenings(context.Background(), 4, []*models.Screening{screening}, 15*time.Minute)
+
+	assert.ErrorIs(t, err, models.ErrScheduleConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_GetScreeningsByAuditorium_Window(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	from := time.Date(2026, 1, 20, 9, 45, 0, 0, time.Local)
+	to := time.Date(2026, 1, 27, 23, 0, 0, 0, time.Local)
+	start := time.Date(2026, 1, 21, 19, 0, 0, 0, time.Local)
+	now := time.Now()
+	var endDate *time.Time
+
+	rows := pgxmock.NewRows(screeningColumns).
+		AddRow(12, 1, 4, 2, start, start.Add(119*time.Minute), 50000.0, now, now,
+			2, "Pengabdi Setan 2", "Sekuel horor", 119, "Horror", "17+", "poster.jpg", now, endDate, now, now)
+
+	mock.ExpectQuery("WHERE sc.auditorium_id = \\$1").
+		WithArgs(4, from, to).
+		WillReturnRows(rows)
+
+	screenings, err := repo.GetScreeningsByAuditorium(context.Background(), 4, from, to)
+
+	assert.NoError(t, err)
+	assert.Len(t, screenings, 1)
+	assert.Equal(t, start, screenings[0].StartTime)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScreeningRepository_CreateScreenings_ArchivedCinema(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewScreeningRepository(&mockDB{pool: mock})
+
+	start := time.Date(2026, 1, 20, 19, 0, 0, 0, time.Local)
+	screening := &models.Screening{AuditoriumID: 4, MovieID: 2, StartTime: start, EndTime: start.Add(119 * time.Minute)}
+
+	// The cinema was archived while the schedule was being prepared
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT c.archived_at IS NOT NULL FROM auditoriums a").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"archived"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.CreateScreenings(context.Background(), 4, []*models.Screening{screening}, 15*time.Minute)
+
+	assert.ErrorIs(t, err, models.ErrCinemaArchived)
+	assert.Zero(t, screening.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
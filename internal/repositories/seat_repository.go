@@ -2,8 +2,8 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/jackc/pgx/v5"
@@ -19,12 +19,12 @@ func NewSeatRepository(db Database) *SeatRepository {
 	return &SeatRepository{db: db}
 }
 
-// GetSeatsByCinema retrieves all seats for a cinema
-func (r *SeatRepository) GetSeatsByCinema(ctx context.Context, cinemaID int) ([]*models.Seat, error) {
-	query := `SELECT id, cinema_id, seat_number, row_number, seat_type, price, created_at, updated_at 
-	FROM seats WHERE cinema_id = $1 ORDER BY row_number, seat_number`
+// GetSeatsByAuditorium retrieves the seats of an auditorium; seats removed from its seat map are left out
+func (r *SeatRepository) GetSeatsByAuditorium(ctx context.Context, auditoriumID int) ([]*models.Seat, error) {
+	query := `SELECT id, auditorium_id, seat_number, row_number, seat_type, price, is_active, created_at, updated_at 
+	FROM seats WHERE auditorium_id = $1 AND is_active ORDER BY row_number, seat_number`
 
-	rows, err := r.db.Query(ctx, query, cinemaID)
+	rows, err := conn(ctx, r.db).Query(ctx, query, auditoriumID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get seats: %w", err)
 	}
@@ -33,7 +33,7 @@ func (r *SeatRepository) GetSeatsByCinema(ctx context.Context, cinemaID int) ([]
 	seats := []*models.Seat{}
 	for rows.Next() {
 		seat := &models.Seat{}
-		err := rows.Scan(&seat.ID, &seat.CinemaID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt)
+		err := rows.Scan(&seat.ID, &seat.AuditoriumID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.IsActive, &seat.CreatedAt, &seat.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan seat: %w", err)
 		}
@@ -46,11 +46,11 @@ func (r *SeatRepository) GetSeatsByCinema(ctx context.Context, cinemaID int) ([]
 // GetSeatByID retrieves a seat by ID
 func (r *SeatRepository) GetSeatByID(ctx context.Context, id int) (*models.Seat, error) {
 	seat := &models.Seat{}
-	query := `SELECT id, cinema_id, seat_number, row_number, seat_type, price, created_at, updated_at 
+	query := `SELECT id, auditorium_id, seat_number, row_number, seat_type, price, is_active, created_at, updated_at 
 	FROM seats WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&seat.ID, &seat.CinemaID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&seat.ID, &seat.AuditoriumID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.IsActive, &seat.CreatedAt, &seat.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -62,30 +62,31 @@ func (r *SeatRepository) GetSeatByID(ctx context.Context, id int) (*models.Seat,
 	return seat, nil
 }
 
-// CreateSeat creates a new seat (for seeding)
+// CreateSeat creates a single seat outside of a seat map
 func (r *SeatRepository) CreateSeat(ctx context.Context, seat *models.Seat) error {
-	query := `INSERT INTO seats (cinema_id, seat_number, row_number, seat_type, price) 
+	query := `INSERT INTO seats (auditorium_id, seat_number, row_number, seat_type, price) 
 	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, seat.CinemaID, seat.SeatNumber, seat.RowNumber, seat.SeatType, seat.Price).
+	err := conn(ctx, r.db).QueryRow(ctx, query, seat.AuditoriumID, seat.SeatNumber, seat.RowNumber, seat.SeatType, seat.Price).
 		Scan(&seat.ID, &seat.CreatedAt, &seat.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create seat: %w", err)
 	}
+	seat.IsActive = true
 	return nil
 }
 
-// GetSeatAvailability retrieves seat availability for a specific date and time
-func (r *SeatRepository) GetSeatAvailability(ctx context.Context, cinemaID int, date time.Time, timeStr string) ([]*models.SeatAvailability, error) {
-	query := `SELECT sa.id, sa.cinema_id, sa.seat_id, sa.show_date, sa.show_time, sa.is_available, sa.created_at, sa.updated_at,
-	s.id, s.cinema_id, s.seat_number, s.row_number, s.seat_type, s.price, s.created_at, s.updated_at
+// GetSeatAvailability retrieves seat availability for a specific screening
+func (r *SeatRepository) GetSeatAvailability(ctx context.Context, screeningID int) ([]*models.SeatAvailability, error) {
+	query := `SELECT sa.id, sa.screening_id, sa.seat_id, sa.is_available, sa.created_at, sa.updated_at,
+	s.id, s.auditorium_id, s.seat_number, s.row_number, s.seat_type, s.price, s.is_active, s.created_at, s.updated_at
 	FROM seat_availability sa
 	JOIN seats s ON sa.seat_id = s.id
-	WHERE sa.cinema_id = $1 AND sa.show_date = $2 AND sa.show_time = $3
+	WHERE sa.screening_id = $1 AND s.is_active
 	ORDER BY s.row_number, s.seat_number`
 
-	rows, err := r.db.Query(ctx, query, cinemaID, date.Format("2006-01-02"), timeStr)
+	rows, err := conn(ctx, r.db).Query(ctx, query, screeningID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get seat availability: %w", err)
 	}
@@ -96,8 +97,8 @@ func (r *SeatRepository) GetSeatAvailability(ctx context.Context, cinemaID int,
 		sa := &models.SeatAvailability{}
 		seat := &models.Seat{}
 		err := rows.Scan(
-			&sa.ID, &sa.CinemaID, &sa.SeatID, &sa.ShowDate, &sa.ShowTime, &sa.IsAvailable, &sa.CreatedAt, &sa.UpdatedAt,
-			&seat.ID, &seat.CinemaID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.CreatedAt, &seat.UpdatedAt,
+			&sa.ID, &sa.ScreeningID, &sa.SeatID, &sa.IsAvailable, &sa.CreatedAt, &sa.UpdatedAt,
+			&seat.ID, &seat.AuditoriumID, &seat.SeatNumber, &seat.RowNumber, &seat.SeatType, &seat.Price, &seat.IsActive, &seat.CreatedAt, &seat.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan seat availability: %w", err)
@@ -109,19 +110,19 @@ func (r *SeatRepository) GetSeatAvailability(ctx context.Context, cinemaID int,
 	return availabilities, nil
 }
 
-// CreateSeatAvailability creates seat availability records (for seeding)
-func (r *SeatRepository) CreateSeatAvailability(ctx context.Context, cinemaID int, date time.Time, timeStr string) error {
-	// Get all seats for the cinema
-	seats, err := r.GetSeatsBycinema(ctx, cinemaID)
+// CreateSeatAvailability creates seat availability records for every seat of a screening's auditorium
+func (r *SeatRepository) CreateSeatAvailability(ctx context.Context, screeningID, auditoriumID int) error {
+	// Get all seats for the auditorium
+	seats, err := r.GetSeatsByAuditorium(ctx, auditoriumID)
 	if err != nil {
 		return err
 	}
 
 	for _, seat := range seats {
-		query := `INSERT INTO seat_availability (cinema_id, seat_id, show_date, show_time, is_available) 
-		VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`
+		query := `INSERT INTO seat_availability (screening_id, seat_id, is_available) 
+		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
 
-		_, err := r.db.Exec(ctx, query, cinemaID, seat.ID, date.Format("2006-01-02"), timeStr, true)
+		_, err := conn(ctx, r.db).Exec(ctx, query, screeningID, seat.ID, true)
 		if err != nil {
 			return fmt.Errorf("failed to create seat availability: %w", err)
 		}
@@ -130,20 +131,128 @@ func (r *SeatRepository) CreateSeatAvailability(ctx context.Context, cinemaID in
 	return nil
 }
 
-// GetSeatsBycinema is a helper to get seats by cinema
-func (r *SeatRepository) GetSeatsBycinema(ctx context.Context, cinemaID int) ([]*models.Seat, error) {
-	return r.GetSeatsByCinema(ctx, cinemaID)
+// UpdateSeatAvailability updates the availability of a seat for a screening
+func (r *SeatRepository) UpdateSeatAvailability(ctx context.Context, screeningID, seatID int, isAvailable bool) error {
+	query := `UPDATE seat_availability SET is_available = $1, updated_at = CURRENT_TIMESTAMP 
+	WHERE screening_id = $2 AND seat_id = $3`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, isAvailable, screeningID, seatID)
+	if err != nil {
+		return fmt.Errorf("failed to update seat availability: %w", err)
+	}
+
+	return nil
 }
 
-// UpdateSeatAvailability updates the availability of a seat
-func (r *SeatRepository) UpdateSeatAvailability(ctx context.Context, seatID int, date time.Time, timeStr string, isAvailable bool) error {
-	query := `UPDATE seat_availability SET is_available = $1, updated_at = CURRENT_TIMESTAMP 
-	WHERE seat_id = $2 AND show_date = $3 AND show_time = $4`
+// GetSeatMap retrieves the seat layout uploaded for an auditorium. It returns nil if the
+// auditorium does not exist or has no uploaded layout.
+func (r *SeatRepository) GetSeatMap(ctx context.Context, auditoriumID int) ([]models.SeatMapRowLayout, error) {
+	var layout []byte
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT seat_map FROM auditoriums WHERE id = $1`, auditoriumID).Scan(&layout)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get seat map: %w", err)
+	}
+	if layout == nil {
+		return nil, nil
+	}
+
+	var rows []models.SeatMapRowLayout
+	if err := json.Unmarshal(layout, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode seat map: %w", err)
+	}
+	return rows, nil
+}
+
+// SaveSeatMap stores the seat layout of an auditorium along with its seats in a single
+// transaction. Seats are matched by seat number: existing seats are updated in place,
+// new ones are created and seats no longer in the layout are deactivated rather than
+// deleted, so bookings keep their seats. Seat availability of upcoming screenings follows
+// the new layout, except for seats that are already booked. The IDs of seats are filled
+// in. It returns models.ErrAuditoriumNotFound if the auditorium does not exist.
+func (r *SeatRepository) SaveSeatMap(ctx context.Context, auditoriumID int, layout []models.SeatMapRowLayout, seats []*models.Seat) error {
+	encoded, err := json.Marshal(layout)
+	if err != nil {
+		return fmt.Errorf("failed to encode seat map: %w", err)
+	}
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	_, err := r.db.Exec(ctx, query, isAvailable, seatID, date.Format("2006-01-02"), timeStr)
+	// Lock the auditorium so concurrent uploads are serialized
+	var id int
+	err = tx.QueryRow(ctx, `SELECT id FROM auditoriums WHERE id = $1 FOR UPDATE`, auditoriumID).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.ErrAuditoriumNotFound
+		}
+		return fmt.Errorf("failed to lock auditorium: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE auditoriums SET seat_map = $1, capacity = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		encoded, len(seats), auditoriumID)
+	if err != nil {
+		return fmt.Errorf("failed to update auditorium: %w", err)
+	}
+
+	upsertQuery := `INSERT INTO seats (auditorium_id, seat_number, row_number, seat_type, price, is_active) 
+	VALUES ($1, $2, $3, $4, $5, TRUE) 
+	ON CONFLICT (auditorium_id, seat_number) DO UPDATE SET row_number = EXCLUDED.row_number, 
+	seat_type = EXCLUDED.seat_type, price = EXCLUDED.price, is_active = TRUE, updated_at = CURRENT_TIMESTAMP 
+	RETURNING id, created_at, updated_at`
+
+	seatNumbers := make([]string, 0, len(seats))
+	for _, seat := range seats {
+		err := tx.QueryRow(ctx, upsertQuery, auditoriumID, seat.SeatNumber, seat.RowNumber, seat.SeatType, seat.Price).
+			Scan(&seat.ID, &seat.CreatedAt, &seat.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save seat: %w", err)
+		}
+		seat.AuditoriumID = auditoriumID
+		seat.IsActive = true
+		seatNumbers = append(seatNumbers, seat.SeatNumber)
+	}
+
+	deactivateQuery := `UPDATE seats SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP 
+	WHERE auditorium_id = $1 AND is_active AND NOT (seat_number = ANY($2))`
+
+	_, err = tx.Exec(ctx, deactivateQuery, auditoriumID, seatNumbers)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate removed seats: %w", err)
+	}
+
+	// Upcoming screenings get availability for new seats...
+	addAvailabilityQuery := `INSERT INTO seat_availability (screening_id, seat_id, is_available) 
+	SELECT sc.id, s.id, TRUE FROM screenings sc 
+	JOIN seats s ON s.auditorium_id = sc.auditorium_id 
+	WHERE sc.auditorium_id = $1 AND sc.start_time > CURRENT_TIMESTAMP AND s.is_active 
+	ON CONFLICT (screening_id, seat_id) DO NOTHING`
+
+	_, err = tx.Exec(ctx, addAvailabilityQuery, auditoriumID)
+	if err != nil {
+		return fmt.Errorf("failed to add seat availability: %w", err)
+	}
+
+	// ...and removed seats stop being bookable while restored ones become bookable again.
+	// Seats held by a booking are left as they are.
+	syncAvailabilityQuery := `UPDATE seat_availability sa SET is_available = s.is_active, updated_at = CURRENT_TIMESTAMP 
+	FROM seats s, screenings sc 
+	WHERE sa.seat_id = s.id AND sa.screening_id = sc.id 
+	AND sc.auditorium_id = $1 AND sc.start_time > CURRENT_TIMESTAMP AND sa.is_available <> s.is_active 
+	AND NOT EXISTS (SELECT 1 FROM booking_seats bs WHERE bs.screening_id = sa.screening_id AND bs.seat_id = sa.seat_id AND bs.is_active)`
+
+	_, err = tx.Exec(ctx, syncAvailabilityQuery, auditoriumID)
 	if err != nil {
 		return fmt.Errorf("failed to update seat availability: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
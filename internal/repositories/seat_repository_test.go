@@ -11,7 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestSeatRepository_GetSeatsByCinema_Success(t *testing.T) {
+func TestSeatRepository_GetSeatsByAuditorium_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer mock.Close()
@@ -19,17 +19,17 @@ func TestSeatRepository_GetSeatsByCinema_Success(t *testing.T) {
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "cinema_id", "seat_number", "row_number", "seat_type", "price", "created_at", "updated_at"}).
-		AddRow(1, 1, "A1", 1, "regular", 50000.0, now, now).
-		AddRow(2, 1, "A2", 1, "regular", 50000.0, now, now).
-		AddRow(3, 1, "B1", 2, "vip", 100000.0, now, now)
+	rows := pgxmock.NewRows([]string{"id", "auditorium_id", "seat_number", "row_number", "seat_type", "price", "is_active", "created_at", "updated_at"}).
+		AddRow(1, 1, "A1", 1, "regular", 50000.0, true, now, now).
+		AddRow(2, 1, "A2", 1, "regular", 50000.0, true, now, now).
+		AddRow(3, 1, "B1", 2, "vip", 100000.0, true, now, now)
 
-	mock.ExpectQuery("SELECT id, cinema_id, seat_number").
+	mock.ExpectQuery("SELECT id, auditorium_id, seat_number").
 		WithArgs(1).
 		WillReturnRows(rows)
 
 	// Execute
-	seats, err := repo.GetSeatsByCinema(context.Background(), 1)
+	seats, err := repo.GetSeatsByAuditorium(context.Background(), 1)
 
 	// Assert
 	assert.NoError(t, err)
@@ -48,10 +48,10 @@ func TestSeatRepository_GetSeatByID_Success(t *testing.T) {
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "cinema_id", "seat_number", "row_number", "seat_type", "price", "created_at", "updated_at"}).
-		AddRow(1, 1, "A1", 1, "regular", 50000.0, now, now)
+	rows := pgxmock.NewRows([]string{"id", "auditorium_id", "seat_number", "row_number", "seat_type", "price", "is_active", "created_at", "updated_at"}).
+		AddRow(1, 1, "A1", 1, "regular", 50000.0, true, now, now)
 
-	mock.ExpectQuery("SELECT id, cinema_id, seat_number").
+	mock.ExpectQuery("SELECT id, auditorium_id, seat_number").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -73,7 +73,7 @@ func TestSeatRepository_GetSeatByID_NotFound(t *testing.T) {
 
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
-	mock.ExpectQuery("SELECT id, cinema_id, seat_number").
+	mock.ExpectQuery("SELECT id, auditorium_id, seat_number").
 		WithArgs(999).
 		WillReturnError(pgx.ErrNoRows)
 
@@ -98,16 +98,16 @@ func TestSeatRepository_CreateSeat_Success(t *testing.T) {
 		AddRow(1, now, now)
 
 	mock.ExpectQuery("INSERT INTO seats").
-		WithArgs(1, "A1", 1, "regular", 50000.0).
+		WithArgs(1, "A1", 1, "regular", models.MustParseMoney("50000")).
 		WillReturnRows(rows)
 
 	// Execute
 	seat := &models.Seat{
-		CinemaID:   1,
-		SeatNumber: "A1",
-		RowNumber:  1,
-		SeatType:   "regular",
-		Price:      50000.0,
+		AuditoriumID: 1,
+		SeatNumber:   "A1",
+		RowNumber:    1,
+		SeatType:     "regular",
+		Price:        models.MustParseMoney("50000"),
 	}
 	err = repo.CreateSeat(context.Background(), seat)
 
@@ -125,28 +125,28 @@ func TestSeatRepository_GetSeatAvailability_Success(t *testing.T) {
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	showDate := time.Now().AddDate(0, 0, 1) // Tomorrow
 	rows := pgxmock.NewRows([]string{
-		"sa_id", "sa_cinema_id", "sa_seat_id", "sa_show_date", "sa_show_time", "sa_is_available", "sa_created_at", "sa_updated_at",
-		"s_id", "s_cinema_id", "s_seat_number", "s_row_number", "s_seat_type", "s_price", "s_created_at", "s_updated_at",
+		"sa_id", "sa_screening_id", "sa_seat_id", "sa_is_available", "sa_created_at", "sa_updated_at",
+		"s_id", "s_auditorium_id", "s_seat_number", "s_row_number", "s_seat_type", "s_price", "s_is_active", "s_created_at", "s_updated_at",
 	}).
-		AddRow(1, 1, 1, showDate, "19:00", true, now, now,
-			1, 1, "A1", 1, "regular", 50000.0, now, now).
-		AddRow(2, 1, 2, showDate, "19:00", false, now, now,
-			2, 1, "A2", 1, "regular", 50000.0, now, now)
+		AddRow(1, 7, 1, true, now, now,
+			1, 1, "A1", 1, "regular", 50000.0, true, now, now).
+		AddRow(2, 7, 2, false, now, now,
+			2, 1, "A2", 1, "regular", 50000.0, true, now, now)
 
-	mock.ExpectQuery("SELECT sa.id, sa.cinema_id").
-		WithArgs(1, showDate.Format("2006-01-02"), "19:00").
+	mock.ExpectQuery("SELECT sa.id, sa.screening_id").
+		WithArgs(7).
 		WillReturnRows(rows)
 
 	// Execute
-	availabilities, err := repo.GetSeatAvailability(context.Background(), 1, showDate, "19:00")
+	availabilities, err := repo.GetSeatAvailability(context.Background(), 7)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Len(t, availabilities, 2)
 	assert.True(t, availabilities[0].IsAvailable)
 	assert.False(t, availabilities[1].IsAvailable)
+	assert.Equal(t, 7, availabilities[0].ScreeningID)
 	assert.Equal(t, "A1", availabilities[0].Seat.SeatNumber)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -158,13 +158,12 @@ func TestSeatRepository_UpdateSeatAvailability_Success(t *testing.T) {
 
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
-	showDate := time.Now().AddDate(0, 0, 1)
 	mock.ExpectExec("UPDATE seat_availability SET is_available").
-		WithArgs(false, 1, showDate.Format("2006-01-02"), "19:00").
+		WithArgs(false, 7, 1).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 	// Execute
-	err = repo.UpdateSeatAvailability(context.Background(), 1, showDate, "19:00", false)
+	err = repo.UpdateSeatAvailability(context.Background(), 7, 1, false)
 
 	// Assert
 	assert.NoError(t, err)
@@ -179,30 +178,133 @@ func TestSeatRepository_CreateSeatAvailability_Success(t *testing.T) {
 	repo := NewSeatRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	showDate := time.Now().AddDate(0, 0, 1)
 
-	// Mock GetSeatsByCinema query first
-	seatRows := pgxmock.NewRows([]string{"id", "cinema_id", "seat_number", "row_number", "seat_type", "price", "created_at", "updated_at"}).
-		AddRow(1, 1, "A1", 1, "regular", 50000.0, now, now).
-		AddRow(2, 1, "A2", 1, "regular", 50000.0, now, now)
+	// Mock GetSeatsByAuditorium query first
+	seatRows := pgxmock.NewRows([]string{"id", "auditorium_id", "seat_number", "row_number", "seat_type", "price", "is_active", "created_at", "updated_at"}).
+		AddRow(1, 1, "A1", 1, "regular", 50000.0, true, now, now).
+		AddRow(2, 1, "A2", 1, "regular", 50000.0, true, now, now)
 
-	mock.ExpectQuery("SELECT id, cinema_id, seat_number").
+	mock.ExpectQuery("SELECT id, auditorium_id, seat_number").
 		WithArgs(1).
 		WillReturnRows(seatRows)
 
 	// Mock INSERT for each seat
 	mock.ExpectExec("INSERT INTO seat_availability").
-		WithArgs(1, 1, showDate.Format("2006-01-02"), "19:00", true).
+		WithArgs(7, 1, true).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	mock.ExpectExec("INSERT INTO seat_availability").
-		WithArgs(1, 2, showDate.Format("2006-01-02"), "19:00", true).
+		WithArgs(7, 2, true).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	// Execute
-	err = repo.CreateSeatAvailability(context.Background(), 1, showDate, "19:00")
+	err = repo.CreateSeatAvailability(context.Background(), 7, 1)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestSeatRepository_GetSeatMap_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSeatRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT seat_map FROM auditoriums").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_map"}).
+			AddRow([]byte(`[{"label":"A","cells":["standard","aisle","vip"]}]`)))
+
+	layout, err := repo.GetSeatMap(context.Background(), 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard", "aisle", "vip"}}}, layout)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeatRepository_GetSeatMap_NotUploaded(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSeatRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT seat_map FROM auditoriums").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"seat_map"}).AddRow(nil))
+
+	layout, err := repo.GetSeatMap(context.Background(), 4)
+
+	assert.NoError(t, err)
+	assert.Nil(t, layout)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeatRepository_SaveSeatMap_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSeatRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	layout := []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard", "aisle", "standard"}}}
+	seats := []*models.Seat{
+		{SeatNumber: "A1", RowNumber: 1, SeatType: "standard", Price: models.MustParseMoney("50000")},
+		{SeatNumber: "A2", RowNumber: 1, SeatType: "standard", Price: models.MustParseMoney("50000")},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM auditoriums .* FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectExec("UPDATE auditoriums SET seat_map").
+		WithArgs([]byte(`[{"label":"A","cells":["standard","aisle","standard"]}]`), 2, 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	// A1 already exists and is updated in place, A2 is new
+	mock.ExpectQuery("INSERT INTO seats .* ON CONFLICT").
+		WithArgs(4, "A1", 1, "standard", models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(10, now, now))
+	mock.ExpectQuery("INSERT INTO seats .* ON CONFLICT").
+		WithArgs(4, "A2", 1, "standard", models.MustParseMoney("50000")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(21, now, now))
+	mock.ExpectExec("UPDATE seats SET is_active = FALSE").
+		WithArgs(4, []string{"A1", "A2"}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	mock.ExpectExec("INSERT INTO seat_availability").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("INSERT", 5))
+	mock.ExpectExec("UPDATE seat_availability sa SET is_available = s.is_active").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 12))
+	mock.ExpectCommit()
+
+	err = repo.SaveSeatMap(context.Background(), 4, layout, seats)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, seats[0].ID)
+	assert.Equal(t, 21, seats[1].ID)
+	assert.Equal(t, 4, seats[1].AuditoriumID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeatRepository_SaveSeatMap_AuditoriumNotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSeatRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM auditoriums .* FOR UPDATE").
+		WithArgs(99).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.SaveSeatMap(context.Background(), 99, []models.SeatMapRowLayout{}, []*models.Seat{})
+
+	assert.ErrorIs(t, err, models.ErrAuditoriumNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txKey is the context key of the transaction a unit of work runs in
+type txKey struct{}
+
+// UnitOfWork runs operations spanning several repositories in one transaction
+type UnitOfWork struct {
+	db Database
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(db Database) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// WithTx runs fn in a transaction. Repositories called with the context passed to fn
+// run their queries in that transaction, which is committed when fn returns nil and
+// rolled back otherwise. A nested call runs in a savepoint of the outer transaction.
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := conn(ctx, u.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction of the unit of work ctx runs in, or db outside of one
+func conn(ctx context.Context, db Database) Database {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork_WithTx_CommitsRepositoryCalls(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &mockDB{pool: mock}
+	uow := NewUnitOfWork(db)
+	bookingRepo := NewBookingRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE bookings SET payment_status").
+		WithArgs("paid", 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bookings SET status").
+		WithArgs("confirmed", 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = uow.WithTx(context.Background(), func(ctx context.Context) error {
+		_, inTx := ctx.Value(txKey{}).(pgx.Tx)
+		assert.True(t, inTx)
+		if err := bookingRepo.UpdateBookingPaymentStatus(ctx, 1, "paid"); err != nil {
+			return err
+		}
+		return bookingRepo.UpdateBookingStatus(ctx, 1, "confirmed")
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnitOfWork_WithTx_RollsBackOnError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &mockDB{pool: mock}
+	uow := NewUnitOfWork(db)
+	bookingRepo := NewBookingRepository(db)
+	failure := errors.New("refund failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE bookings SET status").
+		WithArgs("cancelled", 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectRollback()
+
+	err = uow.WithTx(context.Background(), func(ctx context.Context) error {
+		if err := bookingRepo.UpdateBookingStatus(ctx, 1, "cancelled"); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,9 +9,8 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-// Database interface for database operations
+// Database interface for database operations; both a connection pool and a transaction satisfy it
 type Database interface {
-	Close(ctx context.Context) error
 	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
 	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
@@ -31,10 +30,10 @@ func NewUserRepository(db Database) *UserRepository {
 // CreateUser creates a new user in the database
 func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
 	query := `INSERT INTO users (username, email, password, is_verified) 
-	VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+	VALUES ($1, $2, $3, $4) RETURNING id, role, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, user.Username, user.Email, user.Password, false).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, user.Username, user.Email, user.Password, false).
+		Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -45,11 +44,11 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) erro
 // GetUserByUsername retrieves a user by username
 func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	user := &models.User{}
-	query := `SELECT id, username, email, password, is_verified, created_at, updated_at 
+	query := `SELECT id, username, email, password, is_verified, role, created_at, updated_at 
 	FROM users WHERE username = $1`
 
-	err := r.db.QueryRow(ctx, query, username).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, username).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -63,11 +62,30 @@ func (r *UserRepository) GetUserByUsername(ctx context.Context, username string)
 // GetUserByEmail retrieves a user by email
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
-	query := `SELECT id, username, email, password, is_verified, created_at, updated_at 
+	query := `SELECT id, username, email, password, is_verified, role, created_at, updated_at 
 	FROM users WHERE email = $1`
 
-	err := r.db.QueryRow(ctx, query, email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, email).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return user, nil
+}
+
+// GetUserByEmailForUpdate retrieves a user by email and locks its row until the end of the
+// transaction in ctx, so requests made for the same user are serialized
+func (r *UserRepository) GetUserByEmailForUpdate(ctx context.Context, email string) (*models.User, error) {
+	user := &models.User{}
+	query := `SELECT id, username, email, password, is_verified, role, created_at, updated_at 
+	FROM users WHERE email = $1 FOR UPDATE`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, email).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -81,11 +99,11 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 // GetUserByID retrieves a user by ID
 func (r *UserRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	user := &models.User{}
-	query := `SELECT id, username, email, password, is_verified, created_at, updated_at 
+	query := `SELECT id, username, email, password, is_verified, role, created_at, updated_at 
 	FROM users WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsVerified, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -96,13 +114,87 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int) (*models.User,
 	return user, nil
 }
 
+// GetUserCinemaIDs retrieves the cinemas a cinema staff member or admin is assigned to
+func (r *UserRepository) GetUserCinemaIDs(ctx context.Context, userID int) ([]int, error) {
+	query := `SELECT cinema_id FROM cinema_staff WHERE user_id = $1 ORDER BY cinema_id`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cinemas: %w", err)
+	}
+	defer rows.Close()
+
+	cinemaIDs := []int{}
+	for rows.Next() {
+		var cinemaID int
+		err := rows.Scan(&cinemaID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user cinema: %w", err)
+		}
+		cinemaIDs = append(cinemaIDs, cinemaID)
+	}
+
+	return cinemaIDs, nil
+}
+
+// UpdateUserRole gives a user a new role and replaces the cinemas they are assigned to.
+// The user's sessions are deleted in the same transaction, so tokens carrying the old
+// role stop working. It returns models.ErrCinemaNotFound if a cinema does not exist.
+func (r *UserRepository) UpdateUserRole(ctx context.Context, userID int, role string, cinemaIDs []int) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM cinema_staff WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear user cinemas: %w", err)
+	}
+
+	for _, cinemaID := range cinemaIDs {
+		_, err = tx.Exec(ctx, `INSERT INTO cinema_staff (user_id, cinema_id) VALUES ($1, $2)`, userID, cinemaID)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return models.ErrCinemaNotFound
+			}
+			return fmt.Errorf("failed to assign user cinema: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `UPDATE users SET password = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err := conn(ctx, r.db).Exec(ctx, query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
 // CreateSession creates a new user session
 func (r *UserRepository) CreateSession(ctx context.Context, session *models.UserSession) error {
-	query := `INSERT INTO user_sessions (user_id, token, expires_at) 
-	VALUES ($1, $2, $3) RETURNING id, created_at`
+	query := `INSERT INTO user_sessions (user_id, user_agent, ip_address, expires_at) 
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at, last_used_at`
 
-	err := r.db.QueryRow(ctx, query, session.UserID, session.Token, session.ExpiresAt).
-		Scan(&session.ID, &session.CreatedAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt).
+		Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
@@ -110,30 +202,144 @@ func (r *UserRepository) CreateSession(ctx context.Context, session *models.User
 	return nil
 }
 
-// GetSessionByToken retrieves a session by token
-func (r *UserRepository) GetSessionByToken(ctx context.Context, token string) (*models.UserSession, error) {
+// GetSessionByID retrieves a session by ID
+func (r *UserRepository) GetSessionByID(ctx context.Context, id int) (*models.UserSession, error) {
 	session := &models.UserSession{}
-	query := `SELECT id, user_id, token, created_at, expires_at 
-	FROM user_sessions WHERE token = $1`
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at 
+	FROM user_sessions WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, token).
-		Scan(&session.ID, &session.UserID, &session.Token, &session.CreatedAt, &session.ExpiresAt)
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).
+		Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to get session by token: %w", err)
+		return nil, fmt.Errorf("failed to get session by id: %w", err)
 	}
 	return session, nil
 }
 
-// DeleteSession deletes a session
-func (r *UserRepository) DeleteSession(ctx context.Context, token string) error {
-	query := `DELETE FROM user_sessions WHERE token = $1`
-	_, err := r.db.Exec(ctx, query, token)
+// RevokeSession ends a session; its access and refresh tokens stop working
+func (r *UserRepository) RevokeSession(ctx context.Context, id int) error {
+	query := `UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records that a session was just used
+func (r *UserRepository) TouchSession(ctx context.Context, id int) error {
+	query := `UPDATE user_sessions SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// ListActiveSessions lists the sessions of a user that are neither revoked nor expired,
+// most recently used first
+func (r *UserRepository) ListActiveSessions(ctx context.Context, userID int) ([]*models.UserSession, error) {
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at 
+	FROM user_sessions 
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP 
+	ORDER BY last_used_at DESC, id DESC`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.UserSession
+	for rows.Next() {
+		session := &models.UserSession{}
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession ends one session of a user. It returns models.ErrSessionNotFound
+// if the session belongs to someone else or already ended.
+func (r *UserRepository) RevokeUserSession(ctx context.Context, userID, id int) error {
+	query := `UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP 
+	WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions ends every session of a user and returns how many were active
+func (r *UserRepository) RevokeUserSessions(ctx context.Context, userID int) (int, error) {
+	query := `UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP 
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// CreateRefreshToken stores a refresh token and extends its session until the token expires
+func (r *UserRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `WITH extended AS (
+		UPDATE user_sessions SET expires_at = $3, last_used_at = CURRENT_TIMESTAMP WHERE id = $1
+	)
+	INSERT INTO refresh_tokens (session_id, token_hash, expires_at) 
+	VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, token.SessionID, token.TokenHash, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value. The row is
+// locked until the transaction ends, so a token can only be used by one request.
+func (r *UserRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	query := `SELECT id, session_id, token_hash, created_at, expires_at, used_at 
+	FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, tokenHash).
+		Scan(&token.ID, &token.SessionID, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// MarkRefreshTokenUsed records that a refresh token was exchanged, so it cannot be used again
+func (r *UserRepository) MarkRefreshTokenUsed(ctx context.Context, id int) error {
+	query := `UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete session: %w", err)
+		return fmt.Errorf("failed to mark refresh token used: %w", err)
 	}
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -19,8 +20,8 @@ func TestUserRepository_CreateUser_Success(t *testing.T) {
 	repo := NewUserRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).
-		AddRow(1, now, now)
+	rows := pgxmock.NewRows([]string{"id", "role", "created_at", "updated_at"}).
+		AddRow(1, "customer", now, now)
 
 	mock.ExpectQuery("INSERT INTO users").
 		WithArgs("testuser", "test@example.com", "hashedpassword", false).
@@ -37,6 +38,7 @@ func TestUserRepository_CreateUser_Success(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, "customer", user.Role)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -48,8 +50,8 @@ func TestUserRepository_GetUserByUsername_Success(t *testing.T) {
 	repo := NewUserRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "created_at", "updated_at"}).
-		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, now, now)
+	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "role", "created_at", "updated_at"}).
+		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, "customer", now, now)
 
 	mock.ExpectQuery("SELECT id, username, email").
 		WithArgs("testuser").
@@ -95,8 +97,8 @@ func TestUserRepository_GetUserByEmail_Success(t *testing.T) {
 	repo := NewUserRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "created_at", "updated_at"}).
-		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, now, now)
+	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "role", "created_at", "updated_at"}).
+		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, "customer", now, now)
 
 	mock.ExpectQuery("SELECT id, username, email").
 		WithArgs("test@example.com").
@@ -112,6 +114,24 @@ func TestUserRepository_GetUserByEmail_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_GetUserByEmailForUpdate_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("FROM users WHERE email = \\$1 FOR UPDATE").
+		WithArgs("nobody@example.com").
+		WillReturnError(pgx.ErrNoRows)
+
+	user, err := repo.GetUserByEmailForUpdate(context.Background(), "nobody@example.com")
+
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepository_GetUserByID_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
@@ -120,8 +140,8 @@ func TestUserRepository_GetUserByID_Success(t *testing.T) {
 	repo := NewUserRepository(&mockDB{pool: mock})
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "created_at", "updated_at"}).
-		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, now, now)
+	rows := pgxmock.NewRows([]string{"id", "username", "email", "password", "is_verified", "role", "created_at", "updated_at"}).
+		AddRow(1, "testuser", "test@example.com", "hashedpassword", true, "customer", now, now)
 
 	mock.ExpectQuery("SELECT id, username, email").
 		WithArgs(1).
@@ -138,6 +158,25 @@ func TestUserRepository_GetUserByID_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_UpdatePassword_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE users SET password").
+		WithArgs("new-hash", 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	// Execute
+	err = repo.UpdatePassword(context.Background(), 1, "new-hash")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepository_CreateSession_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
@@ -147,17 +186,18 @@ func TestUserRepository_CreateSession_Success(t *testing.T) {
 
 	now := time.Now()
 	expiresAt := now.Add(24 * time.Hour)
-	rows := pgxmock.NewRows([]string{"id", "created_at"}).
-		AddRow(1, now)
+	rows := pgxmock.NewRows([]string{"id", "created_at", "last_used_at"}).
+		AddRow(1, now, now)
 
 	mock.ExpectQuery("INSERT INTO user_sessions").
-		WithArgs(1, "session-token-123", pgxmock.AnyArg()).
+		WithArgs(1, "Mozilla/5.0", "203.0.113.7", pgxmock.AnyArg()).
 		WillReturnRows(rows)
 
 	// Execute
 	session := &models.UserSession{
 		UserID:    1,
-		Token:     "session-token-123",
+		UserAgent: "Mozilla/5.0",
+		IPAddress: "203.0.113.7",
 		ExpiresAt: expiresAt,
 	}
 	err = repo.CreateSession(context.Background(), session)
@@ -168,7 +208,7 @@ func TestUserRepository_CreateSession_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestUserRepository_GetSessionByToken_Success(t *testing.T) {
+func TestUserRepository_GetSessionByID_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer mock.Close()
@@ -177,41 +217,283 @@ func TestUserRepository_GetSessionByToken_Success(t *testing.T) {
 
 	now := time.Now()
 	expiresAt := now.Add(24 * time.Hour)
-	rows := pgxmock.NewRows([]string{"id", "user_id", "token", "expires_at", "created_at"}).
-		AddRow(1, 1, "session-token-123", expiresAt, now)
+	rows := pgxmock.NewRows([]string{"id", "user_id", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at", "revoked_at"}).
+		AddRow(1, 1, "Mozilla/5.0", "203.0.113.7", now, now, expiresAt, nil)
 
-	mock.ExpectQuery("SELECT id, user_id, token").
-		WithArgs("session-token-123").
+	mock.ExpectQuery("FROM user_sessions WHERE id = \\$1").
+		WithArgs(1).
 		WillReturnRows(rows)
 
 	// Execute
-	session, err := repo.GetSessionByToken(context.Background(), "session-token-123")
+	session, err := repo.GetSessionByID(context.Background(), 1)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NotNil(t, session)
-	assert.Equal(t, "session-token-123", session.Token)
 	assert.Equal(t, 1, session.UserID)
+	assert.Nil(t, session.RevokedAt)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestUserRepository_DeleteSession_Success(t *testing.T) {
+func TestUserRepository_RevokeSession_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer mock.Close()
 
 	repo := NewUserRepository(&mockDB{pool: mock})
 
-	mock.ExpectExec("DELETE FROM user_sessions WHERE token").
-		WithArgs("session-token-123").
-		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec("UPDATE user_sessions SET revoked_at").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 	// Execute
-	err = repo.DeleteSession(context.Background(), "session-token-123")
+	err = repo.RevokeSession(context.Background(), 1)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_TouchSession_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE user_sessions SET last_used_at = CURRENT_TIMESTAMP WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	// Execute
+	err = repo.TouchSession(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
+func TestUserRepository_ListActiveSessions_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	expiresAt := now.Add(24 * time.Hour)
+	rows := pgxmock.NewRows([]string{"id", "user_id", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at", "revoked_at"}).
+		AddRow(2, 1, "curl/8.0", "198.51.100.4", now, now, expiresAt, nil).
+		AddRow(1, 1, "Mozilla/5.0", "203.0.113.7", now, now.Add(-time.Hour), expiresAt, nil)
+
+	mock.ExpectQuery("WHERE user_id = \\$1 AND revoked_at IS NULL").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	// Execute
+	sessions, err := repo.ListActiveSessions(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "curl/8.0", sessions[0].UserAgent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_RevokeUserSession_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE user_sessions SET revoked_at").
+		WithArgs(12, 1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// Execute
+	err = repo.RevokeUserSession(context.Background(), 1, 12)
+
+	// Assert
+	assert.ErrorIs(t, err, models.ErrSessionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_RevokeUserSessions_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE user_sessions SET revoked_at").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+	// Execute
+	revoked, err := repo.RevokeUserSessions(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_CreateRefreshToken_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	expiresAt := now.Add(720 * time.Hour)
+
+	mock.ExpectQuery("INSERT INTO refresh_tokens").
+		WithArgs(1, "token-hash", expiresAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(5, now))
+
+	// Execute
+	token := &models.RefreshToken{SessionID: 1, TokenHash: "token-hash", ExpiresAt: expiresAt}
+	err = repo.CreateRefreshToken(context.Background(), token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, token.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetRefreshTokenByHash_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "session_id", "token_hash", "created_at", "expires_at", "used_at"}).
+		AddRow(5, 1, "token-hash", now, now.Add(720*time.Hour), &now)
+
+	mock.ExpectQuery("FROM refresh_tokens WHERE token_hash = \\$1 FOR UPDATE").
+		WithArgs("token-hash").
+		WillReturnRows(rows)
+
+	// Execute
+	token, err := repo.GetRefreshTokenByHash(context.Background(), "token-hash")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, token.SessionID)
+	assert.NotNil(t, token.UsedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetRefreshTokenByHash_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT id, session_id, token_hash").
+		WithArgs("unknown").
+		WillReturnError(pgx.ErrNoRows)
+
+	// Execute
+	token, err := repo.GetRefreshTokenByHash(context.Background(), "unknown")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, token)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_MarkRefreshTokenUsed_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectExec("UPDATE refresh_tokens SET used_at").
+		WithArgs(5).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	// Execute
+	err = repo.MarkRefreshTokenUsed(context.Background(), 5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetUserCinemaIDs_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectQuery("SELECT cinema_id FROM cinema_staff").
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"cinema_id"}).AddRow(1).AddRow(3))
+
+	cinemaIDs, err := repo.GetUserCinemaIDs(context.Background(), 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, cinemaIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateUserRole_ReplacesCinemasAndSessions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET role").
+		WithArgs("cinema_staff", 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM cinema_staff").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec("INSERT INTO cinema_staff").
+		WithArgs(4, 2).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("DELETE FROM user_sessions WHERE user_id").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.ExpectCommit()
+
+	err = repo.UpdateUserRole(context.Background(), 4, "cinema_staff", []int{2})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateUserRole_UnknownCinema(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(&mockDB{pool: mock})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET role").
+		WithArgs("cinema_admin", 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM cinema_staff").
+		WithArgs(4).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO cinema_staff").
+		WithArgs(4, 99).
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+	mock.ExpectRollback()
+
+	err = repo.UpdateUserRole(context.Background(), 4, "cinema_admin", []int{99})
+
+	assert.ErrorIs(t, err, models.ErrCinemaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+)
+
+// AuditoriumService handles auditorium-related business logic
+type AuditoriumService struct {
+	auditoriumRepo AuditoriumRepository
+	cinemaRepo     CinemaRepository
+}
+
+// NewAuditoriumService creates a new AuditoriumService
+func NewAuditoriumService(auditoriumRepo AuditoriumRepository, cinemaRepo CinemaRepository) *AuditoriumService {
+	return &AuditoriumService{
+		auditoriumRepo: auditoriumRepo,
+		cinemaRepo:     cinemaRepo,
+	}
+}
+
+// GetAuditoriumsByCinema lists the auditoriums of a cinema; returns nil if the cinema does not exist
+func (s *AuditoriumService) GetAuditoriumsByCinema(ctx context.Context, cinemaID int) ([]*models.Auditorium, error) {
+	ctx, span := tracing.Start(ctx, "AuditoriumService.GetAuditoriumsByCinema")
+	defer span.End()
+
+	cinema, err := s.cinemaRepo.GetCinemaByID(ctx, cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cinema: %w", err)
+	}
+	if cinema == nil {
+		return nil, nil
+	}
+
+	auditoriums, err := s.auditoriumRepo.GetAuditoriumsByCinema(ctx, cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auditoriums: %w", err)
+	}
+	return auditoriums, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditoriumRepository is a mock implementation of AuditoriumRepository
+type MockAuditoriumRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditoriumRepository) GetAuditoriumsByCinema(ctx context.Context, cinemaID int) ([]*models.Auditorium, error) {
+	args := m.Called(ctx, cinemaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Auditorium), args.Error(1)
+}
+
+func (m *MockAuditoriumRepository) GetAuditoriumByID(ctx context.Context, id int) (*models.Auditorium, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Auditorium), args.Error(1)
+}
+
+func TestGetAuditoriumsByCinema_Success(t *testing.T) {
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewAuditoriumService(auditoriumRepo, cinemaRepo)
+
+	auditoriums := []*models.Auditorium{
+		{ID: 1, CinemaID: 1, Name: "Studio 1", Format: "2D", Capacity: 100},
+		{ID: 2, CinemaID: 1, Name: "Studio 2", Format: "IMAX", Capacity: 60},
+	}
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	auditoriumRepo.On("GetAuditoriumsByCinema", mock.Anything, 1).Return(auditoriums, nil)
+
+	result, err := service.GetAuditoriumsByCinema(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "IMAX", result[1].Format)
+	cinemaRepo.AssertExpectations(t)
+	auditoriumRepo.AssertExpectations(t)
+}
+
+func TestGetAuditoriumsByCinema_CinemaNotFound(t *testing.T) {
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewAuditoriumService(auditoriumRepo, cinemaRepo)
+
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 9).Return(nil, nil)
+
+	result, err := service.GetAuditoriumsByCinema(context.Background(), 9)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	auditoriumRepo.AssertNotCalled(t, "GetAuditoriumsByCinema", mock.Anything, mock.Anything)
+}
+
+func TestGetAuditoriumsByCinema_RepoError(t *testing.T) {
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewAuditoriumService(auditoriumRepo, cinemaRepo)
+
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	auditoriumRepo.On("GetAuditoriumsByCinema", mock.Anything, 1).Return(nil, errors.New("db error"))
+
+	result, err := service.GetAuditoriumsByCinema(context.Background(), 1)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
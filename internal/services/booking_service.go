@@ -7,106 +7,137 @@ import (
 	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 )
 
+// Refunder refunds the payment of a cancelled booking according to the refund policy
+type Refunder interface {
+	RefundPercent(showtime time.Time) int
+	RefundBooking(ctx context.Context, bookingID, percent int) (*models.Refund, error)
+}
+
 // BookingService handles booking-related business logic
 type BookingService struct {
-	bookingRepo BookingRepository
-	seatRepo    SeatRepository
-	cinemaRepo  CinemaRepository
+	bookingRepo   BookingRepository
+	seatRepo      SeatRepository
+	screeningRepo ScreeningRepository
+	refunder      Refunder
+	uow           UnitOfWork
+	holdTTL       time.Duration
+	cancelCutoff  time.Duration
+	newReference  func(prefix string) (string, error)
 }
 
-// NewBookingService creates a new BookingService
-func NewBookingService(bookingRepo BookingRepository, seatRepo SeatRepository, cinemaRepo CinemaRepository) *BookingService {
+// NewBookingService creates a new BookingService; uow runs a cancellation and the
+// release of its seats atomically, holdTTL is how long a pending booking keeps its seats before it
+// expires unpaid and cancelCutoff is how long before showtime a booking can last be cancelled
+func NewBookingService(bookingRepo BookingRepository, seatRepo SeatRepository, screeningRepo ScreeningRepository, refunder Refunder, uow UnitOfWork, holdTTL, cancelCutoff time.Duration) *BookingService {
 	return &BookingService{
-		bookingRepo: bookingRepo,
-		seatRepo:    seatRepo,
-		cinemaRepo:  cinemaRepo,
+		bookingRepo:   bookingRepo,
+		seatRepo:      seatRepo,
+		screeningRepo: screeningRepo,
+		refunder:      refunder,
+		uow:           uow,
+		holdTTL:       holdTTL,
+		cancelCutoff:  cancelCutoff,
+		newReference:  NewReference,
 	}
 }
 
-// CreateBooking creates a new booking
+// CreateBooking creates a booking for one or more seats of a screening.
+// Either every requested seat is reserved or none of them is. The seats are
+// held until the returned HoldExpiresAt; an unpaid booking is expired after that.
+// Screenings of archived cinemas cannot be booked.
 func (s *BookingService) CreateBooking(ctx context.Context, userID int, req *models.BookingRequest) (*models.BookingResponse, error) {
-	// Parse date
-	showDate, err := time.Parse("2006-01-02", req.Date)
-	if err != nil {
-		return nil, errors.New("invalid date format")
-	}
+	ctx, span := tracing.Start(ctx, "BookingService.CreateBooking")
+	defer span.End()
 
-	// Check if seat exists and get its price
-	seat, err := s.seatRepo.GetSeatByID(ctx, req.SeatID)
+	// Check if screening exists and has not started yet
+	screening, err := s.screeningRepo.GetScreeningByID(ctx, req.ScreeningID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get seat: %w", err)
+		return nil, fmt.Errorf("failed to get screening: %w", err)
 	}
-	if seat == nil {
-		return nil, errors.New("seat not found")
-	}
-
-	// Check if cinema exists
-	cinema, err := s.cinemaRepo.GetCinemaByID(ctx, req.CinemaID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cinema: %w", err)
+	if screening == nil {
+		return nil, errors.New("screening not found")
 	}
-	if cinema == nil {
-		return nil, errors.New("cinema not found")
+	if !screening.StartTime.After(time.Now()) {
+		return nil, errors.New("screening has already started")
 	}
 
-	// Check if seat belongs to the cinema
-	if seat.CinemaID != req.CinemaID {
-		return nil, errors.New("seat does not belong to this cinema")
+	// Check every seat exists in the screening's auditorium and sum their prices
+	items := make([]*models.BookingSeat, 0, len(req.SeatIDs))
+	totalPrice := models.Money{}
+	for _, seatID := range req.SeatIDs {
+		seat, err := s.seatRepo.GetSeatByID(ctx, seatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get seat: %w", err)
+		}
+		if seat == nil || !seat.IsActive {
+			return nil, fmt.Errorf("seat %d not found", seatID)
+		}
+		if seat.AuditoriumID != screening.AuditoriumID {
+			return nil, fmt.Errorf("seat %d does not belong to this screening's auditorium", seatID)
+		}
+
+		items = append(items, &models.BookingSeat{SeatID: seat.ID, Price: seat.Price, Seat: seat})
+		totalPrice = totalPrice.Add(seat.Price)
 	}
 
-	// Check if seat is already booked
-	isBooked, err := s.bookingRepo.CheckSeatBooked(ctx, req.SeatID, showDate, req.Time)
+	// Create booking and hold its seats in one transaction
+	reference, err := s.newReference(ReferencePrefixBooking)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check seat booking: %w", err)
-	}
-	if isBooked {
-		return nil, errors.New("seat is already booked for this date and time")
+		return nil, err
 	}
-
-	// Create booking
+	holdExpiresAt := time.Now().Add(s.holdTTL)
 	booking := &models.Booking{
+		Reference:     reference,
 		UserID:        userID,
-		CinemaID:      req.CinemaID,
-		SeatID:        req.SeatID,
-		ShowDate:      showDate,
-		ShowTime:      req.Time,
+		ScreeningID:   req.ScreeningID,
 		Status:        "pending",
-		TotalPrice:    seat.Price,
+		TotalPrice:    totalPrice,
 		PaymentMethod: req.PaymentMethod,
 		PaymentStatus: "pending",
+		HoldExpiresAt: &holdExpiresAt,
+		Seats:         items,
 	}
 
 	err = s.bookingRepo.CreateBooking(ctx, booking)
 	if err != nil {
+		if errors.Is(err, models.ErrSeatAlreadyBooked) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
-
-	// Update seat availability
-	err = s.seatRepo.UpdateSeatAvailability(ctx, req.SeatID, showDate, req.Time, false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update seat availability: %w", err)
-	}
+	bookingsCreated.Inc()
 
 	response := &models.BookingResponse{
 		ID:            booking.ID,
-		CinemaID:      booking.CinemaID,
-		SeatID:        booking.SeatID,
-		ShowDate:      booking.ShowDate,
-		ShowTime:      booking.ShowTime,
+		Reference:     booking.Reference,
+		ScreeningID:   booking.ScreeningID,
+		CinemaID:      screening.CinemaID,
+		AuditoriumID:  screening.AuditoriumID,
+		MovieID:       screening.MovieID,
+		SeatIDs:       req.SeatIDs,
+		StartTime:     screening.StartTime,
 		TotalPrice:    booking.TotalPrice,
 		PaymentMethod: booking.PaymentMethod,
 		Status:        booking.Status,
 		PaymentStatus: booking.PaymentStatus,
+		HoldExpiresAt: booking.HoldExpiresAt,
 		CreatedAt:     booking.CreatedAt,
 	}
+	if screening.Movie != nil {
+		response.MovieTitle = screening.Movie.Title
+	}
 
 	return response, nil
 }
 
 // GetUserBookings retrieves bookings for a user
 func (s *BookingService) GetUserBookings(ctx context.Context, userID int, page, limit int) (*models.PaginatedResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookingService.GetUserBookings")
+	defer span.End()
+
 	if page < 1 {
 		page = 1
 	}
@@ -144,6 +175,9 @@ func (s *BookingService) GetUserBookings(ctx context.Context, userID int, page,
 
 // GetBookingByID retrieves a booking by ID
 func (s *BookingService) GetBookingByID(ctx context.Context, id int) (*models.Booking, error) {
+	ctx, span := tracing.Start(ctx, "BookingService.GetBookingByID")
+	defer span.End()
+
 	booking, err := s.bookingRepo.GetBookingWithDetails(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get booking: %w", err)
@@ -151,8 +185,92 @@ func (s *BookingService) GetBookingByID(ctx context.Context, id int) (*models.Bo
 	return booking, nil
 }
 
+// CancelBooking cancels a booking of the given user and releases its seats.
+// A paid booking is refunded according to the refund policy once the cancellation is
+// committed; until the refund is issued its payment status is refund_pending, and it is
+// non_refundable when the policy grants nothing. It returns nil, nil if the booking
+// does not exist.
+func (s *BookingService) CancelBooking(ctx context.Context, userID, bookingID int) (*models.Booking, error) {
+	ctx, span := tracing.Start(ctx, "BookingService.CancelBooking")
+	defer span.End()
+
+	booking, err := s.bookingRepo.GetBookingWithDetails(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking == nil {
+		return nil, nil
+	}
+
+	if booking.UserID != userID {
+		return nil, models.ErrBookingNotOwned
+	}
+	if booking.Status != "pending" && booking.Status != "confirmed" {
+		return nil, models.ErrBookingNotCancellable
+	}
+	// The provider's webhook could still capture the charge after the seats are released
+	if booking.PaymentStatus == "processing" {
+		return nil, models.ErrBookingPaymentInProgress
+	}
+	if booking.Screening != nil && time.Now().Add(s.cancelCutoff).After(booking.Screening.StartTime) {
+		return nil, models.ErrCancellationWindowClosed
+	}
+
+	paymentStatus := booking.PaymentStatus
+	percent := 0
+	if booking.PaymentStatus == "paid" && booking.Screening != nil {
+		percent = s.refunder.RefundPercent(booking.Screening.StartTime)
+		paymentStatus = "non_refundable"
+		if percent > 0 {
+			paymentStatus = "refund_pending"
+		}
+	}
+
+	// Cancel and release the seats before the gateway is asked for the money, so a
+	// refund is never issued for a booking that stays active
+	err = s.uow.WithTx(ctx, func(ctx context.Context) error {
+		err := s.bookingRepo.CancelBooking(ctx, bookingID)
+		if err != nil {
+			if errors.Is(err, models.ErrBookingNotCancellable) {
+				return err
+			}
+			return fmt.Errorf("failed to cancel booking: %w", err)
+		}
+
+		if paymentStatus != booking.PaymentStatus {
+			if err := s.bookingRepo.UpdateBookingPaymentStatus(ctx, bookingID, paymentStatus); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	booking.Status = "cancelled"
+	booking.PaymentStatus = paymentStatus
+
+	if percent > 0 {
+		// A failed refund leaves the booking cancelled with its refund pending, for an
+		// admin to issue it; the cancellation itself stands
+		refund, err := s.refunder.RefundBooking(ctx, bookingID, percent)
+		if err != nil {
+			span.RecordError(fmt.Errorf("failed to refund booking: %w", err))
+			return booking, nil
+		}
+		if refund != nil {
+			booking.PaymentStatus = refund.PaymentStatus
+		}
+	}
+
+	return booking, nil
+}
+
 // UpdateBookingStatus updates the status of a booking
 func (s *BookingService) UpdateBookingStatus(ctx context.Context, id int, status string) error {
+	ctx, span := tracing.Start(ctx, "BookingService.UpdateBookingStatus")
+	defer span.End()
+
 	err := s.bookingRepo.UpdateBookingStatus(ctx, id, status)
 	if err != nil {
 		return fmt.Errorf("failed to update booking status: %w", err)
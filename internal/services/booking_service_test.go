@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -47,9 +49,12 @@ func (m *MockBookingRepository) UpdateBookingPaymentStatus(ctx context.Context,
 	return args.Error(0)
 }
 
-func (m *MockBookingRepository) CheckSeatBooked(ctx context.Context, seatID int, date time.Time, showTime string) (bool, error) {
-	args := m.Called(ctx, seatID, date, showTime)
-	return args.Bool(0), args.Error(1)
+func (m *MockBookingRepository) GetBookingByIDForUpdate(ctx context.Context, id int) (*models.Booking, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
 func (m *MockBookingRepository) GetBookingWithDetails(ctx context.Context, id int) (*models.Booking, error) {
@@ -60,13 +65,63 @@ func (m *MockBookingRepository) GetBookingWithDetails(ctx context.Context, id in
 	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
+func (m *MockBookingRepository) CancelBooking(ctx context.Context, bookingID int) error {
+	args := m.Called(ctx, bookingID)
+	return args.Error(0)
+}
+
+// MockRefunder is a mock implementation of Refunder
+type MockRefunder struct {
+	mock.Mock
+}
+
+func (m *MockRefunder) RefundPercent(showtime time.Time) int {
+	args := m.Called(showtime)
+	return args.Int(0)
+}
+
+func (m *MockRefunder) RefundBooking(ctx context.Context, bookingID, percent int) (*models.Refund, error) {
+	args := m.Called(ctx, bookingID, percent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Refund), args.Error(1)
+}
+
+// inlineUnitOfWork stands in for a database transaction by running functions directly
+type inlineUnitOfWork struct{}
+
+func (inlineUnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// inTxKey marks the context of a recordingUnitOfWork transaction
+type inTxKey struct{}
+
+// recordingUnitOfWork records whether its transaction was rolled back
+type recordingUnitOfWork struct {
+	rolledBack bool
+}
+
+func (u *recordingUnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(context.WithValue(ctx, inTxKey{}, true))
+	u.rolledBack = err != nil
+	return err
+}
+
+// inTx matches a context inside a recordingUnitOfWork transaction
+var inTx = mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(inTxKey{}) != nil })
+
+// outsideTx matches a context outside of a recordingUnitOfWork transaction
+var outsideTx = mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(inTxKey{}) == nil })
+
 // MockSeatRepository is a mock implementation of SeatRepository
 type MockSeatRepository struct {
 	mock.Mock
 }
 
-func (m *MockSeatRepository) GetSeatsByCinema(ctx context.Context, cinemaID int) ([]*models.Seat, error) {
-	args := m.Called(ctx, cinemaID)
+func (m *MockSeatRepository) GetSeatsByAuditorium(ctx context.Context, auditoriumID int) ([]*models.Seat, error) {
+	args := m.Called(ctx, auditoriumID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -86,16 +141,29 @@ func (m *MockSeatRepository) CreateSeat(ctx context.Context, seat *models.Seat)
 	return args.Error(0)
 }
 
-func (m *MockSeatRepository) GetSeatAvailability(ctx context.Context, cinemaID int, date time.Time, timeStr string) ([]*models.SeatAvailability, error) {
-	args := m.Called(ctx, cinemaID, date, timeStr)
+func (m *MockSeatRepository) GetSeatAvailability(ctx context.Context, screeningID int) ([]*models.SeatAvailability, error) {
+	args := m.Called(ctx, screeningID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.SeatAvailability), args.Error(1)
 }
 
-func (m *MockSeatRepository) UpdateSeatAvailability(ctx context.Context, seatID int, date time.Time, timeStr string, isAvailable bool) error {
-	args := m.Called(ctx, seatID, date, timeStr, isAvailable)
+func (m *MockSeatRepository) UpdateSeatAvailability(ctx context.Context, screeningID, seatID int, isAvailable bool) error {
+	args := m.Called(ctx, screeningID, seatID, isAvailable)
+	return args.Error(0)
+}
+
+func (m *MockSeatRepository) GetSeatMap(ctx context.Context, auditoriumID int) ([]models.SeatMapRowLayout, error) {
+	args := m.Called(ctx, auditoriumID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SeatMapRowLayout), args.Error(1)
+}
+
+func (m *MockSeatRepository) SaveSeatMap(ctx context.Context, auditoriumID int, layout []models.SeatMapRowLayout, seats []*models.Seat) error {
+	args := m.Called(ctx, auditoriumID, layout, seats)
 	return args.Error(0)
 }
 
@@ -125,37 +193,49 @@ func (m *MockCinemaRepository) CreateCinema(ctx context.Context, cinema *models.
 	return args.Error(0)
 }
 
-// TestCreateBooking_Success tests successful booking creation
+// upcomingScreening returns a screening that starts tomorrow in auditorium 4 of cinema 1
+func upcomingScreening() *models.Screening {
+	return &models.Screening{
+		ID:           3,
+		CinemaID:     1,
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartTime:    time.Now().Add(24 * time.Hour),
+		Movie:        &models.Movie{ID: 2, Title: "Pengabdi Setan"},
+	}
+}
+
+// testHoldTTL and testCancelCutoff are the booking policy used by booking service tests
+const (
+	testHoldTTL      = 10 * time.Minute
+	testCancelCutoff = 2 * time.Hour
+)
+
+// TestCreateBooking_Success tests successful booking creation for several seats
 func TestCreateBooking_Success(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	userID := 1
 	req := &models.BookingRequest{
-		CinemaID:      1,
-		SeatID:        1,
-		Date:          "2026-01-15",
-		Time:          "19:00",
+		ScreeningID:   3,
+		SeatIDs:       []int{1, 2},
 		PaymentMethod: "credit_card",
 	}
 
-	showDate, _ := time.Parse("2006-01-02", req.Date)
+	screening := upcomingScreening()
 
-	seat := &models.Seat{ID: 1, CinemaID: 1, SeatNumber: "A1", Price: 50000}
-	cinema := &models.Cinema{ID: 1, Name: "Cinema XXI", City: "Jakarta"}
-
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(seat, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(cinema, nil)
-	mockBookingRepo.On("CheckSeatBooked", mock.Anything, 1, showDate, "19:00").Return(false, nil)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(screening, nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, SeatNumber: "A1", IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 2).Return(&models.Seat{ID: 2, AuditoriumID: 4, SeatNumber: "E1", IsActive: true, Price: models.MustParseMoney("100000")}, nil)
 	mockBookingRepo.On("CreateBooking", mock.Anything, mock.AnythingOfType("*models.Booking")).Run(func(args mock.Arguments) {
 		b := args.Get(1).(*models.Booking)
 		b.ID = 1
 		b.CreatedAt = time.Now()
 	}).Return(nil)
-	mockSeatRepo.On("UpdateSeatAvailability", mock.Anything, 1, showDate, "19:00", false).Return(nil)
 
 	// Act
 	response, err := service.CreateBooking(context.Background(), userID, req)
@@ -164,28 +244,42 @@ func TestCreateBooking_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, response)
 	assert.Equal(t, 1, response.ID)
+	assert.Equal(t, []int{1, 2}, response.SeatIDs)
+	assert.Equal(t, models.MustParseMoney("150000"), response.TotalPrice)
+	assert.Equal(t, "Pengabdi Setan", response.MovieTitle)
+	assert.Equal(t, screening.StartTime, response.StartTime)
+	assert.NotNil(t, response.HoldExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(testHoldTTL), *response.HoldExpiresAt, 5*time.Second)
+
+	booking := mockBookingRepo.Calls[0].Arguments.Get(1).(*models.Booking)
+	assert.Equal(t, response.HoldExpiresAt, booking.HoldExpiresAt)
+	_, ok := ParseReference(ReferencePrefixBooking, booking.Reference)
+	assert.True(t, ok)
+	assert.Equal(t, booking.Reference, response.Reference)
+	assert.Len(t, booking.Seats, 2)
+	assert.Equal(t, models.MustParseMoney("100000"), booking.Seats[1].Price)
 	mockBookingRepo.AssertExpectations(t)
 	mockSeatRepo.AssertExpectations(t)
-	mockCinemaRepo.AssertExpectations(t)
+	mockScreeningRepo.AssertExpectations(t)
 }
 
 func TestCreateBooking_SeatNotFound(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	userID := 1
 	req := &models.BookingRequest{
-		CinemaID:      1,
-		SeatID:        999,
-		Date:          "2026-01-15",
-		Time:          "19:00",
+		ScreeningID:   3,
+		SeatIDs:       []int{1, 999},
 		PaymentMethod: "credit_card",
 	}
 
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 999).Return(nil, errors.New("seat not found"))
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 999).Return(nil, nil)
 
 	// Act
 	response, err := service.CreateBooking(context.Background(), userID, req)
@@ -193,45 +287,39 @@ func TestCreateBooking_SeatNotFound(t *testing.T) {
 	// Assert
 	assert.Error(t, err)
 	assert.Nil(t, response)
-	assert.Contains(t, err.Error(), "seat")
+	assert.Contains(t, err.Error(), "seat 999 not found")
 	mockSeatRepo.AssertExpectations(t)
+	mockBookingRepo.AssertNotCalled(t, "CreateBooking", mock.Anything, mock.Anything)
 }
 
 func TestCreateBooking_SeatAlreadyBooked(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	userID := 1
 	req := &models.BookingRequest{
-		CinemaID:      1,
-		SeatID:        1,
-		Date:          "2026-01-15",
-		Time:          "19:00",
+		ScreeningID:   3,
+		SeatIDs:       []int{1, 2},
 		PaymentMethod: "credit_card",
 	}
 
-	seat := &models.Seat{ID: 1, CinemaID: 1, SeatNumber: "A1", Price: 50000}
-	cinema := &models.Cinema{ID: 1, Name: "Cinema XXI", City: "Jakarta"}
-
-	showDate, _ := time.Parse("2006-01-02", req.Date)
-
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(seat, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(cinema, nil)
-	mockBookingRepo.On("CheckSeatBooked", mock.Anything, 1, showDate, "19:00").Return(true, nil)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 2).Return(&models.Seat{ID: 2, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+	mockBookingRepo.On("CreateBooking", mock.Anything, mock.AnythingOfType("*models.Booking")).Return(models.ErrSeatAlreadyBooked)
 
 	// Act
 	response, err := service.CreateBooking(context.Background(), userID, req)
 
 	// Assert
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyBooked)
 	assert.Nil(t, response)
-	assert.Contains(t, err.Error(), "already booked")
 	mockBookingRepo.AssertExpectations(t)
 	mockSeatRepo.AssertExpectations(t)
-	mockCinemaRepo.AssertExpectations(t)
+	mockScreeningRepo.AssertExpectations(t)
 }
 
 // TestGetUserBookings_Success tests retrieving user bookings
@@ -239,16 +327,15 @@ func TestGetUserBookings_Success(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	userID := 1
 	page := 1
 	limit := 10
 
-	showDate := time.Now()
-	baseBookings := []*models.Booking{{ID: 1, UserID: userID, ShowDate: showDate, ShowTime: "19:00"}}
-	detailed := &models.Booking{ID: 1, UserID: userID, ShowDate: showDate, ShowTime: "19:00", Status: "confirmed"}
+	baseBookings := []*models.Booking{{ID: 1, UserID: userID, ScreeningID: 3}}
+	detailed := &models.Booking{ID: 1, UserID: userID, ScreeningID: 3, Status: "confirmed"}
 
 	mockBookingRepo.On("GetUserBookings", mock.Anything, userID, page, limit).Return(baseBookings, 1, nil)
 	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 1).Return(detailed, nil)
@@ -268,8 +355,8 @@ func TestGetUserBookings_EmptyResult(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	userID := 1
 	page := 1
@@ -293,8 +380,8 @@ func TestUpdateBookingStatus_Success(t *testing.T) {
 	// Arrange
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	bookingID := 1
 	newStatus := "confirmed"
@@ -309,98 +396,95 @@ func TestUpdateBookingStatus_Success(t *testing.T) {
 	mockBookingRepo.AssertExpectations(t)
 }
 
-func TestCreateBooking_InvalidDate(t *testing.T) {
+func TestCreateBooking_ScreeningNotFound(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
-	userID := 1
-	req := &models.BookingRequest{Date: "15-01-2026", Time: "19:00"}
+	req := &models.BookingRequest{ScreeningID: 10, SeatIDs: []int{1}}
 
-	response, err := service.CreateBooking(context.Background(), userID, req)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 10).Return(nil, nil)
+
+	resp, err := service.CreateBooking(context.Background(), 1, req)
 
 	assert.Error(t, err)
-	assert.Nil(t, response)
-	assert.Contains(t, err.Error(), "invalid date")
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "screening not found")
+	mockScreeningRepo.AssertExpectations(t)
+	mockSeatRepo.AssertNotCalled(t, "GetSeatByID", mock.Anything, mock.Anything)
 }
 
-func TestCreateBooking_CinemaNotFound(t *testing.T) {
+func TestCreateBooking_ScreeningAlreadyStarted(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
-	req := &models.BookingRequest{CinemaID: 10, SeatID: 1, Date: "2026-01-15", Time: "19:00"}
-	showDate, _ := time.Parse("2006-01-02", req.Date)
+	req := &models.BookingRequest{ScreeningID: 3, SeatIDs: []int{1}}
+	screening := upcomingScreening()
+	screening.StartTime = time.Now().Add(-10 * time.Minute)
 
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, CinemaID: 10, Price: 50000}, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 10).Return(nil, nil)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(screening, nil)
 
 	resp, err := service.CreateBooking(context.Background(), 1, req)
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
-	assert.Contains(t, err.Error(), "cinema not found")
-	mockSeatRepo.AssertExpectations(t)
-	mockCinemaRepo.AssertExpectations(t)
-
-	_ = showDate // keep showDate used to mirror other tests
+	assert.Contains(t, err.Error(), "already started")
+	mockBookingRepo.AssertNotCalled(t, "CreateBooking", mock.Anything, mock.Anything)
 }
 
-func TestCreateBooking_SeatWrongCinema(t *testing.T) {
+func TestCreateBooking_SeatWrongAuditorium(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
-	req := &models.BookingRequest{CinemaID: 2, SeatID: 1, Date: "2026-01-15", Time: "19:00"}
+	req := &models.BookingRequest{ScreeningID: 3, SeatIDs: []int{1, 2}}
 
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, CinemaID: 1, Price: 50000}, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 2).Return(&models.Cinema{ID: 2}, nil)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 2).Return(&models.Seat{ID: 2, AuditoriumID: 5, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
 
 	resp, err := service.CreateBooking(context.Background(), 1, req)
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
-	assert.Contains(t, err.Error(), "does not belong")
+	assert.Contains(t, err.Error(), "seat 2 does not belong")
 	mockSeatRepo.AssertExpectations(t)
-	mockCinemaRepo.AssertExpectations(t)
+	mockBookingRepo.AssertNotCalled(t, "CreateBooking", mock.Anything, mock.Anything)
 }
 
-func TestCreateBooking_CheckSeatBookedError(t *testing.T) {
+func TestCreateBooking_SeatRemovedFromSeatMap(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
-	req := &models.BookingRequest{CinemaID: 1, SeatID: 1, Date: "2026-01-15", Time: "19:00"}
-	showDate, _ := time.Parse("2006-01-02", req.Date)
+	req := &models.BookingRequest{ScreeningID: 3, SeatIDs: []int{1}}
 
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, CinemaID: 1, Price: 50000}, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
-	mockBookingRepo.On("CheckSeatBooked", mock.Anything, 1, showDate, "19:00").Return(false, errors.New("db error"))
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: false, Price: models.MustParseMoney("50000")}, nil)
 
 	resp, err := service.CreateBooking(context.Background(), 1, req)
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
-	assert.Contains(t, err.Error(), "failed to check seat booking")
-	mockBookingRepo.AssertExpectations(t)
+	assert.Contains(t, err.Error(), "seat 1 not found")
+	mockBookingRepo.AssertNotCalled(t, "CreateBooking", mock.Anything, mock.Anything)
 }
 
 func TestCreateBooking_CreateBookingError(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
-	req := &models.BookingRequest{CinemaID: 1, SeatID: 1, Date: "2026-01-15", Time: "19:00", PaymentMethod: "cash"}
-	showDate, _ := time.Parse("2006-01-02", req.Date)
+	req := &models.BookingRequest{ScreeningID: 3, SeatIDs: []int{1}, PaymentMethod: "cash"}
 
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, CinemaID: 1, Price: 50000}, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
-	mockBookingRepo.On("CheckSeatBooked", mock.Anything, 1, showDate, "19:00").Return(false, nil)
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
 	mockBookingRepo.On("CreateBooking", mock.Anything, mock.AnythingOfType("*models.Booking")).Return(errors.New("insert fail"))
 
 	resp, err := service.CreateBooking(context.Background(), 1, req)
@@ -411,34 +495,11 @@ func TestCreateBooking_CreateBookingError(t *testing.T) {
 	mockBookingRepo.AssertExpectations(t)
 }
 
-func TestCreateBooking_UpdateAvailabilityError(t *testing.T) {
-	mockBookingRepo := new(MockBookingRepository)
-	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
-
-	req := &models.BookingRequest{CinemaID: 1, SeatID: 1, Date: "2026-01-15", Time: "19:00", PaymentMethod: "cash"}
-	showDate, _ := time.Parse("2006-01-02", req.Date)
-
-	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, CinemaID: 1, Price: 50000}, nil)
-	mockCinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
-	mockBookingRepo.On("CheckSeatBooked", mock.Anything, 1, showDate, "19:00").Return(false, nil)
-	mockBookingRepo.On("CreateBooking", mock.Anything, mock.AnythingOfType("*models.Booking")).Return(nil)
-	mockSeatRepo.On("UpdateSeatAvailability", mock.Anything, 1, showDate, "19:00", false).Return(errors.New("update fail"))
-
-	resp, err := service.CreateBooking(context.Background(), 1, req)
-
-	assert.Error(t, err)
-	assert.Nil(t, resp)
-	assert.Contains(t, err.Error(), "update seat availability")
-	mockSeatRepo.AssertExpectations(t)
-}
-
 func TestGetUserBookings_RepoError(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	mockBookingRepo.On("GetUserBookings", mock.Anything, 1, 1, 10).Return(nil, 0, errors.New("query fail"))
 
@@ -452,8 +513,8 @@ func TestGetUserBookings_RepoError(t *testing.T) {
 func TestGetUserBookings_DetailError(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	bookings := []*models.Booking{{ID: 1}}
 	mockBookingRepo.On("GetUserBookings", mock.Anything, 1, 1, 10).Return(bookings, 1, nil)
@@ -469,8 +530,8 @@ func TestGetUserBookings_DetailError(t *testing.T) {
 func TestGetBookingByID_Success(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	booking := &models.Booking{ID: 7}
 	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
@@ -485,8 +546,8 @@ func TestGetBookingByID_Success(t *testing.T) {
 func TestGetBookingByID_Error(t *testing.T) {
 	mockBookingRepo := new(MockBookingRepository)
 	mockSeatRepo := new(MockSeatRepository)
-	mockCinemaRepo := new(MockCinemaRepository)
-	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockCinemaRepo)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(mockBookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
 
 	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(nil, errors.New("db fail"))
 
@@ -497,3 +558,239 @@ func TestGetBookingByID_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to get booking")
 	mockBookingRepo.AssertExpectations(t)
 }
+
+// lockingBookingRepo is an in-memory BookingRepository whose CreateBooking reserves
+// seats atomically, mirroring the transactional behavior of the real repository
+type lockingBookingRepo struct {
+	MockBookingRepository
+	mu     sync.Mutex
+	nextID int
+	taken  map[int]bool
+}
+
+func (r *lockingBookingRepo) CreateBooking(ctx context.Context, booking *models.Booking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range booking.Seats {
+		if r.taken[item.SeatID] {
+			return models.ErrSeatAlreadyBooked
+		}
+	}
+	for _, item := range booking.Seats {
+		r.taken[item.SeatID] = true
+	}
+	r.nextID++
+	booking.ID = r.nextID
+	return nil
+}
+
+func TestCreateBooking_ConcurrentRequestsForOneSeat(t *testing.T) {
+	bookingRepo := &lockingBookingRepo{taken: map[int]bool{}}
+	mockSeatRepo := new(MockSeatRepository)
+	mockScreeningRepo := new(MockScreeningRepository)
+	service := NewBookingService(bookingRepo, mockSeatRepo, mockScreeningRepo, nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	mockScreeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(upcomingScreening(), nil)
+	mockSeatRepo.On("GetSeatByID", mock.Anything, 1).Return(&models.Seat{ID: 1, AuditoriumID: 4, IsActive: true, Price: models.MustParseMoney("50000")}, nil)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	var succeeded, rejected int32
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			req := &models.BookingRequest{ScreeningID: 3, SeatIDs: []int{1}, PaymentMethod: "cash"}
+			_, err := service.CreateBooking(context.Background(), userID, req)
+			if err == nil {
+				atomic.AddInt32(&succeeded, 1)
+			} else if errors.Is(err, models.ErrSeatAlreadyBooked) {
+				atomic.AddInt32(&rejected, 1)
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded)
+	assert.Equal(t, int32(workers-1), rejected)
+}
+
+func TestCancelBooking_PaidBookingIsRefundedAfterCommit(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	refunder := new(MockRefunder)
+	uow := &recordingUnitOfWork{}
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), refunder, uow, testHoldTTL, testCancelCutoff)
+
+	screening := upcomingScreening()
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "confirmed", PaymentStatus: "paid", Screening: screening}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+	mockBookingRepo.On("CancelBooking", inTx, 7).Return(nil)
+	mockBookingRepo.On("UpdateBookingPaymentStatus", inTx, 7, "refund_pending").Return(nil)
+	refunder.On("RefundPercent", screening.StartTime).Return(100)
+	refunder.On("RefundBooking", outsideTx, 7, 100).Return(&models.Refund{ID: 1, PaymentStatus: "refunded"}, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+	assert.False(t, uow.rolledBack)
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, "refunded", result.PaymentStatus)
+	mockBookingRepo.AssertExpectations(t)
+	refunder.AssertExpectations(t)
+}
+
+func TestCancelBooking_RefundFailureLeavesRefundPending(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	refunder := new(MockRefunder)
+	uow := &recordingUnitOfWork{}
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), refunder, uow, testHoldTTL, testCancelCutoff)
+
+	screening := upcomingScreening()
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "confirmed", PaymentStatus: "paid", Screening: screening}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+	mockBookingRepo.On("CancelBooking", inTx, 7).Return(nil)
+	mockBookingRepo.On("UpdateBookingPaymentStatus", inTx, 7, "refund_pending").Return(nil)
+	refunder.On("RefundPercent", screening.StartTime).Return(50)
+	refunder.On("RefundBooking", outsideTx, 7, 50).Return(nil, errors.New("gateway unavailable"))
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+	assert.False(t, uow.rolledBack)
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, "refund_pending", result.PaymentStatus)
+	mockBookingRepo.AssertExpectations(t)
+	refunder.AssertExpectations(t)
+}
+
+func TestCancelBooking_FailedCancellationIsNotRefunded(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	refunder := new(MockRefunder)
+	uow := &recordingUnitOfWork{}
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), refunder, uow, testHoldTTL, testCancelCutoff)
+
+	screening := upcomingScreening()
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "confirmed", PaymentStatus: "paid", Screening: screening}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+	mockBookingRepo.On("CancelBooking", inTx, 7).Return(nil)
+	mockBookingRepo.On("UpdateBookingPaymentStatus", inTx, 7, "refund_pending").Return(errors.New("connection reset"))
+	refunder.On("RefundPercent", screening.StartTime).Return(100)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.True(t, uow.rolledBack)
+	assert.Equal(t, "confirmed", booking.Status)
+	refunder.AssertNotCalled(t, "RefundBooking", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_PendingBookingIsNotRefunded(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	refunder := new(MockRefunder)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), refunder, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "pending", PaymentStatus: "pending", Screening: upcomingScreening()}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+	mockBookingRepo.On("CancelBooking", mock.Anything, 7).Return(nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, "pending", result.PaymentStatus)
+	mockBookingRepo.AssertNotCalled(t, "UpdateBookingPaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+	refunder.AssertNotCalled(t, "RefundBooking", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_NoRefundMarksPaymentNonRefundable(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	refunder := new(MockRefunder)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), refunder, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	screening := upcomingScreening()
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "confirmed", PaymentStatus: "paid", Screening: screening}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+	mockBookingRepo.On("CancelBooking", mock.Anything, 7).Return(nil)
+	mockBookingRepo.On("UpdateBookingPaymentStatus", mock.Anything, 7, "non_refundable").Return(nil)
+	refunder.On("RefundPercent", screening.StartTime).Return(0)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, "non_refundable", result.PaymentStatus)
+	mockBookingRepo.AssertExpectations(t)
+	refunder.AssertNotCalled(t, "RefundBooking", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_NotOwner(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	booking := &models.Booking{ID: 7, UserID: 2, Status: "pending", Screening: upcomingScreening()}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.ErrorIs(t, err, models.ErrBookingNotOwned)
+	assert.Nil(t, result)
+	mockBookingRepo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_PastCutoff(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	screening := upcomingScreening()
+	screening.StartTime = time.Now().Add(time.Hour)
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "confirmed", Screening: screening}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.ErrorIs(t, err, models.ErrCancellationWindowClosed)
+	assert.Nil(t, result)
+	mockBookingRepo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_AlreadyCancelled(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "cancelled", Screening: upcomingScreening()}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.ErrorIs(t, err, models.ErrBookingNotCancellable)
+	assert.Nil(t, result)
+}
+
+func TestCancelBooking_PaymentInProgress(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	booking := &models.Booking{ID: 7, UserID: 1, Status: "pending", PaymentStatus: "processing", Screening: upcomingScreening()}
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(booking, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 7)
+
+	assert.ErrorIs(t, err, models.ErrBookingPaymentInProgress)
+	assert.Nil(t, result)
+	mockBookingRepo.AssertNotCalled(t, "CancelBooking", mock.Anything, mock.Anything)
+}
+
+func TestCancelBooking_NotFound(t *testing.T) {
+	mockBookingRepo := new(MockBookingRepository)
+	service := NewBookingService(mockBookingRepo, new(MockSeatRepository), new(MockScreeningRepository), nil, inlineUnitOfWork{}, testHoldTTL, testCancelCutoff)
+
+	mockBookingRepo.On("GetBookingWithDetails", mock.Anything, 99).Return(nil, nil)
+
+	result, err := service.CancelBooking(context.Background(), 1, 99)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 )
 
 // CinemaService handles cinema-related business logic
@@ -19,6 +21,9 @@ func NewCinemaService(cinemaRepo CinemaRepository) *CinemaService {
 
 // GetAllCinemas retrieves all cinemas with pagination
 func (s *CinemaService) GetAllCinemas(ctx context.Context, page, limit int, filters *models.CinemaFilters) (*models.PaginatedResponse, error) {
+	ctx, span := tracing.Start(ctx, "CinemaService.GetAllCinemas")
+	defer span.End()
+
 	if page < 1 {
 		page = 1
 	}
@@ -44,9 +49,110 @@ func (s *CinemaService) GetAllCinemas(ctx context.Context, page, limit int, filt
 
 // GetCinemaByID retrieves a cinema by ID
 func (s *CinemaService) GetCinemaByID(ctx context.Context, id int) (*models.Cinema, error) {
+	ctx, span := tracing.Start(ctx, "CinemaService.GetCinemaByID")
+	defer span.End()
+
 	cinema, err := s.cinemaRepo.GetCinemaByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cinema: %w", err)
 	}
 	return cinema, nil
 }
+
+// CreateCinema creates a new cinema
+func (s *CinemaService) CreateCinema(ctx context.Context, req *models.CinemaRequest) (*models.Cinema, error) {
+	ctx, span := tracing.Start(ctx, "CinemaService.CreateCinema")
+	defer span.End()
+
+	cinema := &models.Cinema{
+		Name:     req.Name,
+		Location: req.Location,
+		City:     req.City,
+		Address:  req.Address,
+		ImageURL: req.ImageURL,
+	}
+
+	err := s.cinemaRepo.CreateCinema(ctx, cinema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cinema: %w", err)
+	}
+	return cinema, nil
+}
+
+// UpdateCinema replaces the details of a cinema. It returns nil, nil if the cinema does
+// not exist or is archived.
+func (s *CinemaService) UpdateCinema(ctx context.Context, id int, req *models.CinemaRequest) (*models.Cinema, error) {
+	ctx, span := tracing.Start(ctx, "CinemaService.UpdateCinema")
+	defer span.End()
+
+	return s.saveCinema(ctx, id, func(cinema *models.Cinema) {
+		cinema.Name = req.Name
+		cinema.Location = req.Location
+		cinema.City = req.City
+		cinema.Address = req.Address
+		cinema.ImageURL = req.ImageURL
+	})
+}
+
+// PatchCinema updates the fields of a cinema present in req. It returns nil, nil if the
+// cinema does not exist or is archived.
+func (s *CinemaService) PatchCinema(ctx context.Context, id int, req *models.CinemaPatchRequest) (*models.Cinema, error) {
+	ctx, span := tracing.Start(ctx, "CinemaService.PatchCinema")
+	defer span.End()
+
+	return s.saveCinema(ctx, id, func(cinema *models.Cinema) {
+		if req.Name != nil {
+			cinema.Name = *req.Name
+		}
+		if req.Location != nil {
+			cinema.Location = *req.Location
+		}
+		if req.City != nil {
+			cinema.City = *req.City
+		}
+		if req.Address != nil {
+			cinema.Address = *req.Address
+		}
+		if req.ImageURL != nil {
+			cinema.ImageURL = *req.ImageURL
+		}
+	})
+}
+
+// saveCinema applies update to an active cinema and stores it
+func (s *CinemaService) saveCinema(ctx context.Context, id int, update func(cinema *models.Cinema)) (*models.Cinema, error) {
+	cinema, err := s.cinemaRepo.GetCinemaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cinema: %w", err)
+	}
+	if cinema == nil || cinema.ArchivedAt != nil {
+		return nil, nil
+	}
+
+	update(cinema)
+
+	err = s.cinemaRepo.UpdateCinema(ctx, cinema)
+	if err != nil {
+		if errors.Is(err, models.ErrCinemaNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update cinema: %w", err)
+	}
+	return cinema, nil
+}
+
+// DeleteCinema archives a cinema. Archived cinemas are no longer listed but stay linked
+// to their bookings. A cinema with paid bookings for upcoming screenings cannot be deleted.
+func (s *CinemaService) DeleteCinema(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "CinemaService.DeleteCinema")
+	defer span.End()
+
+	err := s.cinemaRepo.ArchiveCinema(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrCinemaNotFound) || errors.Is(err, models.ErrCinemaHasFutureBookings) {
+			return err
+		}
+		return fmt.Errorf("failed to delete cinema: %w", err)
+	}
+	return nil
+}
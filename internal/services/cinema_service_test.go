@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,21 @@ func (m *MockCinemaRepo) GetCinemaByID(ctx context.Context, id int) (*models.Cin
 	return args.Get(0).(*models.Cinema), args.Error(1)
 }
 
+func (m *MockCinemaRepo) CreateCinema(ctx context.Context, cinema *models.Cinema) error {
+	args := m.Called(ctx, cinema)
+	return args.Error(0)
+}
+
+func (m *MockCinemaRepo) UpdateCinema(ctx context.Context, cinema *models.Cinema) error {
+	args := m.Called(ctx, cinema)
+	return args.Error(0)
+}
+
+func (m *MockCinemaRepo) ArchiveCinema(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func TestGetAllCinemas_Success(t *testing.T) {
 	repo := new(MockCinemaRepo)
 	service := NewCinemaService(repo)
@@ -87,3 +103,62 @@ func TestGetCinemaByID_Error(t *testing.T) {
 	assert.Nil(t, result)
 	repo.AssertExpectations(t)
 }
+
+func TestCreateCinema_Success(t *testing.T) {
+	repo := new(MockCinemaRepo)
+	service := NewCinemaService(repo)
+
+	repo.On("CreateCinema", mock.Anything, mock.AnythingOfType("*models.Cinema")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Cinema).ID = 6
+	}).Return(nil)
+
+	cinema, err := service.CreateCinema(context.Background(), &models.CinemaRequest{Name: "CGV Bekasi", Location: "Summarecon Mall", City: "Bekasi", Address: "Jl. Boulevard Ahmad Yani"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, cinema.ID)
+	assert.Equal(t, "Bekasi", cinema.City)
+	repo.AssertExpectations(t)
+}
+
+func TestPatchCinema_OnlyChangesGivenFields(t *testing.T) {
+	repo := new(MockCinemaRepo)
+	service := NewCinemaService(repo)
+
+	existing := &models.Cinema{ID: 1, Name: "CGV Blok M", Location: "Blok M Plaza", City: "Jakarta", Address: "Jl. Melawai No. 1"}
+	repo.On("GetCinemaByID", mock.Anything, 1).Return(existing, nil)
+	repo.On("UpdateCinema", mock.Anything, existing).Return(nil)
+
+	name := "CGV Blok M Square"
+	cinema, err := service.PatchCinema(context.Background(), 1, &models.CinemaPatchRequest{Name: &name})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "CGV Blok M Square", cinema.Name)
+	assert.Equal(t, "Blok M Plaza", cinema.Location)
+	assert.Equal(t, "Jl. Melawai No. 1", cinema.Address)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCinema_Archived(t *testing.T) {
+	repo := new(MockCinemaRepo)
+	service := NewCinemaService(repo)
+
+	archivedAt := time.Now().Add(-time.Hour)
+	repo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1, ArchivedAt: &archivedAt}, nil)
+
+	cinema, err := service.UpdateCinema(context.Background(), 1, &models.CinemaRequest{Name: "New", Location: "L", City: "C", Address: "A"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, cinema)
+	repo.AssertNotCalled(t, "UpdateCinema", mock.Anything, mock.Anything)
+}
+
+func TestDeleteCinema_HasFutureBookings(t *testing.T) {
+	repo := new(MockCinemaRepo)
+	service := NewCinemaService(repo)
+
+	repo.On("ArchiveCinema", mock.Anything, 1).Return(models.ErrCinemaHasFutureBookings)
+
+	err := service.DeleteCinema(context.Background(), 1)
+
+	assert.ErrorIs(t, err, models.ErrCinemaHasFutureBookings)
+}
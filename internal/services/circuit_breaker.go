@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // calls go through
+	BreakerOpen     = "open"      // calls fail fast without reaching the gateway
+	BreakerHalfOpen = "half_open" // one trial call goes through to see if the gateway recovered
+)
+
+// CircuitBreakerGateway stops calling a failing payment gateway. After threshold
+// consecutive calls fail with an error, rather than a decline, every call fails fast
+// with ErrGatewayUnavailable for cooldown; then one trial call decides whether the
+// breaker closes again or stays open for another cooldown.
+type CircuitBreakerGateway struct {
+	gateway   PaymentGateway
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreakerGateway wraps gateway in a circuit breaker
+func NewCircuitBreakerGateway(gateway PaymentGateway, threshold int, cooldown time.Duration) *CircuitBreakerGateway {
+	return &CircuitBreakerGateway{
+		gateway:   gateway,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// Authorize reserves the amount of a charge
+func (b *CircuitBreakerGateway) Authorize(ctx context.Context, req *GatewayRequest) (*GatewayResult, error) {
+	return b.call(func() (*GatewayResult, error) { return b.gateway.Authorize(ctx, req) })
+}
+
+// Capture collects an authorized charge
+func (b *CircuitBreakerGateway) Capture(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error) {
+	return b.call(func() (*GatewayResult, error) { return b.gateway.Capture(ctx, transactionID, amount) })
+}
+
+// Void releases an authorized charge that was not captured
+func (b *CircuitBreakerGateway) Void(ctx context.Context, transactionID string) (*GatewayResult, error) {
+	return b.call(func() (*GatewayResult, error) { return b.gateway.Void(ctx, transactionID) })
+}
+
+// Refund returns money of a captured charge
+func (b *CircuitBreakerGateway) Refund(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error) {
+	return b.call(func() (*GatewayResult, error) { return b.gateway.Refund(ctx, transactionID, amount) })
+}
+
+// QueryStatus returns the last known state of a transaction
+func (b *CircuitBreakerGateway) QueryStatus(ctx context.Context, transactionID string) (*GatewayResult, error) {
+	return b.call(func() (*GatewayResult, error) { return b.gateway.QueryStatus(ctx, transactionID) })
+}
+
+// State returns the breaker's state and how many calls in a row have failed
+func (b *CircuitBreakerGateway) State() (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(), b.failures
+}
+
+// Health reports the breaker's state; the gateway counts as down while the breaker is open
+func (b *CircuitBreakerGateway) Health(ctx context.Context) (map[string]any, error) {
+	state, failures := b.State()
+	details := map[string]any{"circuit_breaker": state, "consecutive_failures": failures}
+	if state == BreakerOpen {
+		return details, errors.New("circuit breaker is open")
+	}
+	return details, nil
+}
+
+// call runs fn unless the breaker is open and records its outcome
+func (b *CircuitBreakerGateway) call(fn func() (*GatewayResult, error)) (*GatewayResult, error) {
+	b.mu.Lock()
+	state := b.currentState()
+	if state == BreakerOpen {
+		b.mu.Unlock()
+		return nil, models.ErrGatewayUnavailable
+	}
+	if state == BreakerHalfOpen {
+		// Let this call be the only trial: calls made until it answers fail fast
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.mu.Unlock()
+
+	result, err := fn()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.failures++
+		if state == BreakerHalfOpen || b.failures >= b.threshold {
+			b.state = BreakerOpen
+			b.openedAt = b.now()
+		}
+		return nil, err
+	}
+	b.failures = 0
+	b.state = BreakerClosed
+	return result, nil
+}
+
+// currentState returns the state, turning an open breaker half open once its cooldown passed
+func (b *CircuitBreakerGateway) currentState() string {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
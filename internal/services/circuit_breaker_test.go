@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBreaker returns a breaker around a simulator in mode, with a clock the test moves by hand
+func newTestBreaker(t *testing.T, mode string) (*CircuitBreakerGateway, *SimulatorGateway, *time.Time) {
+	simulator, err := NewSimulatorGateway(mode)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreakerGateway(simulator, 3, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+	return breaker, simulator, &now
+}
+
+func authorize(breaker *CircuitBreakerGateway) error {
+	_, err := breaker.Authorize(context.Background(), &GatewayRequest{
+		Reference:     "BK-1",
+		Amount:        models.NewMoney(50000),
+		PaymentMethod: "credit_card",
+	})
+	return err
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	breaker, _, _ := newTestBreaker(t, SimulatorTimeout)
+
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, authorize(breaker), models.ErrGatewayTimeout)
+	}
+	state, failures := breaker.State()
+	assert.Equal(t, BreakerClosed, state)
+	assert.Equal(t, 2, failures)
+
+	assert.ErrorIs(t, authorize(breaker), models.ErrGatewayTimeout)
+	state, _ = breaker.State()
+	assert.Equal(t, BreakerOpen, state)
+
+	// Calls fail fast without reaching the gateway
+	assert.ErrorIs(t, authorize(breaker), models.ErrGatewayUnavailable)
+
+	_, err := breaker.Health(context.Background())
+	assert.Error(t, err)
+}
+
+func TestCircuitBreaker_DeclineDoesNotCountAsFailure(t *testing.T) {
+	breaker, _, _ := newTestBreaker(t, SimulatorDecline)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, authorize(breaker))
+	}
+	state, failures := breaker.State()
+	assert.Equal(t, BreakerClosed, state)
+	assert.Equal(t, 0, failures)
+}
+
+func TestCircuitBreaker_ClosesAfterSuccessfulTrial(t *testing.T) {
+	breaker, simulator, now := newTestBreaker(t, SimulatorTimeout)
+	for i := 0; i < 3; i++ {
+		authorize(breaker)
+	}
+
+	*now = now.Add(30 * time.Second)
+	state, _ := breaker.State()
+	assert.Equal(t, BreakerHalfOpen, state)
+
+	simulator.mode = SimulatorApprove
+	assert.NoError(t, authorize(breaker))
+
+	state, failures := breaker.State()
+	assert.Equal(t, BreakerClosed, state)
+	assert.Equal(t, 0, failures)
+
+	details, err := breaker.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, details["circuit_breaker"])
+}
+
+func TestCircuitBreaker_ReopensAfterFailedTrial(t *testing.T) {
+	breaker, _, now := newTestBreaker(t, SimulatorTimeout)
+	for i := 0; i < 3; i++ {
+		authorize(breaker)
+	}
+
+	*now = now.Add(30 * time.Second)
+	assert.ErrorIs(t, authorize(breaker), models.ErrGatewayTimeout)
+
+	state, _ := breaker.State()
+	assert.Equal(t, BreakerOpen, state)
+	assert.ErrorIs(t, authorize(breaker), models.ErrGatewayUnavailable)
+}
@@ -9,10 +9,13 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 	"github.com/andre/project-app-bioskop-golang/internal/repositories"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -22,15 +25,23 @@ type EmailService struct {
 	logger    *zap.Logger
 	apiURL    string
 	apiKey    string
+	resetURL  string // page that takes password reset codes from a link; empty sends codes only
+
+	mu            sync.Mutex // guards the outcome of the latest email API calls
+	lastSentAt    time.Time
+	lastFailedAt  time.Time
+	lastSendError string
 }
 
-// NewEmailService creates a new email service
-func NewEmailService(emailRepo *repositories.EmailVerificationRepository, logger *zap.Logger, apiURL, apiKey string) *EmailService {
+// NewEmailService creates a new email service. When resetURL is set, password reset
+// emails link to it with the email and code in the query string.
+func NewEmailService(emailRepo *repositories.EmailVerificationRepository, logger *zap.Logger, apiURL, apiKey, resetURL string) *EmailService {
 	return &EmailService{
 		emailRepo: emailRepo,
 		logger:    logger,
 		apiURL:    apiURL,
 		apiKey:    apiKey,
+		resetURL:  resetURL,
 	}
 }
 
@@ -49,6 +60,9 @@ func (s *EmailService) GenerateOTP() (string, error) {
 
 // SendOTP generates and sends OTP to user's email
 func (s *EmailService) SendOTP(ctx context.Context, userID int, email, username string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendOTP")
+	defer span.End()
+
 	// Generate OTP
 	otpCode, err := s.GenerateOTP()
 	if err != nil {
@@ -60,6 +74,7 @@ func (s *EmailService) SendOTP(ctx context.Context, userID int, email, username
 	verification := &models.EmailVerification{
 		UserID:    userID,
 		Email:     email,
+		Purpose:   models.OTPPurposeEmailVerification,
 		OTPCode:   otpCode,
 		ExpiresAt: time.Now().Add(5 * time.Minute),
 	}
@@ -72,7 +87,8 @@ func (s *EmailService) SendOTP(ctx context.Context, userID int, email, username
 
 	// Send email asynchronously (non-blocking)
 	go func() {
-		err := s.sendEmailViaAPI(email, username, otpCode)
+		err := s.sendEmailViaAPI(email, username, verificationSubject, verificationText(username, otpCode))
+		s.recordDelivery(err)
 		if err != nil {
 			s.logger.Error("Failed to send email", zap.Error(err), zap.String("email", email))
 		} else {
@@ -83,9 +99,66 @@ func (s *EmailService) SendOTP(ctx context.Context, userID int, email, username
 	return nil
 }
 
-// sendEmailViaAPI sends email via Lumoshive Email API
-func (s *EmailService) sendEmailViaAPI(toEmail, name, otpCode string) error {
-	emailBody := fmt.Sprintf(`
+// recordDelivery notes the outcome of an email API call for metrics and health checks
+func (s *EmailService) recordDelivery(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		otpEmails.Inc("failed")
+		s.lastFailedAt = time.Now()
+		s.lastSendError = err.Error()
+		return
+	}
+	otpEmails.Inc("sent")
+	s.lastSentAt = time.Now()
+}
+
+// Health reports the outcome of the latest email API calls; the API counts as down
+// while the latest call failed. The API is not called, so checking costs nothing.
+func (s *EmailService) Health(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	details := map[string]any{}
+	if !s.lastSentAt.IsZero() {
+		details["last_sent_at"] = s.lastSentAt
+	}
+	if !s.lastFailedAt.IsZero() {
+		details["last_failed_at"] = s.lastFailedAt
+	}
+	if s.lastFailedAt.After(s.lastSentAt) {
+		return details, fmt.Errorf("latest email failed: %s", s.lastSendError)
+	}
+	return details, nil
+}
+
+// SendPasswordReset emails a password reset code, with a link to the reset page when one is configured
+func (s *EmailService) SendPasswordReset(email, username, otpCode string, validFor time.Duration) {
+	link := ""
+	if s.resetURL != "" {
+		link = s.resetURL + "?" + url.Values{"email": {email}, "code": {otpCode}}.Encode()
+	}
+
+	// Send email asynchronously (non-blocking)
+	go func() {
+		err := s.sendEmailViaAPI(email, username, passwordResetSubject, passwordResetText(username, otpCode, link, validFor))
+		s.recordDelivery(err)
+		if err != nil {
+			s.logger.Error("Failed to send password reset email", zap.Error(err), zap.String("email", email))
+		} else {
+			s.logger.Info("Password reset email sent successfully", zap.String("email", email))
+		}
+	}()
+}
+
+const (
+	verificationSubject  = "Cinema Booking System - Kode OTP Verifikasi Email"
+	passwordResetSubject = "Cinema Booking System - Kode Reset Password"
+)
+
+// verificationText is the body of an email verification OTP email
+func verificationText(name, otpCode string) string {
+	return fmt.Sprintf(`
 Halo %s,
 
 Kode OTP untuk verifikasi email Anda adalah:
@@ -99,12 +172,36 @@ Jika Anda tidak merasa melakukan registrasi, abaikan email ini.
 Terima kasih,
 Cinema Booking System Team
 `, name, otpCode)
+}
+
+// passwordResetText is the body of a password reset email
+func passwordResetText(name, otpCode, link string, validFor time.Duration) string {
+	if link != "" {
+		link = fmt.Sprintf("\nAtau buka tautan berikut untuk membuat password baru:\n\n%s\n", link)
+	}
+	return fmt.Sprintf(`
+Halo %s,
+
+Kode OTP untuk reset password Anda adalah:
+
+%s
+%s
+Kode ini berlaku selama %d menit. Setelah password diganti, semua sesi login Anda akan diakhiri.
+
+Jika Anda tidak meminta reset password, abaikan email ini; password Anda tidak berubah.
+
+Terima kasih,
+Cinema Booking System Team
+`, name, otpCode, link, int(validFor.Minutes()))
+}
 
+// sendEmailViaAPI sends email via Lumoshive Email API
+func (s *EmailService) sendEmailViaAPI(toEmail, name, subject, text string) error {
 	reqBody := map[string]string{
 		"to":      toEmail,
 		"name":    name,
-		"subject": "Cinema Booking System - Kode OTP Verifikasi Email",
-		"text":    emailBody,
+		"subject": subject,
+		"text":    text,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -136,8 +233,11 @@ Cinema Booking System Team
 
 // VerifyOTP verifies the OTP code provided by user
 func (s *EmailService) VerifyOTP(ctx context.Context, email, otpCode string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.VerifyOTP")
+	defer span.End()
+
 	// Get latest verification record
-	verification, err := s.emailRepo.GetByEmail(ctx, email)
+	verification, err := s.emailRepo.GetByEmail(ctx, email, models.OTPPurposeEmailVerification)
 	if err != nil {
 		s.logger.Error("Failed to get verification", zap.Error(err))
 		return errors.New("verification record not found")
@@ -182,8 +282,11 @@ func (s *EmailService) VerifyOTP(ctx context.Context, email, otpCode string) err
 
 // ResendOTP resends OTP to user's email
 func (s *EmailService) ResendOTP(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.ResendOTP")
+	defer span.End()
+
 	// Get user's latest verification
-	verification, err := s.emailRepo.GetByEmail(ctx, email)
+	verification, err := s.emailRepo.GetByEmail(ctx, email, models.OTPPurposeEmailVerification)
 	if err != nil || verification == nil {
 		return errors.New("no registration found for this email")
 	}
@@ -207,6 +310,7 @@ func (s *EmailService) ResendOTP(ctx context.Context, email string) error {
 	newVerification := &models.EmailVerification{
 		UserID:    verification.UserID,
 		Email:     email,
+		Purpose:   models.OTPPurposeEmailVerification,
 		OTPCode:   otpCode,
 		ExpiresAt: time.Now().Add(5 * time.Minute),
 	}
@@ -221,7 +325,8 @@ func (s *EmailService) ResendOTP(ctx context.Context, email string) error {
 	go func() {
 		// Get username from verification (we need to store it or fetch from users table)
 		// For now, use email as name
-		err := s.sendEmailViaAPI(email, email, otpCode)
+		err := s.sendEmailViaAPI(email, email, verificationSubject, verificationText(email, otpCode))
+		s.recordDelivery(err)
 		if err != nil {
 			s.logger.Error("Failed to resend email", zap.Error(err))
 		}
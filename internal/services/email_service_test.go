@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 )
 
 func TestEmailService_ValidateEmailFormat(t *testing.T) {
@@ -129,3 +131,29 @@ func TestEmailVerification_GetNonExistentCode(t *testing.T) {
 	assert.Equal(t, 0, userID)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestEmailService_Health(t *testing.T) {
+	service := NewEmailService(nil, zap.NewNop(), "http://localhost", "key", "")
+
+	_, err := service.Health(context.Background())
+	assert.NoError(t, err)
+
+	service.recordDelivery(errors.New("status 500"))
+	details, err := service.Health(context.Background())
+	assert.EqualError(t, err, "latest email failed: status 500")
+	assert.Contains(t, details, "last_failed_at")
+
+	service.recordDelivery(nil)
+	_, err = service.Health(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestPasswordResetText_Link(t *testing.T) {
+	text := passwordResetText("john", "123456", "https://cinema.example/reset?code=123456&email=john%40example.com", 15*time.Minute)
+
+	assert.Contains(t, text, "123456")
+	assert.Contains(t, text, "https://cinema.example/reset?code=123456&email=john%40example.com")
+	assert.Contains(t, text, "15 menit")
+
+	assert.NotContains(t, passwordResetText("john", "123456", "", 15*time.Minute), "tautan")
+}
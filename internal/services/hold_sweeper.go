@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"go.uber.org/zap"
+)
+
+// HoldSweeper periodically releases the seats of pending bookings whose hold has expired
+type HoldSweeper struct {
+	holdRepo BookingHoldRepository
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewHoldSweeper creates a new HoldSweeper that runs every interval
+func NewHoldSweeper(holdRepo BookingHoldRepository, logger *zap.Logger, interval time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		holdRepo: holdRepo,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Start runs the sweeper in a goroutine until ctx is cancelled
+func (s *HoldSweeper) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.logger.Info("Hold sweeper stopped")
+				return
+			case <-ticker.C:
+				s.Sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Sweep expires every overdue hold once and returns how many bookings were expired
+func (s *HoldSweeper) Sweep(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "HoldSweeper.Sweep")
+	defer span.End()
+
+	expired, err := s.holdRepo.ExpireHolds(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to expire seat holds", zap.Error(err))
+		return 0
+	}
+	if expired > 0 {
+		seatHoldsExpired.Add(float64(expired))
+		s.logger.Info("Expired seat holds", zap.Int("bookings", expired))
+	}
+	return expired
+}
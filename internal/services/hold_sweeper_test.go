@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockBookingHoldRepository is a mock implementation of BookingHoldRepository
+type MockBookingHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockBookingHoldRepository) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestHoldSweeper_Sweep(t *testing.T) {
+	holdRepo := new(MockBookingHoldRepository)
+	sweeper := NewHoldSweeper(holdRepo, zap.NewNop(), time.Minute)
+
+	holdRepo.On("ExpireHolds", mock.Anything, mock.AnythingOfType("time.Time")).Return(3, nil)
+
+	assert.Equal(t, 3, sweeper.Sweep(context.Background()))
+	holdRepo.AssertExpectations(t)
+}
+
+func TestHoldSweeper_SweepError(t *testing.T) {
+	holdRepo := new(MockBookingHoldRepository)
+	sweeper := NewHoldSweeper(holdRepo, zap.NewNop(), time.Minute)
+
+	holdRepo.On("ExpireHolds", mock.Anything, mock.AnythingOfType("time.Time")).Return(0, errors.New("db error"))
+
+	assert.Equal(t, 0, sweeper.Sweep(context.Background()))
+	holdRepo.AssertExpectations(t)
+}
+
+func TestHoldSweeper_StartStopsOnCancel(t *testing.T) {
+	holdRepo := new(MockBookingHoldRepository)
+	sweeper := NewHoldSweeper(holdRepo, zap.NewNop(), 10*time.Millisecond)
+
+	swept := make(chan struct{}, 1)
+	holdRepo.On("ExpireHolds", mock.Anything, mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		select {
+		case swept <- struct{}{}:
+		default:
+		}
+	}).Return(0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sweeper.Start(ctx)
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not run")
+	}
+	cancel()
+}
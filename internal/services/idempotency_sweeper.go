@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"go.uber.org/zap"
+)
+
+// IdempotencySweeper periodically deletes idempotency keys whose stored response has expired
+type IdempotencySweeper struct {
+	keyRepo  IdempotencyKeyRepository
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// NewIdempotencySweeper creates a new IdempotencySweeper that runs every interval
+func NewIdempotencySweeper(keyRepo IdempotencyKeyRepository, logger *zap.Logger, interval time.Duration) *IdempotencySweeper {
+	return &IdempotencySweeper{
+		keyRepo:  keyRepo,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Start runs the sweeper in a goroutine until ctx is cancelled
+func (s *IdempotencySweeper) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.logger.Info("Idempotency sweeper stopped")
+				return
+			case <-ticker.C:
+				s.Sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Sweep deletes every expired key once and returns how many keys were deleted
+func (s *IdempotencySweeper) Sweep(ctx context.Context) int {
+	ctx, span := tracing.Start(ctx, "IdempotencySweeper.Sweep")
+	defer span.End()
+
+	deleted, err := s.keyRepo.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to delete expired idempotency keys", zap.Error(err))
+		return 0
+	}
+	if deleted > 0 {
+		s.logger.Info("Deleted expired idempotency keys", zap.Int("keys", deleted))
+	}
+	return deleted
+}
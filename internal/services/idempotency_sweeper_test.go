@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockIdempotencyKeyRepository is a mock implementation of IdempotencyKeyRepository
+type MockIdempotencyKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyKeyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func TestIdempotencySweeper_Sweep(t *testing.T) {
+	keyRepo := new(MockIdempotencyKeyRepository)
+	sweeper := NewIdempotencySweeper(keyRepo, zap.NewNop(), time.Hour)
+
+	keyRepo.On("DeleteExpiredIdempotencyKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return(4, nil)
+
+	assert.Equal(t, 4, sweeper.Sweep(context.Background()))
+	keyRepo.AssertExpectations(t)
+}
+
+func TestIdempotencySweeper_SweepError(t *testing.T) {
+	keyRepo := new(MockIdempotencyKeyRepository)
+	sweeper := NewIdempotencySweeper(keyRepo, zap.NewNop(), time.Hour)
+
+	keyRepo.On("DeleteExpiredIdempotencyKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return(0, errors.New("db error"))
+
+	assert.Equal(t, 0, sweeper.Sweep(context.Background()))
+	keyRepo.AssertExpectations(t)
+}
@@ -7,28 +7,72 @@ import (
 	"github.com/andre/project-app-bioskop-golang/internal/models"
 )
 
+// UnitOfWork runs operations spanning several repositories atomically: repository calls
+// made with the context passed to fn commit together when fn returns nil or not at all.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // BookingRepository defines the data access behavior needed by booking-related services.
 type BookingRepository interface {
 	CreateBooking(ctx context.Context, booking *models.Booking) error
 	GetBookingByID(ctx context.Context, id int) (*models.Booking, error)
+	GetBookingByIDForUpdate(ctx context.Context, id int) (*models.Booking, error)
 	GetBookingWithDetails(ctx context.Context, id int) (*models.Booking, error)
 	GetUserBookings(ctx context.Context, userID, page, limit int) ([]*models.Booking, int, error)
 	UpdateBookingStatus(ctx context.Context, id int, status string) error
 	UpdateBookingPaymentStatus(ctx context.Context, id int, paymentStatus string) error
-	CheckSeatBooked(ctx context.Context, seatID int, showDate time.Time, showTime string) (bool, error)
+	CancelBooking(ctx context.Context, bookingID int) error
+}
+
+// BookingHoldRepository describes how expired seat holds are released.
+type BookingHoldRepository interface {
+	ExpireHolds(ctx context.Context, now time.Time) (int, error)
+}
+
+// IdempotencyKeyRepository describes how expired idempotency keys are cleaned up.
+type IdempotencyKeyRepository interface {
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
 }
 
 // SeatRepository describes seat persistence behaviors.
 type SeatRepository interface {
-	GetSeatAvailability(ctx context.Context, cinemaID int, date time.Time, timeStr string) ([]*models.SeatAvailability, error)
+	GetSeatAvailability(ctx context.Context, screeningID int) ([]*models.SeatAvailability, error)
 	GetSeatByID(ctx context.Context, id int) (*models.Seat, error)
-	UpdateSeatAvailability(ctx context.Context, seatID int, date time.Time, timeStr string, isAvailable bool) error
+	UpdateSeatAvailability(ctx context.Context, screeningID, seatID int, isAvailable bool) error
+	GetSeatsByAuditorium(ctx context.Context, auditoriumID int) ([]*models.Seat, error)
+	GetSeatMap(ctx context.Context, auditoriumID int) ([]models.SeatMapRowLayout, error)
+	SaveSeatMap(ctx context.Context, auditoriumID int, layout []models.SeatMapRowLayout, seats []*models.Seat) error
 }
 
 // CinemaRepository defines the storage behavior for cinemas used by services.
 type CinemaRepository interface {
 	GetAllCinemas(ctx context.Context, page, limit int, filters *models.CinemaFilters) ([]*models.Cinema, int, error)
 	GetCinemaByID(ctx context.Context, id int) (*models.Cinema, error)
+	CreateCinema(ctx context.Context, cinema *models.Cinema) error
+	UpdateCinema(ctx context.Context, cinema *models.Cinema) error
+	ArchiveCinema(ctx context.Context, id int) error
+}
+
+// AuditoriumRepository defines the storage behavior for cinema auditoriums used by services.
+type AuditoriumRepository interface {
+	GetAuditoriumsByCinema(ctx context.Context, cinemaID int) ([]*models.Auditorium, error)
+	GetAuditoriumByID(ctx context.Context, id int) (*models.Auditorium, error)
+}
+
+// MovieRepository defines the storage behavior for movies used by services.
+type MovieRepository interface {
+	GetAllMovies(ctx context.Context, page, limit int, filters *models.MovieFilters) ([]*models.Movie, int, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+}
+
+// ScreeningRepository defines the storage behavior for screenings used by services.
+type ScreeningRepository interface {
+	CreateScreening(ctx context.Context, screening *models.Screening) error
+	GetScreeningByID(ctx context.Context, id int) (*models.Screening, error)
+	GetScreeningsByCinema(ctx context.Context, cinemaID int, date time.Time) ([]*models.Screening, error)
+	GetScreeningsByAuditorium(ctx context.Context, auditoriumID int, from, to time.Time) ([]*models.Screening, error)
+	CreateScreenings(ctx context.Context, auditoriumID int, screenings []*models.Screening, cleaningTime time.Duration) error
 }
 
 // PaymentRepository describes payment persistence behaviors.
@@ -36,7 +80,16 @@ type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment *models.Payment) error
 	GetPaymentByID(ctx context.Context, id int) (*models.Payment, error)
 	GetPaymentByBookingID(ctx context.Context, bookingID int) (*models.Payment, error)
+	GetPaymentByReference(ctx context.Context, reference string) (*models.Payment, error)
 	UpdatePaymentStatus(ctx context.Context, id int, status string) error
+	UpdatePaymentResult(ctx context.Context, id int, status, transactionID string) error
 	GetPaymentMethods(ctx context.Context) ([]*models.PaymentMethod, error)
 	GetPaymentMethodByName(ctx context.Context, name string) (*models.PaymentMethod, error)
+	GetRefundedAmount(ctx context.Context, paymentID int) (models.Money, error)
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+}
+
+// PaymentWebhookRepository describes how provider callbacks are applied to payments.
+type PaymentWebhookRepository interface {
+	ApplyPaymentWebhook(ctx context.Context, event *models.PaymentWebhookEvent) (bool, error)
 }
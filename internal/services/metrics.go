@@ -0,0 +1,17 @@
+package services
+
+import "github.com/andre/project-app-bioskop-golang/internal/metrics"
+
+// Business metrics exposed on /metrics
+var (
+	bookingsCreated = metrics.NewCounter("bookings_created_total",
+		"Bookings created, each holding its seats until paid.")
+	paymentResults = metrics.NewCounter("payment_results_total",
+		"Payment results recorded from charges and provider webhooks, by status: success, failed or pending.",
+		"status")
+	otpEmails = metrics.NewCounter("otp_emails_total",
+		"OTP emails handed to the email API, by result: sent or failed.",
+		"result")
+	seatHoldsExpired = metrics.NewCounter("seat_holds_expired_total",
+		"Pending bookings expired unpaid, releasing their seats.")
+)
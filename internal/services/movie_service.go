@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+)
+
+// MovieService handles movie-related business logic
+type MovieService struct {
+	movieRepo MovieRepository
+}
+
+// NewMovieService creates a new MovieService
+func NewMovieService(movieRepo MovieRepository) *MovieService {
+	return &MovieService{movieRepo: movieRepo}
+}
+
+// GetAllMovies retrieves all movies with pagination
+func (s *MovieService) GetAllMovies(ctx context.Context, page, limit int, filters *models.MovieFilters) (*models.PaginatedResponse, error) {
+	ctx, span := tracing.Start(ctx, "MovieService.GetAllMovies")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	movies, total, err := s.movieRepo.GetAllMovies(ctx, page, limit, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movies: %w", err)
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	return &models.PaginatedResponse{
+		Data:       movies,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetMovieByID retrieves a movie by ID
+func (s *MovieService) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieService.GetMovieByID")
+	defer span.End()
+
+	movie, err := s.movieRepo.GetMovieByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+	return movie, nil
+}
+
+// isShowingOn reports whether the movie is in its theatrical run on the given date
+func isShowingOn(movie *models.Movie, date time.Time) bool {
+	day := date.Format("2006-01-02")
+	if day < movie.ReleaseDate.Format("2006-01-02") {
+		return false
+	}
+	if movie.EndDate != nil && day > movie.EndDate.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMovieRepository is a mock implementation of MovieRepository
+type MockMovieRepository struct {
+	mock.Mock
+}
+
+func (m *MockMovieRepository) GetAllMovies(ctx context.Context, page, limit int, filters *models.MovieFilters) ([]*models.Movie, int, error) {
+	args := m.Called(ctx, page, limit, filters)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.Movie), args.Int(1), args.Error(2)
+}
+
+func (m *MockMovieRepository) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Movie), args.Error(1)
+}
+
+func TestGetAllMovies_Success(t *testing.T) {
+	repo := new(MockMovieRepository)
+	service := NewMovieService(repo)
+
+	movies := []*models.Movie{{ID: 1, Title: "Agak Laen"}, {ID: 2, Title: "Dilan 1990"}}
+	filters := &models.MovieFilters{NowShowing: true}
+	repo.On("GetAllMovies", mock.Anything, 1, 10, filters).Return(movies, 2, nil)
+
+	resp, err := service.GetAllMovies(context.Background(), 0, 500, filters)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Page)
+	assert.Equal(t, 10, resp.Limit)
+	assert.Equal(t, 2, resp.Total)
+	assert.Len(t, resp.Data.([]*models.Movie), 2)
+	repo.AssertExpectations(t)
+}
+
+func TestGetAllMovies_Error(t *testing.T) {
+	repo := new(MockMovieRepository)
+	service := NewMovieService(repo)
+
+	filters := &models.MovieFilters{}
+	repo.On("GetAllMovies", mock.Anything, 1, 10, filters).Return(nil, 0, errors.New("db error"))
+
+	resp, err := service.GetAllMovies(context.Background(), 1, 10, filters)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	repo.AssertExpectations(t)
+}
+
+func TestGetMovieByID_Success(t *testing.T) {
+	repo := new(MockMovieRepository)
+	service := NewMovieService(repo)
+
+	movie := &models.Movie{ID: 3, Title: "Ngeri-Ngeri Sedap"}
+	repo.On("GetMovieByID", mock.Anything, 3).Return(movie, nil)
+
+	result, err := service.GetMovieByID(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, movie, result)
+	repo.AssertExpectations(t)
+}
+
+func TestIsShowingOn(t *testing.T) {
+	release := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		movie   *models.Movie
+		date    time.Time
+		showing bool
+	}{
+		{"Before release", &models.Movie{ReleaseDate: release}, release.AddDate(0, 0, -1), false},
+		{"On release day", &models.Movie{ReleaseDate: release}, release, true},
+		{"Open-ended run", &models.Movie{ReleaseDate: release}, release.AddDate(1, 0, 0), true},
+		{"Last day of run", &models.Movie{ReleaseDate: release, EndDate: &end}, end, true},
+		{"After run ended", &models.Movie{ReleaseDate: release, EndDate: &end}, end.AddDate(0, 0, 1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.showing, isShowingOn(tt.movie, tt.date))
+		})
+	}
+}
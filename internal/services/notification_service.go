@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +24,9 @@ func NewNotificationService(logger *zap.Logger) *NotificationService {
 
 // SendBookingConfirmationAsync sends booking confirmation notification asynchronously
 func (s *NotificationService) SendBookingConfirmationAsync(ctx context.Context, userEmail string, bookingID int, cinemaName string, seatNumbers []string, bookingTime string) {
+	ctx, span := tracing.Start(ctx, "NotificationService.SendBookingConfirmationAsync")
+	defer span.End()
+
 	// Run notification in goroutine for async execution
 	go func() {
 		// Use background context to prevent cancellation affecting notification
@@ -59,7 +64,10 @@ func (s *NotificationService) SendBookingConfirmationAsync(ctx context.Context,
 }
 
 // SendPaymentConfirmationAsync sends payment confirmation notification asynchronously
-func (s *NotificationService) SendPaymentConfirmationAsync(ctx context.Context, userEmail string, paymentID int, bookingID int, amount float64, paymentMethod string) {
+func (s *NotificationService) SendPaymentConfirmationAsync(ctx context.Context, userEmail string, paymentID int, bookingID int, amount models.Money, paymentMethod string) {
+	ctx, span := tracing.Start(ctx, "NotificationService.SendPaymentConfirmationAsync")
+	defer span.End()
+
 	// Run notification in goroutine for async execution
 	go func() {
 		notifCtx := context.Background()
@@ -73,8 +81,8 @@ func (s *NotificationService) SendPaymentConfirmationAsync(ctx context.Context,
 		time.Sleep(100 * time.Millisecond) // Simulate network delay
 
 		message := fmt.Sprintf(
-			"Payment Successful! Amount: Rp %.2f, Method: %s, Booking ID: #%d, Payment ID: #%d",
-			amount, paymentMethod, bookingID, paymentID,
+			"Payment Successful! Amount: %s %s, Method: %s, Booking ID: #%d, Payment ID: #%d",
+			models.MoneyCurrency, amount, paymentMethod, bookingID, paymentID,
 		)
 
 		s.logger.Info("Payment notification sent successfully",
@@ -89,6 +97,9 @@ func (s *NotificationService) SendPaymentConfirmationAsync(ctx context.Context,
 
 // SendBookingReminderAsync sends booking reminder notification asynchronously
 func (s *NotificationService) SendBookingReminderAsync(ctx context.Context, userEmail string, bookingID int, cinemaName string, showTime time.Time) {
+	ctx, span := tracing.Start(ctx, "NotificationService.SendBookingReminderAsync")
+	defer span.End()
+
 	go func() {
 		notifCtx := context.Background()
 
@@ -136,6 +147,9 @@ func (s *NotificationService) logNotificationToFile(ctx context.Context, notific
 
 // ProcessBulkNotificationsAsync processes multiple notifications in parallel
 func (s *NotificationService) ProcessBulkNotificationsAsync(ctx context.Context, notifications []NotificationTask) {
+	ctx, span := tracing.Start(ctx, "NotificationService.ProcessBulkNotificationsAsync")
+	defer span.End()
+
 	// Use worker pool pattern with goroutines
 	workers := 5
 	tasks := make(chan NotificationTask, len(notifications))
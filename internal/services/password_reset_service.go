@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTTL         = 15 * time.Minute       // how long a reset code works
+	passwordResetCooldown    = time.Minute            // minimum time between two codes for an email
+	passwordResetHourlyLimit = 3                      // codes sent to an email per hour
+	passwordResetMaxAttempts = 5                      // codes entered before a code stops working
+	passwordResetMinDuration = 500 * time.Millisecond // how long every reset request takes
+)
+
+// PasswordResetService lets users who forgot their password set a new one with a code
+// emailed to them
+type PasswordResetService struct {
+	userRepo PasswordResetUserRepository
+	codeRepo PasswordResetCodeRepository
+	mailer   PasswordResetMailer
+	uow      UnitOfWork
+
+	// minDuration pads reset requests, so registered emails do not answer slower
+	minDuration time.Duration
+}
+
+// PasswordResetUserRepository defines the user persistence needed to reset passwords
+type PasswordResetUserRepository interface {
+	GetUserByEmailForUpdate(ctx context.Context, email string) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	RevokeUserSessions(ctx context.Context, userID int) (int, error)
+}
+
+// PasswordResetCodeRepository defines the persistence of reset codes, which are email OTPs
+// with the password reset purpose
+type PasswordResetCodeRepository interface {
+	Create(ctx context.Context, verification *models.EmailVerification) error
+	GetByEmail(ctx context.Context, email, purpose string) (*models.EmailVerification, error)
+	CountSince(ctx context.Context, email, purpose string, since time.Time) (int, error)
+	UseAttempt(ctx context.Context, id, maxAttempts int) (bool, error)
+	Redeem(ctx context.Context, id int) (bool, error)
+}
+
+// PasswordResetMailer generates and emails reset codes; EmailService satisfies this
+type PasswordResetMailer interface {
+	GenerateOTP() (string, error)
+	SendPasswordReset(email, username, otpCode string, validFor time.Duration)
+}
+
+// NewPasswordResetService creates a new PasswordResetService
+func NewPasswordResetService(userRepo PasswordResetUserRepository, codeRepo PasswordResetCodeRepository, mailer PasswordResetMailer, uow UnitOfWork) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:    userRepo,
+		codeRepo:    codeRepo,
+		mailer:      mailer,
+		uow:         uow,
+		minDuration: passwordResetMinDuration,
+	}
+}
+
+// RequestReset emails a reset code to the user with the email. It succeeds whether or
+// not the email is registered, and sends nothing while the email is rate limited, so
+// callers cannot tell which emails have accounts. Every request takes at least
+// minDuration for the same reason.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.RequestReset")
+	defer span.End()
+
+	defer waitUntil(ctx, time.Now().Add(s.minDuration))
+
+	var user *models.User
+	var otpCode string
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		// Lock the user so concurrent requests cannot all pass the rate limit
+		var err error
+		user, err = s.userRepo.GetUserByEmailForUpdate(ctx, email)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return nil
+		}
+
+		// Rate limit per email: one code a minute and a few an hour
+		latest, err := s.codeRepo.GetByEmail(ctx, email, models.OTPPurposePasswordReset)
+		if err != nil {
+			return fmt.Errorf("failed to get latest reset code: %w", err)
+		}
+		if latest != nil && time.Since(latest.CreatedAt) < passwordResetCooldown {
+			span.SetAttribute("password_reset.rate_limited", true)
+			return nil
+		}
+		sent, err := s.codeRepo.CountSince(ctx, email, models.OTPPurposePasswordReset, time.Now().Add(-time.Hour))
+		if err != nil {
+			return fmt.Errorf("failed to count reset codes: %w", err)
+		}
+		if sent >= passwordResetHourlyLimit {
+			span.SetAttribute("password_reset.rate_limited", true)
+			return nil
+		}
+
+		code, err := s.mailer.GenerateOTP()
+		if err != nil {
+			return fmt.Errorf("failed to generate reset code: %w", err)
+		}
+
+		err = s.codeRepo.Create(ctx, &models.EmailVerification{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Purpose:   models.OTPPurposePasswordReset,
+			OTPCode:   code,
+			ExpiresAt: time.Now().Add(passwordResetTTL),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save reset code: %w", err)
+		}
+		otpCode = code
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only email codes that were saved
+	if otpCode != "" {
+		s.mailer.SendPasswordReset(user.Email, user.Username, otpCode, passwordResetTTL)
+	}
+	return nil
+}
+
+// waitUntil blocks until deadline or until ctx is done
+func waitUntil(ctx context.Context, deadline time.Time) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// ResetPassword sets a new password with the latest reset code sent to the email and
+// logs the user out of every session. It returns models.ErrInvalidResetCode when the
+// code is wrong, expired, already used or was guessed at too often.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.ResetPassword")
+	defer span.End()
+
+	code, err := s.codeRepo.GetByEmail(ctx, req.Email, models.OTPPurposePasswordReset)
+	if err != nil {
+		return fmt.Errorf("failed to get reset code: %w", err)
+	}
+	if code == nil || code.IsVerified || time.Now().After(code.ExpiresAt) {
+		return models.ErrInvalidResetCode
+	}
+
+	// Count the attempt before comparing, so concurrent guesses are limited too
+	allowed, err := s.codeRepo.UseAttempt(ctx, code.ID, passwordResetMaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to record reset attempt: %w", err)
+	}
+	if !allowed || subtle.ConstantTimeCompare([]byte(code.OTPCode), []byte(req.OTPCode)) != 1 {
+		return models.ErrInvalidResetCode
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		redeemed, err := s.codeRepo.Redeem(ctx, code.ID)
+		if err != nil {
+			return fmt.Errorf("failed to use reset code: %w", err)
+		}
+		if !redeemed {
+			return models.ErrInvalidResetCode
+		}
+		if err := s.userRepo.UpdatePassword(ctx, code.UserID, string(hashedPassword)); err != nil {
+			return err
+		}
+		// Whoever knew the old password is logged out too
+		if _, err := s.userRepo.RevokeUserSessions(ctx, code.UserID); err != nil {
+			return err
+		}
+		return nil
+	})
+}
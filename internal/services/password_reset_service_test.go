@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockPasswordResetUserRepository is a mock implementation of PasswordResetUserRepository
+type MockPasswordResetUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetUserRepository) GetUserByEmailForUpdate(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockPasswordResetUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetUserRepository) RevokeUserSessions(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+// MockPasswordResetCodeRepository is a mock implementation of PasswordResetCodeRepository
+type MockPasswordResetCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetCodeRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	args := m.Called(ctx, verification)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetCodeRepository) GetByEmail(ctx context.Context, email, purpose string) (*models.EmailVerification, error) {
+	args := m.Called(ctx, email, purpose)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailVerification), args.Error(1)
+}
+
+func (m *MockPasswordResetCodeRepository) CountSince(ctx context.Context, email, purpose string, since time.Time) (int, error) {
+	args := m.Called(ctx, email, purpose, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPasswordResetCodeRepository) UseAttempt(ctx context.Context, id, maxAttempts int) (bool, error) {
+	args := m.Called(ctx, id, maxAttempts)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetCodeRepository) Redeem(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockPasswordResetMailer is a mock implementation of PasswordResetMailer
+type MockPasswordResetMailer struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetMailer) GenerateOTP() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordResetMailer) SendPasswordReset(email, username, otpCode string, validFor time.Duration) {
+	m.Called(email, username, otpCode, validFor)
+}
+
+func newTestPasswordResetService() (*PasswordResetService, *MockPasswordResetUserRepository, *MockPasswordResetCodeRepository, *MockPasswordResetMailer) {
+	userRepo := new(MockPasswordResetUserRepository)
+	codeRepo := new(MockPasswordResetCodeRepository)
+	mailer := new(MockPasswordResetMailer)
+	service := NewPasswordResetService(userRepo, codeRepo, mailer, inlineUnitOfWork{})
+	service.minDuration = 0
+	return service, userRepo, codeRepo, mailer
+}
+
+func TestRequestReset_SendsCode(t *testing.T) {
+	service, userRepo, codeRepo, mailer := newTestPasswordResetService()
+	uow := &recordingUnitOfWork{}
+	service.uow = uow
+
+	// The limits are checked and the code saved with the user locked
+	userRepo.On("GetUserByEmailForUpdate", inTx, "john@example.com").Return(&models.User{ID: 1, Username: "john", Email: "john@example.com"}, nil)
+	codeRepo.On("GetByEmail", inTx, "john@example.com", models.OTPPurposePasswordReset).Return(nil, nil)
+	codeRepo.On("CountSince", inTx, "john@example.com", models.OTPPurposePasswordReset, mock.AnythingOfType("time.Time")).Return(0, nil)
+	mailer.On("GenerateOTP").Return("123456", nil)
+	codeRepo.On("Create", inTx, mock.MatchedBy(func(v *models.EmailVerification) bool {
+		return v.UserID == 1 && v.Purpose == models.OTPPurposePasswordReset && v.OTPCode == "123456"
+	})).Return(nil)
+	mailer.On("SendPasswordReset", "john@example.com", "john", "123456", passwordResetTTL).Return()
+
+	err := service.RequestReset(context.Background(), "john@example.com")
+
+	assert.NoError(t, err)
+	assert.False(t, uow.rolledBack)
+	userRepo.AssertExpectations(t)
+	codeRepo.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestRequestReset_UnknownEmailSucceedsSilently(t *testing.T) {
+	service, userRepo, codeRepo, mailer := newTestPasswordResetService()
+
+	userRepo.On("GetUserByEmailForUpdate", mock.Anything, "nobody@example.com").Return(nil, nil)
+
+	err := service.RequestReset(context.Background(), "nobody@example.com")
+
+	assert.NoError(t, err)
+	codeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mailer.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestReset_RateLimited(t *testing.T) {
+	tests := []struct {
+		name   string
+		latest *models.EmailVerification
+		sent   int
+	}{
+		{"within cooldown", &models.EmailVerification{ID: 3, CreatedAt: time.Now().Add(-10 * time.Second)}, 1},
+		{"hourly limit reached", &models.EmailVerification{ID: 3, CreatedAt: time.Now().Add(-5 * time.Minute)}, passwordResetHourlyLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, userRepo, codeRepo, mailer := newTestPasswordResetService()
+
+			userRepo.On("GetUserByEmailForUpdate", mock.Anything, "john@example.com").Return(&models.User{ID: 1, Email: "john@example.com"}, nil)
+			codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(tt.latest, nil)
+			codeRepo.On("CountSince", mock.Anything, "john@example.com", models.OTPPurposePasswordReset, mock.AnythingOfType("time.Time")).Return(tt.sent, nil)
+
+			err := service.RequestReset(context.Background(), "john@example.com")
+
+			assert.NoError(t, err)
+			codeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			mailer.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRequestReset_SaveFailureSendsNothing(t *testing.T) {
+	service, userRepo, codeRepo, mailer := newTestPasswordResetService()
+	uow := &recordingUnitOfWork{}
+	service.uow = uow
+
+	userRepo.On("GetUserByEmailForUpdate", inTx, "john@example.com").Return(&models.User{ID: 1, Username: "john", Email: "john@example.com"}, nil)
+	codeRepo.On("GetByEmail", inTx, "john@example.com", models.OTPPurposePasswordReset).Return(nil, nil)
+	codeRepo.On("CountSince", inTx, "john@example.com", models.OTPPurposePasswordReset, mock.AnythingOfType("time.Time")).Return(0, nil)
+	mailer.On("GenerateOTP").Return("123456", nil)
+	codeRepo.On("Create", inTx, mock.Anything).Return(errors.New("database error"))
+
+	err := service.RequestReset(context.Background(), "john@example.com")
+
+	assert.Error(t, err)
+	assert.True(t, uow.rolledBack)
+	mailer.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestReset_TakesMinimumDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+	}{
+		{"unknown email", nil},
+		{"rate limited email", &models.User{ID: 1, Email: "john@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, userRepo, codeRepo, _ := newTestPasswordResetService()
+			service.minDuration = 50 * time.Millisecond
+
+			userRepo.On("GetUserByEmailForUpdate", mock.Anything, "john@example.com").Return(tt.user, nil)
+			codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).
+				Return(&models.EmailVerification{ID: 3, CreatedAt: time.Now()}, nil)
+
+			start := time.Now()
+			err := service.RequestReset(context.Background(), "john@example.com")
+
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		})
+	}
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	service, userRepo, codeRepo, _ := newTestPasswordResetService()
+	uow := &recordingUnitOfWork{}
+	service.uow = uow
+
+	code := &models.EmailVerification{ID: 3, UserID: 1, OTPCode: "123456", ExpiresAt: time.Now().Add(10 * time.Minute)}
+	codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(code, nil)
+	codeRepo.On("UseAttempt", mock.Anything, 3, passwordResetMaxAttempts).Return(true, nil)
+	codeRepo.On("Redeem", inTx, 3).Return(true, nil)
+	userRepo.On("UpdatePassword", inTx, 1, mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpassword")) == nil
+	})).Return(nil)
+	userRepo.On("RevokeUserSessions", inTx, 1).Return(2, nil)
+
+	err := service.ResetPassword(context.Background(), &models.ResetPasswordRequest{
+		Email: "john@example.com", OTPCode: "123456", NewPassword: "newpassword",
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, uow.rolledBack)
+	userRepo.AssertExpectations(t)
+	codeRepo.AssertExpectations(t)
+}
+
+func TestResetPassword_WrongCodeCountsAttempt(t *testing.T) {
+	service, userRepo, codeRepo, _ := newTestPasswordResetService()
+
+	code := &models.EmailVerification{ID: 3, UserID: 1, OTPCode: "123456", ExpiresAt: time.Now().Add(10 * time.Minute)}
+	codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(code, nil)
+	codeRepo.On("UseAttempt", mock.Anything, 3, passwordResetMaxAttempts).Return(true, nil)
+
+	err := service.ResetPassword(context.Background(), &models.ResetPasswordRequest{
+		Email: "john@example.com", OTPCode: "654321", NewPassword: "newpassword",
+	})
+
+	assert.ErrorIs(t, err, models.ErrInvalidResetCode)
+	codeRepo.AssertExpectations(t)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_AttemptsUsedUp(t *testing.T) {
+	service, userRepo, codeRepo, _ := newTestPasswordResetService()
+
+	code := &models.EmailVerification{ID: 3, UserID: 1, OTPCode: "123456", ExpiresAt: time.Now().Add(10 * time.Minute), Attempts: passwordResetMaxAttempts}
+	codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(code, nil)
+	codeRepo.On("UseAttempt", mock.Anything, 3, passwordResetMaxAttempts).Return(false, nil)
+
+	// The right code does not help once the attempts are used up
+	err := service.ResetPassword(context.Background(), &models.ResetPasswordRequest{
+		Email: "john@example.com", OTPCode: "123456", NewPassword: "newpassword",
+	})
+
+	assert.ErrorIs(t, err, models.ErrInvalidResetCode)
+	codeRepo.AssertExpectations(t)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_CodeRedeemedConcurrently(t *testing.T) {
+	service, userRepo, codeRepo, _ := newTestPasswordResetService()
+	uow := &recordingUnitOfWork{}
+	service.uow = uow
+
+	code := &models.EmailVerification{ID: 3, UserID: 1, OTPCode: "123456", ExpiresAt: time.Now().Add(10 * time.Minute)}
+	codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(code, nil)
+	codeRepo.On("UseAttempt", mock.Anything, 3, passwordResetMaxAttempts).Return(true, nil)
+	codeRepo.On("Redeem", inTx, 3).Return(false, nil)
+
+	err := service.ResetPassword(context.Background(), &models.ResetPasswordRequest{
+		Email: "john@example.com", OTPCode: "123456", NewPassword: "newpassword",
+	})
+
+	assert.ErrorIs(t, err, models.ErrInvalidResetCode)
+	assert.True(t, uow.rolledBack)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "RevokeUserSessions", mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_UnusableCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code *models.EmailVerification
+	}{
+		{"no code", nil},
+		{"expired", &models.EmailVerification{ID: 3, OTPCode: "123456", ExpiresAt: time.Now().Add(-time.Minute)}},
+		{"already used", &models.EmailVerification{ID: 3, OTPCode: "123456", ExpiresAt: time.Now().Add(time.Minute), IsVerified: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, userRepo, codeRepo, _ := newTestPasswordResetService()
+
+			if tt.code == nil {
+				codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(nil, nil)
+			} else {
+				codeRepo.On("GetByEmail", mock.Anything, "john@example.com", models.OTPPurposePasswordReset).Return(tt.code, nil)
+			}
+
+			// The right code does not help once the code is unusable
+			err := service.ResetPassword(context.Background(), &models.ResetPasswordRequest{
+				Email: "john@example.com", OTPCode: "123456", NewPassword: "newpassword",
+			})
+
+			assert.ErrorIs(t, err, models.ErrInvalidResetCode)
+			userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
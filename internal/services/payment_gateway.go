@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+)
+
+// Gateway transaction statuses
+const (
+	GatewayStatusAuthorized = "authorized"
+	GatewayStatusCaptured   = "captured"
+	GatewayStatusPending    = "pending" // the provider settles the charge later
+	GatewayStatusDeclined   = "declined"
+	GatewayStatusVoided     = "voided"
+	GatewayStatusRefunded   = "refunded"
+)
+
+// GatewayRequest describes a charge sent to a payment gateway
+type GatewayRequest struct {
+	Reference     string // our reference for the charge, e.g. the booking
+	Amount        models.Money
+	PaymentMethod string
+}
+
+// GatewayResult is a payment gateway's answer for a transaction
+type GatewayResult struct {
+	TransactionID string
+	Status        string
+	Message       string
+	RedirectURL   string // where the customer approves a pending charge
+}
+
+// PaymentGateway is a payment provider that moves money for a payment method type
+type PaymentGateway interface {
+	Authorize(ctx context.Context, req *GatewayRequest) (*GatewayResult, error)
+	Capture(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error)
+	Void(ctx context.Context, transactionID string) (*GatewayResult, error)
+	Refund(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error)
+	QueryStatus(ctx context.Context, transactionID string) (*GatewayResult, error)
+}
+
+// GatewayRegistry selects the payment gateway for a payment method type
+type GatewayRegistry struct {
+	gateways map[string]PaymentGateway
+	fallback PaymentGateway
+}
+
+// NewGatewayRegistry creates a registry that uses fallback for method types without their own gateway
+func NewGatewayRegistry(fallback PaymentGateway) *GatewayRegistry {
+	return &GatewayRegistry{
+		gateways: map[string]PaymentGateway{},
+		fallback: fallback,
+	}
+}
+
+// Register uses gateway for payment methods of methodType
+func (r *GatewayRegistry) Register(methodType string, gateway PaymentGateway) {
+	r.gateways[methodType] = gateway
+}
+
+// ForMethodType returns the gateway for a payment method type
+func (r *GatewayRegistry) ForMethodType(methodType string) PaymentGateway {
+	if gateway, ok := r.gateways[methodType]; ok {
+		return gateway
+	}
+	return r.fallback
+}
+
+// Simulator modes
+const (
+	SimulatorApprove = "approve" // charges are authorized and captured
+	SimulatorDecline = "decline" // charges are declined
+	SimulatorTimeout = "timeout" // the provider does not answer
+	SimulatorAsync   = "async"   // charges are accepted and settled later
+)
+
+// SimulatorGateway is a deterministic in-memory gateway for development and tests.
+// Every charge gets the outcome of the configured mode.
+type SimulatorGateway struct {
+	mode         string
+	mu           sync.Mutex
+	seq          int
+	transactions map[string]*GatewayResult
+}
+
+// NewSimulatorGateway creates a simulator answering every charge according to mode
+func NewSimulatorGateway(mode string) (*SimulatorGateway, error) {
+	switch mode {
+	case SimulatorApprove, SimulatorDecline, SimulatorTimeout, SimulatorAsync:
+	default:
+		return nil, fmt.Errorf("unknown payment simulator mode %q", mode)
+	}
+
+	return &SimulatorGateway{
+		mode:         mode,
+		transactions: map[string]*GatewayResult{},
+	}, nil
+}
+
+// Authorize reserves the amount of a charge
+func (g *SimulatorGateway) Authorize(ctx context.Context, req *GatewayRequest) (*GatewayResult, error) {
+	if g.mode == SimulatorTimeout {
+		return nil, models.ErrGatewayTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seq++
+	result := &GatewayResult{TransactionID: fmt.Sprintf("SIM-%s-%d", req.Reference, g.seq)}
+	switch g.mode {
+	case SimulatorDecline:
+		result.Status = GatewayStatusDeclined
+		result.Message = "card declined by simulator"
+	case SimulatorAsync:
+		result.Status = GatewayStatusPending
+		result.Message = "awaiting provider confirmation"
+		result.RedirectURL = "https://simulator.local/pay/" + result.TransactionID
+	default:
+		result.Status = GatewayStatusAuthorized
+	}
+
+	g.transactions[result.TransactionID] = result
+	return copyResult(result), nil
+}
+
+// Capture collects an authorized charge
+func (g *SimulatorGateway) Capture(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error) {
+	return g.record(transactionID, GatewayStatusCaptured)
+}
+
+// Void releases an authorized charge that was not captured
+func (g *SimulatorGateway) Void(ctx context.Context, transactionID string) (*GatewayResult, error) {
+	return g.record(transactionID, GatewayStatusVoided)
+}
+
+// Refund returns money of a captured charge
+func (g *SimulatorGateway) Refund(ctx context.Context, transactionID string, amount models.Money) (*GatewayResult, error) {
+	return g.record(transactionID, GatewayStatusRefunded)
+}
+
+// QueryStatus returns the last known state of a transaction
+func (g *SimulatorGateway) QueryStatus(ctx context.Context, transactionID string) (*GatewayResult, error) {
+	if g.mode == SimulatorTimeout {
+		return nil, models.ErrGatewayTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result, ok := g.transactions[transactionID]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction %s", transactionID)
+	}
+	return copyResult(result), nil
+}
+
+// record stores the new status of a transaction. The simulator keeps no state across
+// restarts, so follow-up calls succeed for transactions it has not seen.
+func (g *SimulatorGateway) record(transactionID, status string) (*GatewayResult, error) {
+	if g.mode == SimulatorTimeout {
+		return nil, models.ErrGatewayTimeout
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := &GatewayResult{TransactionID: transactionID, Status: status}
+	g.transactions[transactionID] = result
+	return copyResult(result), nil
+}
+
+// copyResult returns a copy so callers cannot change the simulator's state
+func copyResult(result *GatewayResult) *GatewayResult {
+	c := *result
+	return &c
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulatorGateway_ApproveThenCaptureAndRefund(t *testing.T) {
+	gateway, err := NewSimulatorGateway(SimulatorApprove)
+	assert.NoError(t, err)
+
+	result, err := gateway.Authorize(context.Background(), &GatewayRequest{Reference: "B7", Amount: models.MustParseMoney("50000")})
+	assert.NoError(t, err)
+	assert.Equal(t, GatewayStatusAuthorized, result.Status)
+	assert.Equal(t, "SIM-B7-1", result.TransactionID)
+
+	result, err = gateway.Capture(context.Background(), result.TransactionID, models.MustParseMoney("50000"))
+	assert.NoError(t, err)
+	assert.Equal(t, GatewayStatusCaptured, result.Status)
+
+	_, err = gateway.Refund(context.Background(), result.TransactionID, models.MustParseMoney("50000"))
+	assert.NoError(t, err)
+
+	status, err := gateway.QueryStatus(context.Background(), result.TransactionID)
+	assert.NoError(t, err)
+	assert.Equal(t, GatewayStatusRefunded, status.Status)
+}
+
+func TestSimulatorGateway_Modes(t *testing.T) {
+	tests := []struct {
+		mode   string
+		status string
+		err    error
+	}{
+		{mode: SimulatorDecline, status: GatewayStatusDeclined},
+		{mode: SimulatorAsync, status: GatewayStatusPending},
+		{mode: SimulatorTimeout, err: models.ErrGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			gateway, err := NewSimulatorGateway(tt.mode)
+			assert.NoError(t, err)
+
+			result, err := gateway.Authorize(context.Background(), &GatewayRequest{Reference: "B1", Amount: models.MustParseMoney("1000")})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, result.Status)
+		})
+	}
+}
+
+func TestNewSimulatorGateway_UnknownMode(t *testing.T) {
+	_, err := NewSimulatorGateway("flaky")
+	assert.Error(t, err)
+}
+
+func TestGatewayRegistry_ForMethodType(t *testing.T) {
+	fallback, _ := NewSimulatorGateway(SimulatorApprove)
+	wallet, _ := NewSimulatorGateway(SimulatorAsync)
+	registry := NewGatewayRegistry(fallback)
+	registry.Register("e_wallet", wallet)
+
+	assert.Same(t, wallet, registry.ForMethodType("e_wallet"))
+	assert.Same(t, fallback, registry.ForMethodType("credit_card"))
+}
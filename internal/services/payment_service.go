@@ -4,26 +4,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 )
 
 // PaymentService handles payment-related business logic
 type PaymentService struct {
-	paymentRepo PaymentRepository
-	bookingRepo BookingRepository
+	paymentRepo  PaymentRepository
+	bookingRepo  BookingRepository
+	gateways     *GatewayRegistry
+	uow          UnitOfWork
+	refundPolicy models.RefundPolicy
+	newReference func(prefix string) (string, error)
 }
 
-// NewPaymentService creates a new PaymentService
-func NewPaymentService(paymentRepo PaymentRepository, bookingRepo BookingRepository) *PaymentService {
+// NewPaymentService creates a new PaymentService; gateways move the money for each
+// payment method type, uow records a charge's result and its booking's new state
+// atomically and refundPolicy decides how much of a payment is returned when its
+// booking is cancelled
+func NewPaymentService(paymentRepo PaymentRepository, bookingRepo BookingRepository, gateways *GatewayRegistry, uow UnitOfWork, refundPolicy models.RefundPolicy) *PaymentService {
 	return &PaymentService{
-		paymentRepo: paymentRepo,
-		bookingRepo: bookingRepo,
+		paymentRepo:  paymentRepo,
+		bookingRepo:  bookingRepo,
+		gateways:     gateways,
+		uow:          uow,
+		refundPolicy: refundPolicy,
+		newReference: NewReference,
 	}
 }
 
-// ProcessPayment processes a payment for a booking
+// ProcessPayment charges a booking through the gateway of the chosen payment method.
+// A captured charge confirms the booking; an async charge leaves the payment pending
+// with a next action for the customer and the booking processing until the provider's
+// webhook settles it.
+// A declined or failed charge marks the payment failed and leaves the booking pending
+// so it can be paid again while the seat hold lasts.
+// Only pending bookings can be paid; if the booking stops being pending while it is
+// charged, the charge is refunded or voided and models.ErrBookingNotPayable returned.
 func (s *PaymentService) ProcessPayment(ctx context.Context, userID int, req *models.PaymentRequest) (*models.PaymentResponse, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.ProcessPayment")
+	defer span.End()
+
 	// Get booking
 	booking, err := s.bookingRepo.GetBookingByID(ctx, req.BookingID)
 	if err != nil {
@@ -38,9 +61,13 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, userID int, req *mo
 		return nil, errors.New("unauthorized to pay for this booking")
 	}
 
+	if err := checkPayable(booking); err != nil {
+		return nil, err
+	}
+
 	// Verify amount
 	if req.Amount != booking.TotalPrice {
-		return nil, fmt.Errorf("amount mismatch: expected %.2f, got %.2f", booking.TotalPrice, req.Amount)
+		return nil, fmt.Errorf("amount mismatch: expected %s, got %s", booking.TotalPrice, req.Amount)
 	}
 
 	// Check payment method exists
@@ -52,35 +79,120 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, userID int, req *mo
 		return nil, errors.New("invalid payment method")
 	}
 
-	// Create payment
+	// Record the attempt before charging so every gateway call has a payment row
+	reference, err := s.newReference(ReferencePrefixPayment)
+	if err != nil {
+		return nil, err
+	}
 	payment := &models.Payment{
+		Reference:     reference,
 		BookingID:     req.BookingID,
 		UserID:        userID,
 		Amount:        req.Amount,
 		PaymentMethod: req.PaymentMethod,
-		Status:        "success", // In real app, this would depend on payment gateway response
-		TransactionID: fmt.Sprintf("TXN-%d-%d", booking.ID, booking.UserID),
+		Status:        "pending",
 	}
 
-	err = s.paymentRepo.CreatePayment(ctx, payment)
+	// Check again under the booking's lock, so concurrent requests cannot both charge it
+	err = s.uow.WithTx(ctx, func(ctx context.Context) error {
+		locked, err := s.bookingRepo.GetBookingByIDForUpdate(ctx, booking.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock booking: %w", err)
+		}
+		if locked == nil {
+			return models.ErrBookingNotPayable
+		}
+		if err := checkPayable(locked); err != nil {
+			return err
+		}
+
+		err = s.paymentRepo.CreatePayment(ctx, payment)
+		if err != nil {
+			if errors.Is(err, models.ErrPaymentAlreadyExists) {
+				return err
+			}
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment: %w", err)
+		return nil, err
 	}
 
-	// Update booking payment status
-	err = s.bookingRepo.UpdateBookingPaymentStatus(ctx, req.BookingID, "paid")
+	// Charge through the gateway: authorize, then capture
+	gateway := s.gateways.ForMethodType(method.Type)
+	result, err := gateway.Authorize(ctx, &GatewayRequest{
+		Reference:     payment.Reference,
+		Amount:        payment.Amount,
+		PaymentMethod: payment.PaymentMethod,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking payment status: %w", err)
+		return nil, s.failPayment(ctx, payment, err)
 	}
+	payment.TransactionID = result.TransactionID
 
-	// Update booking status to confirmed
-	err = s.bookingRepo.UpdateBookingStatus(ctx, req.BookingID, "confirmed")
+	if result.Status == GatewayStatusAuthorized {
+		authorized := result
+		result, err = gateway.Capture(ctx, authorized.TransactionID, payment.Amount)
+		if err != nil {
+			// Release the authorization; if this fails too the provider expires it
+			gateway.Void(ctx, authorized.TransactionID)
+			return nil, s.failPayment(ctx, payment, err)
+		}
+	}
+
+	switch result.Status {
+	case GatewayStatusCaptured:
+		payment.Status = "success"
+	case GatewayStatusPending:
+		payment.Status = "pending"
+	default:
+		return nil, s.failPayment(ctx, payment, fmt.Errorf("%w: %s", models.ErrPaymentDeclined, result.Message))
+	}
+
+	// Record the result together with the booking's new state
+	err = s.uow.WithTx(ctx, func(ctx context.Context) error {
+		// The booking may have been cancelled while the gateway was charging it
+		locked, err := s.bookingRepo.GetBookingByIDForUpdate(ctx, booking.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock booking: %w", err)
+		}
+		if locked == nil || locked.Status != "pending" {
+			return models.ErrBookingNotPayable
+		}
+
+		err = s.paymentRepo.UpdatePaymentResult(ctx, payment.ID, payment.Status, payment.TransactionID)
+		if err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if payment.Status == "success" {
+			return s.confirmBooking(ctx, booking.ID)
+		}
+		// The provider confirms through the payment webhook; keep the seats held until then
+		err = s.bookingRepo.UpdateBookingPaymentStatus(ctx, booking.ID, "processing")
+		if err != nil {
+			return fmt.Errorf("failed to update booking payment status: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, models.ErrBookingNotPayable) {
+		// Give the money back; if this fails the charge needs reconciling with the provider
+		if payment.Status == "success" {
+			gateway.Refund(ctx, payment.TransactionID, payment.Amount)
+		} else {
+			gateway.Void(ctx, payment.TransactionID)
+		}
+		return nil, s.failPayment(ctx, payment, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking status: %w", err)
+		return nil, err
 	}
+	paymentResults.Inc(payment.Status)
 
 	response := &models.PaymentResponse{
 		ID:            payment.ID,
+		Reference:     payment.Reference,
 		BookingID:     payment.BookingID,
 		Amount:        payment.Amount,
 		PaymentMethod: payment.PaymentMethod,
@@ -88,12 +200,214 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, userID int, req *mo
 		TransactionID: payment.TransactionID,
 		CreatedAt:     payment.CreatedAt,
 	}
+	if result.RedirectURL != "" {
+		response.NextAction = &models.PaymentNextAction{Type: "redirect", URL: result.RedirectURL}
+	}
 
 	return response, nil
 }
 
+// checkPayable returns why booking cannot be paid now, or nil if it can
+func checkPayable(booking *models.Booking) error {
+	// Seats are only held for a limited time; an expired hold cannot be paid
+	if booking.Status == "expired" || booking.HoldExpiresAt != nil && !time.Now().Before(*booking.HoldExpiresAt) {
+		return errors.New("seat hold has expired, please book again")
+	}
+
+	// Only a pending booking still holds its seats; a cancelled or confirmed one cannot be paid
+	if booking.Status != "pending" {
+		return models.ErrBookingNotPayable
+	}
+
+	// A booking can only have one successful or in-flight payment
+	if booking.PaymentStatus == "paid" || booking.PaymentStatus == "processing" {
+		return fmt.Errorf("booking payment is already %s", booking.PaymentStatus)
+	}
+	return nil
+}
+
+// failPayment marks a payment failed after an unsuccessful charge and returns the cause
+func (s *PaymentService) failPayment(ctx context.Context, payment *models.Payment, cause error) error {
+	payment.Status = "failed"
+	err := s.paymentRepo.UpdatePaymentResult(ctx, payment.ID, payment.Status, payment.TransactionID)
+	if err != nil {
+		return fmt.Errorf("failed to mark payment failed after %v: %w", cause, err)
+	}
+	paymentResults.Inc(payment.Status)
+
+	if errors.Is(cause, models.ErrPaymentDeclined) || errors.Is(cause, models.ErrGatewayTimeout) || errors.Is(cause, models.ErrGatewayUnavailable) ||
+		errors.Is(cause, models.ErrBookingNotPayable) {
+		return cause
+	}
+	return fmt.Errorf("payment gateway error: %w", cause)
+}
+
+// confirmBooking marks a booking paid and confirmed
+func (s *PaymentService) confirmBooking(ctx context.Context, bookingID int) error {
+	// Update booking payment status
+	err := s.bookingRepo.UpdateBookingPaymentStatus(ctx, bookingID, "paid")
+	if err != nil {
+		return fmt.Errorf("failed to update booking payment status: %w", err)
+	}
+
+	// Update booking status to confirmed
+	err = s.bookingRepo.UpdateBookingStatus(ctx, bookingID, "confirmed")
+	if err != nil {
+		return fmt.Errorf("failed to update booking status: %w", err)
+	}
+	return nil
+}
+
+// RefundPayment refunds a payment in full or in part on behalf of identity, who must be
+// allowed to manage the cinema the payment was made at. When req.Amount is omitted the
+// remaining refundable amount is refunded. It returns nil, nil if the payment does not exist.
+func (s *PaymentService) RefundPayment(ctx context.Context, identity *models.Identity, paymentID int, req *models.RefundRequest) (*models.Refund, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.RefundPayment")
+	defer span.End()
+
+	payment, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil {
+		return nil, nil
+	}
+
+	err = s.authorizeCinema(ctx, identity, payment.BookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := s.refundableAmount(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := remaining
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.Cmp(remaining) > 0 {
+		return nil, models.ErrRefundExceedsPayment
+	}
+
+	return s.refund(ctx, payment, amount, req.Reason)
+}
+
+// RefundPercent returns the share of the payment the refund policy returns when a
+// booking for a show starting at showtime is cancelled now
+func (s *PaymentService) RefundPercent(showtime time.Time) int {
+	return s.refundPolicy.Percent(time.Until(showtime))
+}
+
+// RefundBooking refunds percent of the payment of a cancelled booking. It returns
+// nil, nil when percent is not positive.
+func (s *PaymentService) RefundBooking(ctx context.Context, bookingID, percent int) (*models.Refund, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.RefundBooking")
+	defer span.End()
+
+	if percent <= 0 {
+		return nil, nil
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil {
+		return nil, errors.New("payment not found")
+	}
+
+	remaining, err := s.refundableAmount(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := payment.Amount.Percent(percent)
+	if amount.Cmp(remaining) > 0 {
+		amount = remaining
+	}
+
+	return s.refund(ctx, payment, amount, fmt.Sprintf("booking cancelled, %d%% refund", percent))
+}
+
+// authorizeCinema checks that identity may manage the cinema a booking was made at
+func (s *PaymentService) authorizeCinema(ctx context.Context, identity *models.Identity, bookingID int) error {
+	if identity.Role == models.RoleSuperAdmin {
+		return nil
+	}
+
+	booking, err := s.bookingRepo.GetBookingWithDetails(ctx, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking == nil || booking.Screening == nil || !identity.CanManageCinema(booking.Screening.CinemaID) {
+		return models.ErrCinemaAccessDenied
+	}
+	return nil
+}
+
+// refundableAmount returns how much of a payment can still be refunded
+func (s *PaymentService) refundableAmount(ctx context.Context, payment *models.Payment) (models.Money, error) {
+	if payment.Status != "success" && payment.Status != "partially_refunded" {
+		return models.Money{}, models.ErrPaymentNotRefundable
+	}
+
+	refunded, err := s.paymentRepo.GetRefundedAmount(ctx, payment.ID)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	return payment.Amount.Sub(refunded), nil
+}
+
+// refund records a refund of amount against payment
+func (s *PaymentService) refund(ctx context.Context, payment *models.Payment, amount models.Money, reason string) (*models.Refund, error) {
+	if !amount.IsPositive() {
+		return nil, models.ErrPaymentNotRefundable
+	}
+
+	// Return the money through the gateway that charged it
+	method, err := s.paymentRepo.GetPaymentMethodByName(ctx, payment.PaymentMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment method: %w", err)
+	}
+	methodType := ""
+	if method != nil {
+		methodType = method.Type
+	}
+
+	_, err = s.gateways.ForMethodType(methodType).Refund(ctx, payment.TransactionID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("payment gateway refund failed: %w", err)
+	}
+
+	reference, err := s.newReference(ReferencePrefixRefund)
+	if err != nil {
+		return nil, err
+	}
+	refund := &models.Refund{
+		Reference: reference,
+		PaymentID: payment.ID,
+		BookingID: payment.BookingID,
+		Amount:    amount,
+		Reason:    reason,
+	}
+
+	err = s.paymentRepo.CreateRefund(ctx, refund)
+	if err != nil {
+		if errors.Is(err, models.ErrPaymentNotRefundable) || errors.Is(err, models.ErrRefundExceedsPayment) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+	return refund, nil
+}
+
 // GetPaymentMethods retrieves all available payment methods
 func (s *PaymentService) GetPaymentMethods(ctx context.Context) ([]*models.PaymentMethod, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetPaymentMethods")
+	defer span.End()
+
 	methods, err := s.paymentRepo.GetPaymentMethods(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment methods: %w", err)
@@ -103,9 +417,34 @@ func (s *PaymentService) GetPaymentMethods(ctx context.Context) ([]*models.Payme
 
 // GetPaymentByID retrieves a payment by ID
 func (s *PaymentService) GetPaymentByID(ctx context.Context, id int) (*models.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetPaymentByID")
+	defer span.End()
+
 	payment, err := s.paymentRepo.GetPaymentByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment: %w", err)
 	}
 	return payment, nil
 }
+
+// GetPaymentByReference retrieves a payment of a user by the reference printed on the
+// receipt. It returns nil, nil if the reference is malformed, unknown or belongs to
+// another user's payment.
+func (s *PaymentService) GetPaymentByReference(ctx context.Context, userID int, reference string) (*models.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.GetPaymentByReference")
+	defer span.End()
+
+	reference, ok := ParseReference(ReferencePrefixPayment, reference)
+	if !ok {
+		return nil, nil
+	}
+
+	payment, err := s.paymentRepo.GetPaymentByReference(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment == nil || payment.UserID != userID {
+		return nil, nil
+	}
+	return payment, nil
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,11 +38,34 @@ func (m *MockPaymentRepository) GetPaymentByBookingID(ctx context.Context, booki
 	return args.Get(0).(*models.Payment), args.Error(1)
 }
 
+func (m *MockPaymentRepository) GetPaymentByReference(ctx context.Context, reference string) (*models.Payment, error) {
+	args := m.Called(ctx, reference)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
 func (m *MockPaymentRepository) UpdatePaymentStatus(ctx context.Context, id int, status string) error {
 	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
+func (m *MockPaymentRepository) UpdatePaymentResult(ctx context.Context, id int, status, transactionID string) error {
+	args := m.Called(ctx, id, status, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) GetRefundedAmount(ctx context.Context, paymentID int) (models.Money, error) {
+	args := m.Called(ctx, paymentID)
+	return args.Get(0).(models.Money), args.Error(1)
+}
+
+func (m *MockPaymentRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
 func (m *MockPaymentRepository) GetPaymentMethods(ctx context.Context) ([]*models.PaymentMethod, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -57,6 +82,24 @@ func (m *MockPaymentRepository) GetPaymentMethodByName(ctx context.Context, name
 	return args.Get(0).(*models.PaymentMethod), args.Error(1)
 }
 
+// testRefundPolicy refunds everything from 48 hours before the show and half from 24 hours
+var testRefundPolicy = models.RefundPolicy{{MinHoursBefore: 48, Percent: 100}, {MinHoursBefore: 24, Percent: 50}}
+
+// superAdmin may refund payments of every cinema
+var superAdmin = &models.Identity{UserID: 99, Role: models.RoleSuperAdmin}
+
+// fixedReference generates the reference "<prefix>-TEST" so tests can predict gateway transaction IDs
+func fixedReference(prefix string) (string, error) {
+	return prefix + "-TEST", nil
+}
+
+// newTestGateways returns a registry routing every payment method type to a simulator in mode
+func newTestGateways(t *testing.T, mode string) *GatewayRegistry {
+	simulator, err := NewSimulatorGateway(mode)
+	assert.NoError(t, err)
+	return NewGatewayRegistry(simulator)
+}
+
 type MockBookingRepoForPayment struct {
 	mock.Mock
 }
@@ -73,8 +116,20 @@ func (m *MockBookingRepoForPayment) GetBookingByID(ctx context.Context, id int)
 	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
+func (m *MockBookingRepoForPayment) GetBookingByIDForUpdate(ctx context.Context, id int) (*models.Booking, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
+}
+
 func (m *MockBookingRepoForPayment) GetBookingWithDetails(ctx context.Context, id int) (*models.Booking, error) {
-	return nil, errors.New("not implemented")
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Booking), args.Error(1)
 }
 
 func (m *MockBookingRepoForPayment) GetUserBookings(ctx context.Context, userID, page, limit int) ([]*models.Booking, int, error) {
@@ -91,22 +146,27 @@ func (m *MockBookingRepoForPayment) UpdateBookingPaymentStatus(ctx context.Conte
 	return args.Error(0)
 }
 
-func (m *MockBookingRepoForPayment) CheckSeatBooked(ctx context.Context, seatID int, showDate time.Time, showTime string) (bool, error) {
-	return false, errors.New("not implemented")
+func (m *MockBookingRepoForPayment) CancelBooking(ctx context.Context, bookingID int) error {
+	return errors.New("not implemented")
 }
 
 func TestProcessPayment_Success(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+	service.newReference = fixedReference
 
-	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: 100000}
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
 	method := &models.PaymentMethod{Name: "Card"}
-	req := &models.PaymentRequest{BookingID: 1, Amount: 100000, PaymentMethod: "Card"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
 
 	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
 	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(method, nil)
-	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 5, "success", "SIM-PAY-TEST-1").Return(nil)
 	bookingRepo.On("UpdateBookingPaymentStatus", mock.Anything, 1, "paid").Return(nil)
 	bookingRepo.On("UpdateBookingStatus", mock.Anything, 1, "confirmed").Return(nil)
 
@@ -115,16 +175,225 @@ func TestProcessPayment_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, req.Amount, resp.Amount)
 	assert.Equal(t, req.PaymentMethod, resp.PaymentMethod)
+	assert.Equal(t, "success", resp.Status)
+	assert.Equal(t, "PAY-TEST", resp.Reference)
+	assert.Equal(t, "SIM-PAY-TEST-1", resp.TransactionID)
 	bookingRepo.AssertExpectations(t)
 	paymentRepo.AssertExpectations(t)
 }
 
+func TestProcessPayment_ConfirmFailureRollsBackPaymentResult(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	uow := &recordingUnitOfWork{}
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), uow, testRefundPolicy)
+	service.newReference = fixedReference
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	method := &models.PaymentMethod{Name: "Card"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(method, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	bookingRepo.On("GetBookingByIDForUpdate", inTx, 1).Return(booking, nil)
+	paymentRepo.On("UpdatePaymentResult", inTx, 5, "success", "SIM-PAY-TEST-1").Return(nil)
+	bookingRepo.On("UpdateBookingPaymentStatus", inTx, 1, "paid").Return(nil)
+	bookingRepo.On("UpdateBookingStatus", inTx, 1, "confirmed").Return(errors.New("connection reset"))
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.True(t, uow.rolledBack)
+	bookingRepo.AssertExpectations(t)
+	paymentRepo.AssertExpectations(t)
+}
+
+func TestProcessPayment_BookingCancelledWhileChargingRefunds(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	uow := &recordingUnitOfWork{}
+	gateways := newTestGateways(t, SimulatorApprove)
+	service := NewPaymentService(paymentRepo, bookingRepo, gateways, uow, testRefundPolicy)
+	service.newReference = fixedReference
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	cancelled := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "cancelled"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	// Cancelled after the payment was recorded, while the gateway was charging
+	bookingRepo.On("GetBookingByIDForUpdate", inTx, 1).Return(booking, nil).Once()
+	bookingRepo.On("GetBookingByIDForUpdate", inTx, 1).Return(cancelled, nil).Once()
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 5, "failed", "SIM-PAY-TEST-1").Return(nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.ErrorIs(t, err, models.ErrBookingNotPayable)
+	assert.Nil(t, resp)
+	assert.True(t, uow.rolledBack)
+	charge, err := gateways.ForMethodType("").QueryStatus(context.Background(), "SIM-PAY-TEST-1")
+	assert.NoError(t, err)
+	assert.Equal(t, GatewayStatusRefunded, charge.Status)
+	paymentRepo.AssertExpectations(t)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingPaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_CancelledBooking(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "cancelled"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.ErrorIs(t, err, models.ErrBookingNotPayable)
+	assert.Nil(t, resp)
+	paymentRepo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_PaidWhileWaitingForLock(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	uow := &recordingUnitOfWork{}
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), uow, testRefundPolicy)
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending", PaymentStatus: "pending"}
+	paid := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending", PaymentStatus: "processing"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	// A concurrent payment started after the booking was read but before it was locked
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	bookingRepo.On("GetBookingByIDForUpdate", inTx, 1).Return(paid, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card"}, nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already processing")
+	assert.Nil(t, resp)
+	assert.True(t, uow.rolledBack)
+	paymentRepo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_BookingAlreadyHasPayment(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(models.ErrPaymentAlreadyExists)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.ErrorIs(t, err, models.ErrPaymentAlreadyExists)
+	assert.Nil(t, resp)
+	paymentRepo.AssertNotCalled(t, "UpdatePaymentResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_DeclinedLeavesBookingPending(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorDecline), inlineUnitOfWork{}, testRefundPolicy)
+	service.newReference = fixedReference
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card", Type: "credit_card"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 5, "failed", "SIM-PAY-TEST-1").Return(nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.ErrorIs(t, err, models.ErrPaymentDeclined)
+	assert.Nil(t, resp)
+	paymentRepo.AssertExpectations(t)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingPaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_GatewayTimeoutFailsPayment(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorTimeout), inlineUnitOfWork{}, testRefundPolicy)
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card", Type: "credit_card"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 5, "failed", "").Return(nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.ErrorIs(t, err, models.ErrGatewayTimeout)
+	assert.Nil(t, resp)
+	paymentRepo.AssertExpectations(t)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessPayment_AsyncStaysPending(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorAsync), inlineUnitOfWork{}, testRefundPolicy)
+	service.newReference = fixedReference
+
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "GoPay"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "GoPay").Return(&models.PaymentMethod{Name: "GoPay", Type: "e_wallet"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Payment).ID = 5
+	}).Return(nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 5, "pending", "SIM-PAY-TEST-1").Return(nil)
+	bookingRepo.On("UpdateBookingPaymentStatus", mock.Anything, 1, "processing").Return(nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", resp.Status)
+	if assert.NotNil(t, resp.NextAction) {
+		assert.Equal(t, "redirect", resp.NextAction.Type)
+		assert.Equal(t, "https://simulator.local/pay/SIM-PAY-TEST-1", resp.NextAction.URL)
+	}
+	bookingRepo.AssertExpectations(t)
+	bookingRepo.AssertNotCalled(t, "UpdateBookingStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestProcessPayment_BookingNotFound(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
-	req := &models.PaymentRequest{BookingID: 99, Amount: 50000, PaymentMethod: "Card"}
+	req := &models.PaymentRequest{BookingID: 99, Amount: models.MustParseMoney("50000"), PaymentMethod: "Card"}
 	bookingRepo.On("GetBookingByID", mock.Anything, 99).Return(nil, nil)
 
 	resp, err := service.ProcessPayment(context.Background(), 1, req)
@@ -136,10 +405,10 @@ func TestProcessPayment_BookingNotFound(t *testing.T) {
 func TestProcessPayment_Unauthorized(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
-	booking := &models.Booking{ID: 1, UserID: 2, TotalPrice: 100000}
-	req := &models.PaymentRequest{BookingID: 1, Amount: 100000, PaymentMethod: "Card"}
+	booking := &models.Booking{ID: 1, UserID: 2, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
 
 	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
 
@@ -152,10 +421,10 @@ func TestProcessPayment_Unauthorized(t *testing.T) {
 func TestProcessPayment_AmountMismatch(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
-	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: 100000}
-	req := &models.PaymentRequest{BookingID: 1, Amount: 200000, PaymentMethod: "Card"}
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("200000"), PaymentMethod: "Card"}
 
 	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
 
@@ -165,13 +434,52 @@ func TestProcessPayment_AmountMismatch(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+func TestProcessPayment_ExactDecimalAmountMatches(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+	service.newReference = fixedReference
+
+	// Seat prices add up to 70000.10, which float64 cannot represent exactly
+	total := models.MustParseMoney("35000.05").Add(models.MustParseMoney("35000.05"))
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: total, Status: "pending"}
+
+	var req models.PaymentRequest
+	err := json.Unmarshal([]byte(`{"booking_id": 1, "amount": "70000.10", "payment_method": "Card"}`), &req)
+	assert.NoError(t, err)
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card"}, nil)
+	paymentRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(nil)
+	bookingRepo.On("GetBookingByIDForUpdate", mock.Anything, 1).Return(booking, nil)
+	paymentRepo.On("UpdatePaymentResult", mock.Anything, 0, "success", "SIM-PAY-TEST-1").Return(nil)
+	bookingRepo.On("UpdateBookingPaymentStatus", mock.Anything, 1, "paid").Return(nil)
+	bookingRepo.On("UpdateBookingStatus", mock.Anything, 1, "confirmed").Return(nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, &req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7000010), resp.Amount.Minor())
+
+	encoded, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"amount":"70000.10"`)
+}
+
+func TestPaymentRequest_RejectsNegativeAmount(t *testing.T) {
+	var req models.PaymentRequest
+	err := json.Unmarshal([]byte(`{"booking_id": 1, "amount": "-70000.10", "payment_method": "Card"}`), &req)
+
+	assert.Error(t, err)
+}
+
 func TestProcessPayment_InvalidMethod(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
-	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: 100000}
-	req := &models.PaymentRequest{BookingID: 1, Amount: 100000, PaymentMethod: "Unknown"}
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending"}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Unknown"}
 
 	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
 	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Unknown").Return(nil, nil)
@@ -182,10 +490,167 @@ func TestProcessPayment_InvalidMethod(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+func TestProcessPayment_HoldExpired(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	booking := &models.Booking{ID: 1, UserID: 1, TotalPrice: models.MustParseMoney("100000"), Status: "pending", HoldExpiresAt: &expiredAt}
+	req := &models.PaymentRequest{BookingID: 1, Amount: models.MustParseMoney("100000"), PaymentMethod: "Card"}
+
+	bookingRepo.On("GetBookingByID", mock.Anything, 1).Return(booking, nil)
+
+	resp, err := service.ProcessPayment(context.Background(), 1, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	paymentRepo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+}
+
+func TestRefundPayment_DefaultsToRemainingAmount(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(&models.Payment{ID: 3, BookingID: 7, Amount: models.MustParseMoney("150000"), PaymentMethod: "Card", Status: "partially_refunded"}, nil)
+	paymentRepo.On("GetRefundedAmount", mock.Anything, 3).Return(models.MustParseMoney("50000"), nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card", Type: "credit_card"}, nil)
+	paymentRepo.On("CreateRefund", mock.Anything, mock.AnythingOfType("*models.Refund")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Refund).PaymentStatus = "refunded"
+	}).Return(nil)
+
+	refund, err := service.RefundPayment(context.Background(), superAdmin, 3, &models.RefundRequest{Reason: "show cancelled"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MustParseMoney("100000"), refund.Amount)
+	assert.Equal(t, 7, refund.BookingID)
+	assert.Equal(t, "refunded", refund.PaymentStatus)
+	paymentRepo.AssertExpectations(t)
+}
+
+func TestRefundPayment_ExceedsRemaining(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	amount := models.MustParseMoney("120000")
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(&models.Payment{ID: 3, Amount: models.MustParseMoney("150000"), Status: "partially_refunded"}, nil)
+	paymentRepo.On("GetRefundedAmount", mock.Anything, 3).Return(models.MustParseMoney("50000"), nil)
+
+	refund, err := service.RefundPayment(context.Background(), superAdmin, 3, &models.RefundRequest{Amount: &amount, Reason: "goodwill"})
+
+	assert.ErrorIs(t, err, models.ErrRefundExceedsPayment)
+	assert.Nil(t, refund)
+	paymentRepo.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything)
+}
+
+func TestRefundPayment_CinemaAdminOfPaymentCinema(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	admin := &models.Identity{UserID: 5, Role: models.RoleCinemaAdmin, CinemaIDs: []int{2}}
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(&models.Payment{ID: 3, BookingID: 7, Amount: models.MustParseMoney("150000"), PaymentMethod: "Card", Status: "success"}, nil)
+	bookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(&models.Booking{ID: 7, Screening: &models.Screening{CinemaID: 2}}, nil)
+	paymentRepo.On("GetRefundedAmount", mock.Anything, 3).Return(models.Money{}, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "Card").Return(&models.PaymentMethod{Name: "Card", Type: "credit_card"}, nil)
+	paymentRepo.On("CreateRefund", mock.Anything, mock.AnythingOfType("*models.Refund")).Return(nil)
+
+	refund, err := service.RefundPayment(context.Background(), admin, 3, &models.RefundRequest{Reason: "show cancelled"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MustParseMoney("150000"), refund.Amount)
+	bookingRepo.AssertExpectations(t)
+}
+
+func TestRefundPayment_CinemaAdminOfOtherCinema(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	bookingRepo := new(MockBookingRepoForPayment)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	admin := &models.Identity{UserID: 5, Role: models.RoleCinemaAdmin, CinemaIDs: []int{1}}
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(&models.Payment{ID: 3, BookingID: 7, Amount: models.MustParseMoney("150000"), Status: "success"}, nil)
+	bookingRepo.On("GetBookingWithDetails", mock.Anything, 7).Return(&models.Booking{ID: 7, Screening: &models.Screening{CinemaID: 2}}, nil)
+
+	refund, err := service.RefundPayment(context.Background(), admin, 3, &models.RefundRequest{Reason: "refund"})
+
+	assert.ErrorIs(t, err, models.ErrCinemaAccessDenied)
+	assert.Nil(t, refund)
+	paymentRepo.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything)
+}
+
+func TestRefundPayment_NotRefundable(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(&models.Payment{ID: 3, Amount: models.MustParseMoney("150000"), Status: "failed"}, nil)
+
+	refund, err := service.RefundPayment(context.Background(), superAdmin, 3, &models.RefundRequest{Reason: "refund"})
+
+	assert.ErrorIs(t, err, models.ErrPaymentNotRefundable)
+	assert.Nil(t, refund)
+}
+
+func TestRefundPayment_NotFound(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	paymentRepo.On("GetPaymentByID", mock.Anything, 3).Return(nil, nil)
+
+	refund, err := service.RefundPayment(context.Background(), superAdmin, 3, &models.RefundRequest{Reason: "refund"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, refund)
+}
+
+func TestRefundBooking_AppliesPolicy(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	paymentRepo.On("GetPaymentByBookingID", mock.Anything, 7).Return(&models.Payment{ID: 3, BookingID: 7, Amount: models.MustParseMoney("150000"), Status: "success"}, nil)
+	paymentRepo.On("GetRefundedAmount", mock.Anything, 3).Return(models.Money{}, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "").Return(nil, nil)
+	paymentRepo.On("CreateRefund", mock.Anything, mock.AnythingOfType("*models.Refund")).Return(nil)
+
+	refund, err := service.RefundBooking(context.Background(), 7, service.RefundPercent(time.Now().Add(30*time.Hour)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MustParseMoney("75000"), refund.Amount)
+	paymentRepo.AssertExpectations(t)
+}
+
+func TestRefundBooking_RoundsPartialRefundToMinorUnit(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	paymentRepo.On("GetPaymentByBookingID", mock.Anything, 7).Return(&models.Payment{ID: 3, BookingID: 7, Amount: models.MustParseMoney("70000.15"), Status: "success"}, nil)
+	paymentRepo.On("GetRefundedAmount", mock.Anything, 3).Return(models.Money{}, nil)
+	paymentRepo.On("GetPaymentMethodByName", mock.Anything, "").Return(nil, nil)
+	paymentRepo.On("CreateRefund", mock.Anything, mock.AnythingOfType("*models.Refund")).Return(nil)
+
+	refund, err := service.RefundBooking(context.Background(), 7, service.RefundPercent(time.Now().Add(30*time.Hour)))
+
+	assert.NoError(t, err)
+	// Half of 70000.15 is 35000.075, rounded half away from zero
+	assert.Equal(t, models.MustParseMoney("35000.08"), refund.Amount)
+}
+
+func TestRefundBooking_TooLateForRefund(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	percent := service.RefundPercent(time.Now().Add(3 * time.Hour))
+	refund, err := service.RefundBooking(context.Background(), 7, percent)
+
+	assert.Equal(t, 0, percent)
+	assert.NoError(t, err)
+	assert.Nil(t, refund)
+	paymentRepo.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything)
+}
+
 func TestGetPaymentMethods(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
 	methods := []*models.PaymentMethod{{ID: 1, Name: "Card"}}
 	paymentRepo.On("GetPaymentMethods", mock.Anything).Return(methods, nil)
@@ -199,7 +664,7 @@ func TestGetPaymentMethods(t *testing.T) {
 func TestGetPaymentByID(t *testing.T) {
 	paymentRepo := new(MockPaymentRepository)
 	bookingRepo := new(MockBookingRepoForPayment)
-	service := NewPaymentService(paymentRepo, bookingRepo)
+	service := NewPaymentService(paymentRepo, bookingRepo, newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
 
 	payment := &models.Payment{ID: 10}
 	paymentRepo.On("GetPaymentByID", mock.Anything, 10).Return(payment, nil)
@@ -209,3 +674,56 @@ func TestGetPaymentByID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, payment, result)
 }
+
+func TestParseRefundPolicy(t *testing.T) {
+	policy, err := models.ParseRefundPolicy("24:50, 48:100")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, policy.Percent(72*time.Hour))
+	assert.Equal(t, 50, policy.Percent(30*time.Hour))
+	assert.Equal(t, 0, policy.Percent(time.Hour))
+
+	_, err = models.ParseRefundPolicy("48-100")
+	assert.Error(t, err)
+}
+
+func TestGetPaymentByReference_Owner(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	reference, err := NewReference(ReferencePrefixPayment)
+	assert.NoError(t, err)
+	payment := &models.Payment{ID: 3, Reference: reference, UserID: 1, Status: "success"}
+	paymentRepo.On("GetPaymentByReference", mock.Anything, reference).Return(payment, nil)
+
+	// References are read off receipts, so case and surrounding spaces do not matter
+	result, err := service.GetPaymentByReference(context.Background(), 1, " "+strings.ToLower(reference)+" ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, payment, result)
+}
+
+func TestGetPaymentByReference_OtherUser(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	reference, err := NewReference(ReferencePrefixPayment)
+	assert.NoError(t, err)
+	paymentRepo.On("GetPaymentByReference", mock.Anything, reference).Return(&models.Payment{ID: 3, Reference: reference, UserID: 2}, nil)
+
+	result, err := service.GetPaymentByReference(context.Background(), 1, reference)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetPaymentByReference_Malformed(t *testing.T) {
+	paymentRepo := new(MockPaymentRepository)
+	service := NewPaymentService(paymentRepo, new(MockBookingRepoForPayment), newTestGateways(t, SimulatorApprove), inlineUnitOfWork{}, testRefundPolicy)
+
+	result, err := service.GetPaymentByReference(context.Background(), 1, "TXN-1-1")
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	paymentRepo.AssertNotCalled(t, "GetPaymentByReference", mock.Anything, mock.Anything)
+}
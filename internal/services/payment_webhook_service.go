@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+	"github.com/go-playground/validator/v10"
+)
+
+// webhookSignaturePrefix prefixes the hex HMAC-SHA256 of a webhook body in its signature header
+const webhookSignaturePrefix = "sha256="
+
+// PaymentWebhookService handles payment provider callbacks
+type PaymentWebhookService struct {
+	webhookRepo PaymentWebhookRepository
+	validator   *validator.Validate
+	secrets     map[string]string
+}
+
+// NewPaymentWebhookService creates a new PaymentWebhookService; secrets maps each
+// provider name to the key its webhooks are signed with
+func NewPaymentWebhookService(webhookRepo PaymentWebhookRepository, validator *validator.Validate, secrets map[string]string) *PaymentWebhookService {
+	return &PaymentWebhookService{
+		webhookRepo: webhookRepo,
+		validator:   validator,
+		secrets:     secrets,
+	}
+}
+
+// HandleWebhook verifies a provider callback and applies it to the payment it refers to.
+// Redelivered events are acknowledged without being applied again; the returned bool
+// reports whether the event was new.
+func (s *PaymentWebhookService) HandleWebhook(ctx context.Context, provider string, body []byte, signature string) (*models.PaymentWebhookEvent, bool, error) {
+	ctx, span := tracing.Start(ctx, "PaymentWebhookService.HandleWebhook")
+	defer span.End()
+
+	secret, ok := s.secrets[provider]
+	if !ok || secret == "" {
+		return nil, false, models.ErrUnknownWebhookProvider
+	}
+	if !VerifyWebhookSignature(secret, body, signature) {
+		return nil, false, models.ErrInvalidWebhookSignature
+	}
+
+	var payload models.PaymentWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false, fmt.Errorf("%w: %v", models.ErrInvalidWebhookPayload, err)
+	}
+	if err := s.validator.Struct(payload); err != nil {
+		return nil, false, fmt.Errorf("%w: %v", models.ErrInvalidWebhookPayload, err)
+	}
+
+	event := &models.PaymentWebhookEvent{
+		Provider:      provider,
+		EventID:       payload.EventID,
+		TransactionID: payload.TransactionID,
+		Status:        payload.Status,
+	}
+	switch payload.Status {
+	case GatewayStatusCaptured:
+		event.PaymentStatus = "success"
+	case GatewayStatusDeclined, "failed", "expired":
+		event.PaymentStatus = "failed"
+	default:
+		return nil, false, fmt.Errorf("%w: unsupported status %q", models.ErrInvalidWebhookPayload, payload.Status)
+	}
+
+	applied, err := s.webhookRepo.ApplyPaymentWebhook(ctx, event)
+	if err != nil {
+		if errors.Is(err, models.ErrPaymentNotFound) {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("failed to apply webhook: %w", err)
+	}
+	if applied {
+		paymentResults.Inc(event.PaymentStatus)
+	}
+	return event, applied, nil
+}
+
+// SignWebhookPayload returns the signature header value for a webhook body
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is the valid signature of body
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	expected := SignWebhookPayload(secret, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// FakeWebhookProvider posts signed callbacks like a real payment provider would,
+// for local development and tests
+type FakeWebhookProvider struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewFakeWebhookProvider creates a provider posting to the webhook endpoint at url
+func NewFakeWebhookProvider(url, secret string, client *http.Client) *FakeWebhookProvider {
+	return &FakeWebhookProvider{
+		url:    url,
+		secret: secret,
+		client: client,
+	}
+}
+
+// Notify posts a callback about a transaction and returns the HTTP status code of the answer
+func (p *FakeWebhookProvider) Notify(ctx context.Context, payload *models.PaymentWebhookPayload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", SignWebhookPayload(p.secret, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// MockPaymentWebhookRepository is a mock implementation of PaymentWebhookRepository
+type MockPaymentWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentWebhookRepository) ApplyPaymentWebhook(ctx context.Context, event *models.PaymentWebhookEvent) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+// newWebhookServer serves the webhook service the way the HTTP handler does, so the fake
+// provider can post callbacks to it
+func newWebhookServer(t *testing.T, service *PaymentWebhookService) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, _, err = service.HandleWebhook(r.Context(), "simulator", body, r.Header.Get("X-Signature"))
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusOK)
+		case errors.Is(err, models.ErrInvalidWebhookSignature):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHandleWebhook_FakeProviderSettlesPayment(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+	server := newWebhookServer(t, service)
+
+	webhookRepo.On("ApplyPaymentWebhook", mock.Anything, mock.MatchedBy(func(event *models.PaymentWebhookEvent) bool {
+		return event.Provider == "simulator" && event.EventID == "evt_1" &&
+			event.TransactionID == "SIM-B1-1" && event.PaymentStatus == "success"
+	})).Return(true, nil)
+
+	provider := NewFakeWebhookProvider(server.URL, testWebhookSecret, server.Client())
+	status, err := provider.Notify(context.Background(), &models.PaymentWebhookPayload{
+		EventID: "evt_1", TransactionID: "SIM-B1-1", Status: GatewayStatusCaptured,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	webhookRepo.AssertExpectations(t)
+}
+
+func TestHandleWebhook_FakeProviderWrongSecret(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+	server := newWebhookServer(t, service)
+
+	provider := NewFakeWebhookProvider(server.URL, "wrong-secret", server.Client())
+	status, err := provider.Notify(context.Background(), &models.PaymentWebhookPayload{
+		EventID: "evt_1", TransactionID: "SIM-B1-1", Status: GatewayStatusCaptured,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	webhookRepo.AssertNotCalled(t, "ApplyPaymentWebhook", mock.Anything, mock.Anything)
+}
+
+func TestHandleWebhook_DuplicateEvent(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+
+	body := []byte(`{"event_id":"evt_1","transaction_id":"SIM-B1-1","status":"captured"}`)
+	webhookRepo.On("ApplyPaymentWebhook", mock.Anything, mock.AnythingOfType("*models.PaymentWebhookEvent")).Return(false, nil)
+
+	event, applied, err := service.HandleWebhook(context.Background(), "simulator", body, SignWebhookPayload(testWebhookSecret, body))
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Equal(t, "evt_1", event.EventID)
+}
+
+func TestHandleWebhook_DeclinedFailsPayment(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+
+	body := []byte(`{"event_id":"evt_2","transaction_id":"SIM-B1-1","status":"declined"}`)
+	webhookRepo.On("ApplyPaymentWebhook", mock.Anything, mock.AnythingOfType("*models.PaymentWebhookEvent")).Return(true, nil)
+
+	event, applied, err := service.HandleWebhook(context.Background(), "simulator", body, SignWebhookPayload(testWebhookSecret, body))
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, "failed", event.PaymentStatus)
+}
+
+func TestHandleWebhook_UnknownProvider(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+
+	body := []byte(`{"event_id":"evt_1","transaction_id":"SIM-B1-1","status":"captured"}`)
+	_, _, err := service.HandleWebhook(context.Background(), "stripe", body, SignWebhookPayload(testWebhookSecret, body))
+
+	assert.ErrorIs(t, err, models.ErrUnknownWebhookProvider)
+}
+
+func TestHandleWebhook_UnsupportedStatus(t *testing.T) {
+	webhookRepo := new(MockPaymentWebhookRepository)
+	service := NewPaymentWebhookService(webhookRepo, validator.New(), map[string]string{"simulator": testWebhookSecret})
+
+	body := []byte(`{"event_id":"evt_1","transaction_id":"SIM-B1-1","status":"authorized"}`)
+	_, _, err := service.HandleWebhook(context.Background(), "simulator", body, SignWebhookPayload(testWebhookSecret, body))
+
+	assert.ErrorIs(t, err, models.ErrInvalidWebhookPayload)
+	webhookRepo.AssertNotCalled(t, "ApplyPaymentWebhook", mock.Anything, mock.Anything)
+}
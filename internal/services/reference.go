@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Reference prefixes, telling what a reference points at
+const (
+	ReferencePrefixBooking = "BKG"
+	ReferencePrefixPayment = "PAY"
+	ReferencePrefixRefund  = "RFD"
+)
+
+// referenceAlphabet is Crockford's base32, which leaves out letters easily misread as digits
+const referenceAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const (
+	referenceTimeLen   = 10 // 48-bit millisecond timestamp
+	referenceRandomLen = 8  // 40 random bits
+	referenceBodyLen   = referenceTimeLen + referenceRandomLen
+)
+
+// NewReference returns a new reference such as "PAY-01M53FF4X3GTKF94FTT". Its body is a
+// millisecond timestamp, so references sort by creation time, followed by a random part
+// and a check character that catches mistyped references.
+func NewReference(prefix string) (string, error) {
+	random := make([]byte, referenceRandomLen)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate reference: %w", err)
+	}
+
+	body := make([]byte, 0, referenceBodyLen+1)
+	ms := uint64(time.Now().UnixMilli())
+	for i := referenceTimeLen - 1; i >= 0; i-- {
+		body = append(body, referenceAlphabet[(ms>>(5*uint(i)))&31])
+	}
+	for _, b := range random {
+		body = append(body, referenceAlphabet[b&31])
+	}
+	body = append(body, referenceAlphabet[referenceCheck(string(body))])
+
+	return prefix + "-" + string(body), nil
+}
+
+// ParseReference normalizes a reference typed by a person: it is case-insensitive and
+// accepts O for 0 and I or L for 1. It reports false if the reference does not have
+// the given prefix or its check character does not match.
+func ParseReference(prefix, reference string) (string, bool) {
+	reference = strings.ToUpper(strings.TrimSpace(reference))
+	body, ok := strings.CutPrefix(reference, prefix+"-")
+	if !ok || len(body) != referenceBodyLen+1 {
+		return "", false
+	}
+
+	body = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(body)
+	for _, c := range body {
+		if !strings.ContainsRune(referenceAlphabet, c) {
+			return "", false
+		}
+	}
+	if referenceAlphabet[referenceCheck(body[:referenceBodyLen])] != body[referenceBodyLen] {
+		return "", false
+	}
+	return prefix + "-" + body, true
+}
+
+// referenceCheck computes the Luhn mod 32 check value of a reference body
+func referenceCheck(body string) int {
+	const n = len(referenceAlphabet)
+	sum := 0
+	factor := 2
+	for i := len(body) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(referenceAlphabet, body[i])
+		addend = addend/n + addend%n
+		sum += addend
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return (n - sum%n) % n
+}
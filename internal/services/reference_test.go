@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReference_Format(t *testing.T) {
+	reference, err := NewReference(ReferencePrefixPayment)
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(reference, "PAY-"))
+	assert.Len(t, reference, len("PAY-")+referenceBodyLen+1)
+
+	parsed, ok := ParseReference(ReferencePrefixPayment, reference)
+	assert.True(t, ok)
+	assert.Equal(t, reference, parsed)
+}
+
+func TestNewReference_UniqueAndTimeSortable(t *testing.T) {
+	first, err := NewReference(ReferencePrefixBooking)
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	second, err := NewReference(ReferencePrefixBooking)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.Less(t, first, second)
+}
+
+func TestParseReference_Normalizes(t *testing.T) {
+	reference, err := NewReference(ReferencePrefixRefund)
+	assert.NoError(t, err)
+
+	typed := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(reference, "0", "O"), "1", "l"))
+	parsed, ok := ParseReference(ReferencePrefixRefund, "  "+typed)
+
+	assert.True(t, ok)
+	assert.Equal(t, reference, parsed)
+}
+
+func TestParseReference_RejectsTypos(t *testing.T) {
+	reference, err := NewReference(ReferencePrefixPayment)
+	assert.NoError(t, err)
+
+	// Change one character of the body
+	i := len("PAY-") + 12
+	replacement := "0"
+	if reference[i] == '0' {
+		replacement = "1"
+	}
+	typo := reference[:i] + replacement + reference[i+1:]
+
+	_, ok := ParseReference(ReferencePrefixPayment, typo)
+	assert.False(t, ok)
+}
+
+func TestParseReference_RejectsWrongPrefixAndLength(t *testing.T) {
+	reference, err := NewReference(ReferencePrefixPayment)
+	assert.NoError(t, err)
+
+	_, ok := ParseReference(ReferencePrefixBooking, reference)
+	assert.False(t, ok)
+	_, ok = ParseReference(ReferencePrefixPayment, reference[:len(reference)-1])
+	assert.False(t, ok)
+	_, ok = ParseReference(ReferencePrefixPayment, "TXN-12-5")
+	assert.False(t, ok)
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
+)
+
+// ScreeningService handles screening-related business logic
+type ScreeningService struct {
+	screeningRepo  ScreeningRepository
+	movieRepo      MovieRepository
+	auditoriumRepo AuditoriumRepository
+	cinemaRepo     CinemaRepository
+	cleaningTime   time.Duration
+}
+
+// maxScheduleDays is the longest date range a schedule may span
+const maxScheduleDays = 92
+
+// scheduleWeekdays maps the days of week of a schedule to their time.Weekday
+var scheduleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// NewScreeningService creates a new ScreeningService. cleaningTime is how long an
+// auditorium stays empty between two scheduled screenings.
+func NewScreeningService(screeningRepo ScreeningRepository, movieRepo MovieRepository, auditoriumRepo AuditoriumRepository, cinemaRepo CinemaRepository, cleaningTime time.Duration) *ScreeningService {
+	return &ScreeningService{
+		screeningRepo:  screeningRepo,
+		movieRepo:      movieRepo,
+		auditoriumRepo: auditoriumRepo,
+		cinemaRepo:     cinemaRepo,
+		cleaningTime:   cleaningTime,
+	}
+}
+
+// CreateScreening schedules a movie in an auditorium; the end time is derived from the movie duration
+func (s *ScreeningService) CreateScreening(ctx context.Context, req *models.ScreeningRequest) (*models.Screening, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.CreateScreening")
+	defer span.End()
+
+	auditorium, err := s.auditoriumRepo.GetAuditoriumByID(ctx, req.AuditoriumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auditorium: %w", err)
+	}
+	if auditorium == nil {
+		return nil, errors.New("auditorium not found")
+	}
+
+	movie, err := s.movieRepo.GetMovieByID(ctx, req.MovieID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+	if movie == nil {
+		return nil, errors.New("movie not found")
+	}
+	if !isShowingOn(movie, req.StartTime) {
+		return nil, errors.New("movie is not showing on this date")
+	}
+
+	screening := &models.Screening{
+		CinemaID:     auditorium.CinemaID,
+		AuditoriumID: auditorium.ID,
+		MovieID:      req.MovieID,
+		StartTime:    req.StartTime,
+		EndTime:      req.StartTime.Add(time.Duration(movie.Duration) * time.Minute),
+		BasePrice:    req.BasePrice,
+	}
+
+	err = s.screeningRepo.CreateScreening(ctx, screening)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create screening: %w", err)
+	}
+
+	screening.Movie = movie
+	return screening, nil
+}
+
+// GetScreeningsByCinema lists what's playing at a cinema on the given date (YYYY-MM-DD, defaults to today)
+func (s *ScreeningService) GetScreeningsByCinema(ctx context.Context, cinemaID int, dateStr string) ([]*models.Screening, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.GetScreeningsByCinema")
+	defer span.End()
+
+	date := time.Now()
+	if dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid date format")
+		}
+		date = parsed
+	}
+
+	screenings, err := s.screeningRepo.GetScreeningsByCinema(ctx, cinemaID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screenings: %w", err)
+	}
+	return screenings, nil
+}
+
+// GetScreeningByID retrieves a screening by ID
+func (s *ScreeningService) GetScreeningByID(ctx context.Context, id int) (*models.Screening, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.GetScreeningByID")
+	defer span.End()
+
+	screening, err := s.screeningRepo.GetScreeningByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screening: %w", err)
+	}
+	return screening, nil
+}
+
+// CreateSchedule schedules a movie in an auditorium on a recurring pattern and creates its
+// screenings along with their seat availability. Each screening blocks the auditorium for
+// the movie's duration plus the cleaning time; screenings that would overlap a screening
+// already in the auditorium are reported as conflicts and fail the schedule with
+// models.ErrScheduleConflict. With preview set nothing is created and the response shows
+// the screenings and conflicts the schedule would produce.
+func (s *ScreeningService) CreateSchedule(ctx context.Context, identity *models.Identity, req *models.ScheduleRequest, preview bool) (*models.ScheduleResponse, error) {
+	ctx, span := tracing.Start(ctx, "ScreeningService.CreateSchedule")
+	defer span.End()
+
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid start date", models.ErrInvalidSchedule)
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid end date", models.ErrInvalidSchedule)
+	}
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("%w: end date is before start date", models.ErrInvalidSchedule)
+	}
+	if endDate.After(startDate.AddDate(0, 0, maxScheduleDays-1)) {
+		return nil, fmt.Errorf("%w: a schedule spans at most %d days", models.ErrInvalidSchedule, maxScheduleDays)
+	}
+
+	auditorium, err := s.auditoriumRepo.GetAuditoriumByID(ctx, req.AuditoriumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auditorium: %w", err)
+	}
+	if auditorium == nil {
+		return nil, models.ErrAuditoriumNotFound
+	}
+	if !identity.CanManageCinema(auditorium.CinemaID) {
+		return nil, models.ErrCinemaAccessDenied
+	}
+
+	cinema, err := s.cinemaRepo.GetCinemaByID(ctx, auditorium.CinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cinema: %w", err)
+	}
+	if cinema == nil || cinema.ArchivedAt != nil {
+		return nil, models.ErrCinemaArchived
+	}
+
+	movie, err := s.movieRepo.GetMovieByID(ctx, req.MovieID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+	if movie == nil {
+		return nil, models.ErrMovieNotFound
+	}
+
+	screenings, err := s.scheduleScreenings(auditorium, movie, req, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Screenings already in the auditorium around the schedule
+	first, last := screenings[0], screenings[len(screenings)-1]
+	existing, err := s.screeningRepo.GetScreeningsByAuditorium(ctx, auditorium.ID,
+		first.StartTime.Add(-s.cleaningTime), last.EndTime.Add(s.cleaningTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screenings: %w", err)
+	}
+
+	conflicts := []*models.ScheduleConflict{}
+	for _, screening := range screenings {
+		for _, other := range existing {
+			if s.overlap(screening, other) {
+				conflicts = append(conflicts, &models.ScheduleConflict{
+					StartTime: screening.StartTime,
+					EndTime:   screening.EndTime,
+					Existing:  other,
+				})
+			}
+		}
+	}
+
+	response := &models.ScheduleResponse{
+		Preview:    preview,
+		Total:      len(screenings),
+		Screenings: screenings,
+		Conflicts:  conflicts,
+	}
+	if preview {
+		return response, nil
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %d screenings overlap, preview the schedule to see them", models.ErrScheduleConflict, len(conflicts))
+	}
+
+	err = s.screeningRepo.CreateScreenings(ctx, auditorium.ID, screenings, s.cleaningTime)
+	if err != nil {
+		if errors.Is(err, models.ErrScheduleConflict) || errors.Is(err, models.ErrAuditoriumNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create screenings: %w", err)
+	}
+	return response, nil
+}
+
+// scheduleScreenings lays out the screenings of a schedule in chronological order
+func (s *ScreeningService) scheduleScreenings(auditorium *models.Auditorium, movie *models.Movie, req *models.ScheduleRequest, startDate, endDate time.Time) ([]*models.Screening, error) {
+	days := map[time.Weekday]bool{}
+	for _, day := range req.DaysOfWeek {
+		days[scheduleWeekdays[day]] = true
+	}
+
+	times := make([]time.Time, 0, len(req.Times))
+	for _, t := range req.Times {
+		parsed, err := time.Parse("15:04", t)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid time %s", models.ErrInvalidSchedule, t)
+		}
+		times = append(times, parsed)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	now := time.Now()
+	duration := time.Duration(movie.Duration) * time.Minute
+
+	screenings := []*models.Screening{}
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		if !days[date.Weekday()] {
+			continue
+		}
+		if !isShowingOn(movie, date) {
+			return nil, fmt.Errorf("%w: movie is not showing on %s", models.ErrInvalidSchedule, date.Format("2006-01-02"))
+		}
+
+		for _, t := range times {
+			start := time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+			if !start.After(now) {
+				return nil, fmt.Errorf("%w: %s is in the past", models.ErrInvalidSchedule, start.Format("2006-01-02 15:04"))
+			}
+
+			screening := &models.Screening{
+				CinemaID:     auditorium.CinemaID,
+				AuditoriumID: auditorium.ID,
+				MovieID:      movie.ID,
+				StartTime:    start,
+				EndTime:      start.Add(duration),
+				BasePrice:    req.BasePrice,
+			}
+
+			// Screenings of the same day must leave time to clean in between
+			if n := len(screenings); n > 0 && s.overlap(screenings[n-1], screening) {
+				return nil, fmt.Errorf("%w: screenings at %s and %s overlap", models.ErrInvalidSchedule,
+					screenings[n-1].StartTime.Format("15:04"), start.Format("15:04"))
+			}
+			screenings = append(screenings, screening)
+		}
+	}
+
+	if len(screenings) == 0 {
+		return nil, fmt.Errorf("%w: no dates in range fall on the given days of the week", models.ErrInvalidSchedule)
+	}
+	return screenings, nil
+}
+
+// overlap reports whether two screenings of an auditorium are less than the cleaning time apart
+func (s *ScreeningService) overlap(a, b *models.Screening) bool {
+	return a.StartTime.Before(b.EndTime.Add(s.cleaningTime)) && b.StartTime.Before(a.EndTime.Add(s.cleaningTime))
+}
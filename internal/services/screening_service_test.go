@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockScreeningRepository is a mock implementation of ScreeningRepository
+type MockScreeningRepository struct {
+	mock.Mock
+}
+
+func (m *MockScreeningRepository) CreateScreening(ctx context.Context, screening *models.Screening) error {
+	args := m.Called(ctx, screening)
+	return args.Error(0)
+}
+
+func (m *MockScreeningRepository) GetScreeningByID(ctx context.Context, id int) (*models.Screening, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Screening), args.Error(1)
+}
+
+func (m *MockScreeningRepository) GetScreeningsByCinema(ctx context.Context, cinemaID int, date time.Time) ([]*models.Screening, error) {
+	args := m.Called(ctx, cinemaID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Screening), args.Error(1)
+}
+
+func (m *MockScreeningRepository) GetScreeningsByAuditorium(ctx context.Context, auditoriumID int, from, to time.Time) ([]*models.Screening, error) {
+	args := m.Called(ctx, auditoriumID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Screening), args.Error(1)
+}
+
+func (m *MockScreeningRepository) CreateScreenings(ctx context.Context, auditoriumID int, screenings []*models.Screening, cleaningTime time.Duration) error {
+	args := m.Called(ctx, auditoriumID, screenings, cleaningTime)
+	return args.Error(0)
+}
+
+func TestCreateScreening_DerivesEndTime(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, new(MockCinemaRepo), 15*time.Minute)
+
+	start := time.Date(2026, 1, 15, 19, 0, 0, 0, time.UTC)
+	movie := &models.Movie{ID: 2, Title: "Agak Laen", Duration: 119, ReleaseDate: start.AddDate(0, 0, -7)}
+	req := &models.ScreeningRequest{AuditoriumID: 4, MovieID: 2, StartTime: start, BasePrice: models.MustParseMoney("50000")}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(movie, nil)
+	screeningRepo.On("CreateScreening", mock.Anything, mock.AnythingOfType("*models.Screening")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Screening).ID = 10
+	}).Return(nil)
+
+	screening, err := service.CreateScreening(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, screening.ID)
+	assert.Equal(t, start.Add(119*time.Minute), screening.EndTime)
+	assert.Equal(t, 1, screening.CinemaID)
+	assert.Equal(t, 4, screening.AuditoriumID)
+	assert.Equal(t, movie, screening.Movie)
+	screeningRepo.AssertExpectations(t)
+}
+
+func TestCreateScreening_MovieNotShowing(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, new(MockCinemaRepo), 15*time.Minute)
+
+	start := time.Date(2026, 1, 15, 19, 0, 0, 0, time.UTC)
+	req := &models.ScreeningRequest{AuditoriumID: 4, MovieID: 2, StartTime: start, BasePrice: models.MustParseMoney("50000")}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(&models.Movie{ID: 2, Duration: 90, ReleaseDate: start.AddDate(0, 0, 1)}, nil)
+
+	screening, err := service.CreateScreening(context.Background(), req)
+
+	assert.Error(t, err)
+	assert.Nil(t, screening)
+	screeningRepo.AssertNotCalled(t, "CreateScreening", mock.Anything, mock.Anything)
+}
+
+func TestGetScreeningsByCinema_ParsesDate(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	service := NewScreeningService(screeningRepo, new(MockMovieRepository), new(MockAuditoriumRepository), new(MockCinemaRepo), 15*time.Minute)
+
+	date := time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local)
+	screenings := []*models.Screening{{ID: 1, CinemaID: 1}, {ID: 2, CinemaID: 1}}
+	screeningRepo.On("GetScreeningsByCinema", mock.Anything, 1, date).Return(screenings, nil)
+
+	result, err := service.GetScreeningsByCinema(context.Background(), 1, "2026-01-15")
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	screeningRepo.AssertExpectations(t)
+}
+
+func TestGetScreeningsByCinema_InvalidDate(t *testing.T) {
+	service := NewScreeningService(new(MockScreeningRepository), new(MockMovieRepository), new(MockAuditoriumRepository), new(MockCinemaRepo), 15*time.Minute)
+
+	result, err := service.GetScreeningsByCinema(context.Background(), 1, "15/01/2026")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGetScreeningByID_Error(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	service := NewScreeningService(screeningRepo, new(MockMovieRepository), new(MockAuditoriumRepository), new(MockCinemaRepo), 15*time.Minute)
+
+	screeningRepo.On("GetScreeningByID", mock.Anything, 3).Return(nil, errors.New("db error"))
+
+	result, err := service.GetScreeningByID(context.Background(), 3)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	screeningRepo.AssertExpectations(t)
+}
+
+// nextMonday returns the first Monday at least a week from now, so schedules starting
+// on it are always in the future
+func nextMonday() time.Time {
+	year, month, day := time.Now().AddDate(0, 0, 7).Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	for date.Weekday() != time.Monday {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+func TestCreateSchedule_PreviewListsScreeningsOnPatternDays(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, cinemaRepo, 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.AddDate(0, 0, 13).Format("2006-01-02"),
+		DaysOfWeek:   []string{"fri", "mon"},
+		Times:        []string{"19:00", "13:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(&models.Movie{ID: 2, Duration: 119, ReleaseDate: monday.AddDate(0, 0, -7)}, nil)
+	screeningRepo.On("GetScreeningsByAuditorium", mock.Anything, 4, mock.Anything, mock.Anything).Return([]*models.Screening{}, nil)
+
+	schedule, err := service.CreateSchedule(context.Background(), superAdmin, req, true)
+
+	assert.NoError(t, err)
+	assert.True(t, schedule.Preview)
+	assert.Equal(t, 8, schedule.Total)
+	assert.Empty(t, schedule.Conflicts)
+	assert.Equal(t, monday.Add(13*time.Hour), schedule.Screenings[0].StartTime)
+	assert.Equal(t, monday.Add(13*time.Hour+119*time.Minute), schedule.Screenings[0].EndTime)
+	assert.Equal(t, monday.Add(19*time.Hour), schedule.Screenings[1].StartTime)
+	assert.Equal(t, monday.AddDate(0, 0, 4).Add(13*time.Hour), schedule.Screenings[2].StartTime)
+	assert.Equal(t, 1, schedule.Screenings[0].CinemaID)
+	screeningRepo.AssertNotCalled(t, "CreateScreenings", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateSchedule_CreatesScreenings(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, cinemaRepo, 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.AddDate(0, 0, 6).Format("2006-01-02"),
+		DaysOfWeek:   []string{"sat", "sun"},
+		Times:        []string{"10:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(&models.Movie{ID: 2, Duration: 90, ReleaseDate: monday}, nil)
+	screeningRepo.On("GetScreeningsByAuditorium", mock.Anything, 4,
+		time.Date(monday.Year(), monday.Month(), monday.Day()+5, 9, 45, 0, 0, time.Local),
+		time.Date(monday.Year(), monday.Month(), monday.Day()+6, 11, 45, 0, 0, time.Local)).
+		Return([]*models.Screening{}, nil)
+	screeningRepo.On("CreateScreenings", mock.Anything, 4, mock.AnythingOfType("[]*models.Screening"), 15*time.Minute).Return(nil)
+
+	cinemaAdmin := &models.Identity{UserID: 8, Role: models.RoleCinemaAdmin, CinemaIDs: []int{1}}
+	schedule, err := service.CreateSchedule(context.Background(), cinemaAdmin, req, false)
+
+	assert.NoError(t, err)
+	assert.False(t, schedule.Preview)
+	assert.Equal(t, 2, schedule.Total)
+	screeningRepo.AssertExpectations(t)
+}
+
+func TestCreateSchedule_ConflictWithExistingScreening(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, cinemaRepo, 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.Format("2006-01-02"),
+		DaysOfWeek:   []string{"mon"},
+		Times:        []string{"13:00", "16:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	// Ends at 15:50, leaving only 10 minutes to clean before the 16:00 screening
+	existing := &models.Screening{ID: 30, AuditoriumID: 4, StartTime: monday.Add(14 * time.Hour), EndTime: monday.Add(15*time.Hour + 50*time.Minute)}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(&models.Movie{ID: 2, Duration: 45, ReleaseDate: monday}, nil)
+	screeningRepo.On("GetScreeningsByAuditorium", mock.Anything, 4, mock.Anything, mock.Anything).Return([]*models.Screening{existing}, nil)
+
+	preview, err := service.CreateSchedule(context.Background(), superAdmin, req, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, preview.Conflicts, 1)
+	assert.Equal(t, monday.Add(16*time.Hour), preview.Conflicts[0].StartTime)
+	assert.Equal(t, 30, preview.Conflicts[0].Existing.ID)
+
+	schedule, err := service.CreateSchedule(context.Background(), superAdmin, req, false)
+
+	assert.ErrorIs(t, err, models.ErrScheduleConflict)
+	assert.Nil(t, schedule)
+	screeningRepo.AssertNotCalled(t, "CreateScreenings", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateSchedule_TimesTooCloseForMovie(t *testing.T) {
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewScreeningService(new(MockScreeningRepository), movieRepo, auditoriumRepo, cinemaRepo, 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.Format("2006-01-02"),
+		DaysOfWeek:   []string{"mon"},
+		Times:        []string{"19:00", "21:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1}, nil)
+	movieRepo.On("GetMovieByID", mock.Anything, 2).Return(&models.Movie{ID: 2, Duration: 119, ReleaseDate: monday}, nil)
+
+	schedule, err := service.CreateSchedule(context.Background(), superAdmin, req, true)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSchedule)
+	assert.Contains(t, err.Error(), "19:00 and 21:00")
+	assert.Nil(t, schedule)
+}
+
+func TestCreateSchedule_AuditoriumOfAnotherCinema(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, new(MockCinemaRepo), 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.Format("2006-01-02"),
+		DaysOfWeek:   []string{"mon"},
+		Times:        []string{"19:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 2}, nil)
+
+	cinemaAdmin := &models.Identity{UserID: 8, Role: models.RoleCinemaAdmin, CinemaIDs: []int{1}}
+	schedule, err := service.CreateSchedule(context.Background(), cinemaAdmin, req, false)
+
+	assert.ErrorIs(t, err, models.ErrCinemaAccessDenied)
+	assert.Nil(t, schedule)
+	movieRepo.AssertNotCalled(t, "GetMovieByID", mock.Anything, mock.Anything)
+}
+
+func TestCreateSchedule_EndDateBeforeStartDate(t *testing.T) {
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewScreeningService(new(MockScreeningRepository), new(MockMovieRepository), auditoriumRepo, new(MockCinemaRepo), 15*time.Minute)
+
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    "2026-03-10",
+		EndDate:      "2026-03-09",
+		DaysOfWeek:   []string{"mon"},
+		Times:        []string{"19:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	schedule, err := service.CreateSchedule(context.Background(), superAdmin, req, true)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSchedule)
+	assert.Nil(t, schedule)
+	auditoriumRepo.AssertNotCalled(t, "GetAuditoriumByID", mock.Anything, mock.Anything)
+}
+
+func TestCreateSchedule_ArchivedCinema(t *testing.T) {
+	screeningRepo := new(MockScreeningRepository)
+	movieRepo := new(MockMovieRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	cinemaRepo := new(MockCinemaRepo)
+	service := NewScreeningService(screeningRepo, movieRepo, auditoriumRepo, cinemaRepo, 15*time.Minute)
+
+	monday := nextMonday()
+	req := &models.ScheduleRequest{
+		AuditoriumID: 4,
+		MovieID:      2,
+		StartDate:    monday.Format("2006-01-02"),
+		EndDate:      monday.Format("2006-01-02"),
+		DaysOfWeek:   []string{"mon"},
+		Times:        []string{"19:00"},
+		BasePrice:    models.MustParseMoney("50000"),
+	}
+
+	archivedAt := time.Now().Add(-time.Hour)
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	cinemaRepo.On("GetCinemaByID", mock.Anything, 1).Return(&models.Cinema{ID: 1, ArchivedAt: &archivedAt}, nil)
+
+	// Not even a preview is offered
+	schedule, err := service.CreateSchedule(context.Background(), superAdmin, req, true)
+
+	assert.ErrorIs(t, err, models.ErrCinemaArchived)
+	assert.Nil(t, schedule)
+	movieRepo.AssertNotCalled(t, "GetMovieByID", mock.Anything, mock.Anything)
+	screeningRepo.AssertNotCalled(t, "CreateScreenings", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
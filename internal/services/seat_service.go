@@ -2,32 +2,45 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"strconv"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 )
 
 // SeatService handles seat-related business logic
 type SeatService struct {
-	seatRepo SeatRepository
+	seatRepo       SeatRepository
+	screeningRepo  ScreeningRepository
+	auditoriumRepo AuditoriumRepository
 }
 
 // NewSeatService creates a new SeatService
-func NewSeatService(seatRepo SeatRepository) *SeatService {
-	return &SeatService{seatRepo: seatRepo}
+func NewSeatService(seatRepo SeatRepository, screeningRepo ScreeningRepository, auditoriumRepo AuditoriumRepository) *SeatService {
+	return &SeatService{
+		seatRepo:       seatRepo,
+		screeningRepo:  screeningRepo,
+		auditoriumRepo: auditoriumRepo,
+	}
 }
 
-// GetSeatAvailability retrieves seat availability for a specific cinema, date, and time
-func (s *SeatService) GetSeatAvailability(ctx context.Context, cinemaID int, dateStr, timeStr string) (*models.SeatAvailabilityResponse, error) {
-	// Parse date
-	date, err := time.Parse("2006-01-02", dateStr)
+// GetSeatAvailability retrieves seat availability for a specific screening
+func (s *SeatService) GetSeatAvailability(ctx context.Context, screeningID int) (*models.SeatAvailabilityResponse, error) {
+	ctx, span := tracing.Start(ctx, "SeatService.GetSeatAvailability")
+	defer span.End()
+
+	screening, err := s.screeningRepo.GetScreeningByID(ctx, screeningID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format: %w", err)
+		return nil, fmt.Errorf("failed to get screening: %w", err)
+	}
+	if screening == nil {
+		return nil, nil
 	}
 
 	// Get seat availability from repository
-	availabilities, err := s.seatRepo.GetSeatAvailability(ctx, cinemaID, date, timeStr)
+	availabilities, err := s.seatRepo.GetSeatAvailability(ctx, screeningID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get seat availability: %w", err)
 	}
@@ -45,9 +58,11 @@ func (s *SeatService) GetSeatAvailability(ctx context.Context, cinemaID int, dat
 	}
 
 	response := &models.SeatAvailabilityResponse{
-		CinemaID:         cinemaID,
-		Date:             dateStr,
-		Time:             timeStr,
+		ScreeningID:      screening.ID,
+		CinemaID:         screening.CinemaID,
+		AuditoriumID:     screening.AuditoriumID,
+		MovieID:          screening.MovieID,
+		StartTime:        screening.StartTime,
 		AvailableSeats:   availableSeats,
 		UnavailableSeats: unavailableSeats,
 		TotalAvailable:   len(availableSeats),
@@ -59,9 +74,201 @@ func (s *SeatService) GetSeatAvailability(ctx context.Context, cinemaID int, dat
 
 // GetSeatByID retrieves a seat by ID
 func (s *SeatService) GetSeatByID(ctx context.Context, id int) (*models.Seat, error) {
+	ctx, span := tracing.Start(ctx, "SeatService.GetSeatByID")
+	defer span.End()
+
 	seat, err := s.seatRepo.GetSeatByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get seat: %w", err)
 	}
 	return seat, nil
 }
+
+// GetSeatMap retrieves the seat map of an auditorium of a cinema. Auditoriums without an
+// uploaded layout get a grid of their seats with one row per seat row. It returns nil if
+// the auditorium does not exist or belongs to another cinema.
+func (s *SeatService) GetSeatMap(ctx context.Context, cinemaID, auditoriumID int) (*models.SeatMap, error) {
+	ctx, span := tracing.Start(ctx, "SeatService.GetSeatMap")
+	defer span.End()
+
+	auditorium, err := s.getCinemaAuditorium(ctx, cinemaID, auditoriumID)
+	if err != nil || auditorium == nil {
+		return nil, err
+	}
+
+	layout, err := s.seatRepo.GetSeatMap(ctx, auditoriumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seat map: %w", err)
+	}
+
+	seats, err := s.seatRepo.GetSeatsByAuditorium(ctx, auditoriumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seats: %w", err)
+	}
+
+	if layout == nil {
+		return seatMapFromSeats(auditoriumID, seats), nil
+	}
+	return newSeatMap(auditoriumID, layout, seats), nil
+}
+
+// UpdateSeatMap replaces the seat layout of an auditorium of a cinema and creates or
+// updates its seats to match. Seats left out of the new layout are deactivated, so
+// existing bookings keep them. It returns nil, nil if the auditorium does not exist or
+// belongs to another cinema.
+func (s *SeatService) UpdateSeatMap(ctx context.Context, cinemaID, auditoriumID int, req *models.SeatMapRequest) (*models.SeatMap, error) {
+	ctx, span := tracing.Start(ctx, "SeatService.UpdateSeatMap")
+	defer span.End()
+
+	if err := checkSeatMap(req); err != nil {
+		return nil, err
+	}
+
+	auditorium, err := s.getCinemaAuditorium(ctx, cinemaID, auditoriumID)
+	if err != nil || auditorium == nil {
+		return nil, err
+	}
+
+	seats := []*models.Seat{}
+	for i, row := range req.Rows {
+		number := 0
+		for _, cell := range row.Cells {
+			if !models.IsSeatType(cell) {
+				continue
+			}
+			number++
+			seats = append(seats, &models.Seat{
+				AuditoriumID: auditoriumID,
+				SeatNumber:   seatNumber(row.Label, number),
+				RowNumber:    i + 1,
+				SeatType:     cell,
+				Price:        req.Prices[cell],
+			})
+		}
+	}
+
+	err = s.seatRepo.SaveSeatMap(ctx, auditoriumID, req.Rows, seats)
+	if err != nil {
+		if errors.Is(err, models.ErrAuditoriumNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to save seat map: %w", err)
+	}
+
+	return newSeatMap(auditoriumID, req.Rows, seats), nil
+}
+
+// getCinemaAuditorium retrieves an auditorium; returns nil if it is not one of the cinema's
+func (s *SeatService) getCinemaAuditorium(ctx context.Context, cinemaID, auditoriumID int) (*models.Auditorium, error) {
+	auditorium, err := s.auditoriumRepo.GetAuditoriumByID(ctx, auditoriumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auditorium: %w", err)
+	}
+	if auditorium == nil || auditorium.CinemaID != cinemaID {
+		return nil, nil
+	}
+	return auditorium, nil
+}
+
+// checkSeatMap checks that a seat layout is a grid with uniquely labelled rows, unique
+// seat numbers and a price for every seat type it uses. Labels may end in a digit, so
+// seat 11 of row A and seat 1 of row A1 would both be numbered A11.
+func checkSeatMap(req *models.SeatMapRequest) error {
+	columns := len(req.Rows[0].Cells)
+	labels := map[string]bool{}
+	seatRows := map[string]string{}
+	totalSeats := 0
+
+	for _, row := range req.Rows {
+		if labels[row.Label] {
+			return fmt.Errorf("%w: row %s appears more than once", models.ErrInvalidSeatMap, row.Label)
+		}
+		labels[row.Label] = true
+
+		if len(row.Cells) != columns {
+			return fmt.Errorf("%w: row %s has %d cells, expected %d", models.ErrInvalidSeatMap, row.Label, len(row.Cells), columns)
+		}
+
+		number := 0
+		for _, cell := range row.Cells {
+			if !models.IsSeatType(cell) {
+				continue
+			}
+			if _, ok := req.Prices[cell]; !ok {
+				return fmt.Errorf("%w: no price for %s seats", models.ErrInvalidSeatMap, cell)
+			}
+			number++
+			seat := seatNumber(row.Label, number)
+			if other, ok := seatRows[seat]; ok {
+				return fmt.Errorf("%w: seat %s would be numbered in both row %s and row %s", models.ErrInvalidSeatMap, seat, other, row.Label)
+			}
+			seatRows[seat] = row.Label
+			totalSeats++
+		}
+	}
+
+	if totalSeats == 0 {
+		return fmt.Errorf("%w: no seats", models.ErrInvalidSeatMap)
+	}
+	return nil
+}
+
+// seatNumber labels the n-th seat of a row, counted from the left
+func seatNumber(rowLabel string, n int) string {
+	return fmt.Sprintf("%s%d", rowLabel, n)
+}
+
+// newSeatMap lays out the seats of an auditorium on the grid of its uploaded layout
+func newSeatMap(auditoriumID int, layout []models.SeatMapRowLayout, seats []*models.Seat) *models.SeatMap {
+	seatsByNumber := make(map[string]*models.Seat, len(seats))
+	for _, seat := range seats {
+		seatsByNumber[seat.SeatNumber] = seat
+	}
+
+	seatMap := &models.SeatMap{AuditoriumID: auditoriumID, Rows: []*models.SeatMapRow{}}
+	for i, rowLayout := range layout {
+		row := &models.SeatMapRow{Row: i + 1, Label: rowLayout.Label, Cells: []*models.SeatMapCell{}}
+		number := 0
+		for j, cell := range rowLayout.Cells {
+			mapCell := &models.SeatMapCell{Row: i + 1, Column: j + 1, Type: cell}
+			if models.IsSeatType(cell) {
+				number++
+				mapCell.Type = models.SeatMapCellSeat
+				mapCell.Seat = seatsByNumber[seatNumber(rowLayout.Label, number)]
+				seatMap.TotalSeats++
+			}
+			row.Cells = append(row.Cells, mapCell)
+		}
+
+		if len(row.Cells) > seatMap.Columns {
+			seatMap.Columns = len(row.Cells)
+		}
+		seatMap.Rows = append(seatMap.Rows, row)
+	}
+	return seatMap
+}
+
+// seatMapFromSeats lays out seats created without a seat map: each seat row becomes a
+// grid row labelled with its number, with the seats side by side in seat number order
+func seatMapFromSeats(auditoriumID int, seats []*models.Seat) *models.SeatMap {
+	seatMap := &models.SeatMap{AuditoriumID: auditoriumID, TotalSeats: len(seats), Rows: []*models.SeatMapRow{}}
+
+	var row *models.SeatMapRow
+	for _, seat := range seats {
+		if row == nil || row.Row != seat.RowNumber {
+			row = &models.SeatMapRow{Row: seat.RowNumber, Label: strconv.Itoa(seat.RowNumber), Cells: []*models.SeatMapCell{}}
+			seatMap.Rows = append(seatMap.Rows, row)
+		}
+
+		row.Cells = append(row.Cells, &models.SeatMapCell{
+			Row:    seat.RowNumber,
+			Column: len(row.Cells) + 1,
+			Type:   models.SeatMapCellSeat,
+			Seat:   seat,
+		})
+		if len(row.Cells) > seatMap.Columns {
+			seatMap.Columns = len(row.Cells)
+		}
+	}
+	return seatMap
+}
@@ -15,8 +15,8 @@ type MockSeatAvailabilityRepository struct {
 	mock.Mock
 }
 
-func (m *MockSeatAvailabilityRepository) GetSeatAvailability(ctx context.Context, cinemaID int, date time.Time, timeStr string) ([]*models.SeatAvailability, error) {
-	args := m.Called(ctx, cinemaID, date, timeStr)
+func (m *MockSeatAvailabilityRepository) GetSeatAvailability(ctx context.Context, screeningID int) ([]*models.SeatAvailability, error) {
+	args := m.Called(ctx, screeningID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -31,55 +31,83 @@ func (m *MockSeatAvailabilityRepository) GetSeatByID(ctx context.Context, id int
 	return args.Get(0).(*models.Seat), args.Error(1)
 }
 
-func (m *MockSeatAvailabilityRepository) UpdateSeatAvailability(ctx context.Context, seatID int, date time.Time, timeStr string, isAvailable bool) error {
-	args := m.Called(ctx, seatID, date, timeStr, isAvailable)
+func (m *MockSeatAvailabilityRepository) UpdateSeatAvailability(ctx context.Context, screeningID, seatID int, isAvailable bool) error {
+	args := m.Called(ctx, screeningID, seatID, isAvailable)
+	return args.Error(0)
+}
+
+func (m *MockSeatAvailabilityRepository) GetSeatsByAuditorium(ctx context.Context, auditoriumID int) ([]*models.Seat, error) {
+	args := m.Called(ctx, auditoriumID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Seat), args.Error(1)
+}
+
+func (m *MockSeatAvailabilityRepository) GetSeatMap(ctx context.Context, auditoriumID int) ([]models.SeatMapRowLayout, error) {
+	args := m.Called(ctx, auditoriumID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SeatMapRowLayout), args.Error(1)
+}
+
+func (m *MockSeatAvailabilityRepository) SaveSeatMap(ctx context.Context, auditoriumID int, layout []models.SeatMapRowLayout, seats []*models.Seat) error {
+	args := m.Called(ctx, auditoriumID, layout, seats)
 	return args.Error(0)
 }
 
 func TestGetSeatAvailability_Success(t *testing.T) {
 	repo := new(MockSeatAvailabilityRepository)
-	service := NewSeatService(repo)
-
-	dateStr := "2026-01-15"
-	showDate, _ := time.Parse("2006-01-02", dateStr)
+	screeningRepo := new(MockScreeningRepository)
+	service := NewSeatService(repo, screeningRepo, new(MockAuditoriumRepository))
 
+	screening := &models.Screening{ID: 7, CinemaID: 1, AuditoriumID: 4, MovieID: 2, StartTime: time.Date(2026, 1, 15, 19, 0, 0, 0, time.UTC)}
 	availabilities := []*models.SeatAvailability{
-		{ID: 1, CinemaID: 1, SeatID: 1, ShowDate: showDate, ShowTime: "19:00", IsAvailable: true, Seat: &models.Seat{SeatNumber: "A1"}},
-		{ID: 2, CinemaID: 1, SeatID: 2, ShowDate: showDate, ShowTime: "19:00", IsAvailable: false, Seat: &models.Seat{SeatNumber: "A2"}},
+		{ID: 1, ScreeningID: 7, SeatID: 1, IsAvailable: true, Seat: &models.Seat{SeatNumber: "A1"}},
+		{ID: 2, ScreeningID: 7, SeatID: 2, IsAvailable: false, Seat: &models.Seat{SeatNumber: "A2"}},
 	}
 
-	repo.On("GetSeatAvailability", mock.Anything, 1, showDate, "19:00").Return(availabilities, nil)
+	screeningRepo.On("GetScreeningByID", mock.Anything, 7).Return(screening, nil)
+	repo.On("GetSeatAvailability", mock.Anything, 7).Return(availabilities, nil)
 
-	resp, err := service.GetSeatAvailability(context.Background(), 1, dateStr, "19:00")
+	resp, err := service.GetSeatAvailability(context.Background(), 7)
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.CinemaID)
+	assert.Equal(t, 4, resp.AuditoriumID)
+	assert.Equal(t, screening.StartTime, resp.StartTime)
 	assert.Equal(t, 1, resp.TotalAvailable)
 	assert.Equal(t, 1, resp.TotalUnavailable)
 	assert.Len(t, resp.AvailableSeats, 1)
 	assert.Len(t, resp.UnavailableSeats, 1)
 	repo.AssertExpectations(t)
+	screeningRepo.AssertExpectations(t)
 }
 
-func TestGetSeatAvailability_InvalidDate(t *testing.T) {
+func TestGetSeatAvailability_ScreeningNotFound(t *testing.T) {
 	repo := new(MockSeatAvailabilityRepository)
-	service := NewSeatService(repo)
+	screeningRepo := new(MockScreeningRepository)
+	service := NewSeatService(repo, screeningRepo, new(MockAuditoriumRepository))
 
-	resp, err := service.GetSeatAvailability(context.Background(), 1, "15-01-2026", "19:00")
+	screeningRepo.On("GetScreeningByID", mock.Anything, 99).Return(nil, nil)
 
-	assert.Error(t, err)
+	resp, err := service.GetSeatAvailability(context.Background(), 99)
+
+	assert.NoError(t, err)
 	assert.Nil(t, resp)
+	repo.AssertNotCalled(t, "GetSeatAvailability", mock.Anything, mock.Anything)
 }
 
 func TestGetSeatAvailability_RepoError(t *testing.T) {
 	repo := new(MockSeatAvailabilityRepository)
-	service := NewSeatService(repo)
+	screeningRepo := new(MockScreeningRepository)
+	service := NewSeatService(repo, screeningRepo, new(MockAuditoriumRepository))
 
-	dateStr := "2026-01-15"
-	showDate, _ := time.Parse("2006-01-02", dateStr)
+	screeningRepo.On("GetScreeningByID", mock.Anything, 7).Return(&models.Screening{ID: 7}, nil)
+	repo.On("GetSeatAvailability", mock.Anything, 7).Return(nil, errors.New("db error"))
 
-	repo.On("GetSeatAvailability", mock.Anything, 1, showDate, "19:00").Return(nil, errors.New("db error"))
-
-	resp, err := service.GetSeatAvailability(context.Background(), 1, dateStr, "19:00")
+	resp, err := service.GetSeatAvailability(context.Background(), 7)
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
@@ -88,7 +116,7 @@ func TestGetSeatAvailability_RepoError(t *testing.T) {
 
 func TestGetSeatByID_Success(t *testing.T) {
 	repo := new(MockSeatAvailabilityRepository)
-	service := NewSeatService(repo)
+	service := NewSeatService(repo, new(MockScreeningRepository), new(MockAuditoriumRepository))
 
 	seat := &models.Seat{ID: 1, SeatNumber: "A1"}
 	repo.On("GetSeatByID", mock.Anything, 1).Return(seat, nil)
@@ -102,7 +130,7 @@ func TestGetSeatByID_Success(t *testing.T) {
 
 func TestGetSeatByID_Error(t *testing.T) {
 	repo := new(MockSeatAvailabilityRepository)
-	service := NewSeatService(repo)
+	service := NewSeatService(repo, new(MockScreeningRepository), new(MockAuditoriumRepository))
 
 	repo.On("GetSeatByID", mock.Anything, 1).Return(nil, errors.New("db error"))
 
@@ -112,3 +140,192 @@ func TestGetSeatByID_Error(t *testing.T) {
 	assert.Nil(t, result)
 	repo.AssertExpectations(t)
 }
+
+func TestUpdateSeatMap_NumbersSeatsSkippingAislesAndGaps(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	req := &models.SeatMapRequest{
+		Rows: []models.SeatMapRowLayout{
+			{Label: "A", Cells: []string{"standard", "aisle", "standard", "standard"}},
+			{Label: "B", Cells: []string{"vip", "aisle", "gap", "vip"}},
+		},
+		Prices: map[string]models.Money{
+			"standard": models.MustParseMoney("50000"),
+			"vip":      models.MustParseMoney("100000"),
+		},
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	repo.On("SaveSeatMap", mock.Anything, 4, req.Rows, mock.Anything).Return(nil)
+
+	seatMap, err := service.UpdateSeatMap(context.Background(), 1, 4, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, seatMap.Columns)
+	assert.Equal(t, 5, seatMap.TotalSeats)
+
+	seats := repo.Calls[0].Arguments.Get(3).([]*models.Seat)
+	numbers := []string{}
+	for _, seat := range seats {
+		numbers = append(numbers, seat.SeatNumber)
+	}
+	assert.Equal(t, []string{"A1", "A2", "A3", "B1", "B2"}, numbers)
+	assert.Equal(t, 2, seats[4].RowNumber)
+	assert.Equal(t, models.MustParseMoney("100000"), seats[4].Price)
+
+	// B2 sits right of the aisle and the gap
+	cell := seatMap.Rows[1].Cells[3]
+	assert.Equal(t, "seat", cell.Type)
+	assert.Equal(t, 2, cell.Row)
+	assert.Equal(t, 4, cell.Column)
+	assert.Equal(t, "B2", cell.Seat.SeatNumber)
+	assert.Equal(t, "gap", seatMap.Rows[1].Cells[2].Type)
+	assert.Nil(t, seatMap.Rows[1].Cells[2].Seat)
+}
+
+func TestUpdateSeatMap_RejectsRaggedRows(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	req := &models.SeatMapRequest{
+		Rows: []models.SeatMapRowLayout{
+			{Label: "A", Cells: []string{"standard", "standard"}},
+			{Label: "B", Cells: []string{"standard"}},
+		},
+		Prices: map[string]models.Money{"standard": models.MustParseMoney("50000")},
+	}
+
+	seatMap, err := service.UpdateSeatMap(context.Background(), 1, 4, req)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSeatMap)
+	assert.Nil(t, seatMap)
+	repo.AssertNotCalled(t, "SaveSeatMap", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateSeatMap_RejectsCollidingSeatNumbers(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), new(MockAuditoriumRepository))
+
+	// Seat 11 of row A and seat 1 of row A1 would both be A11
+	rowA := make([]string, 11)
+	rowA1 := make([]string, 11)
+	for i := range rowA {
+		rowA[i] = "standard"
+		rowA1[i] = "gap"
+	}
+	rowA1[0] = "standard"
+	req := &models.SeatMapRequest{
+		Rows: []models.SeatMapRowLayout{
+			{Label: "A", Cells: rowA},
+			{Label: "A1", Cells: rowA1},
+		},
+		Prices: map[string]models.Money{"standard": models.MustParseMoney("50000")},
+	}
+
+	seatMap, err := service.UpdateSeatMap(context.Background(), 1, 4, req)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSeatMap)
+	assert.Contains(t, err.Error(), "A11")
+	assert.Nil(t, seatMap)
+	repo.AssertNotCalled(t, "SaveSeatMap", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateSeatMap_RequiresPriceForEverySeatType(t *testing.T) {
+	service := NewSeatService(new(MockSeatAvailabilityRepository), new(MockScreeningRepository), new(MockAuditoriumRepository))
+
+	req := &models.SeatMapRequest{
+		Rows:   []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard", "premium"}}},
+		Prices: map[string]models.Money{"standard": models.MustParseMoney("50000")},
+	}
+
+	seatMap, err := service.UpdateSeatMap(context.Background(), 1, 4, req)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSeatMap)
+	assert.Contains(t, err.Error(), "premium")
+	assert.Nil(t, seatMap)
+}
+
+func TestUpdateSeatMap_AuditoriumOfAnotherCinema(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	req := &models.SeatMapRequest{
+		Rows:   []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard"}}},
+		Prices: map[string]models.Money{"standard": models.MustParseMoney("50000")},
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 2}, nil)
+
+	seatMap, err := service.UpdateSeatMap(context.Background(), 1, 4, req)
+
+	assert.NoError(t, err)
+	assert.Nil(t, seatMap)
+	repo.AssertNotCalled(t, "SaveSeatMap", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetSeatMap_FromUploadedLayout(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	layout := []models.SeatMapRowLayout{{Label: "A", Cells: []string{"standard", "aisle", "premium"}}}
+	seats := []*models.Seat{
+		{ID: 10, SeatNumber: "A1", RowNumber: 1, SeatType: "standard"},
+		{ID: 11, SeatNumber: "A2", RowNumber: 1, SeatType: "premium"},
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	repo.On("GetSeatMap", mock.Anything, 4).Return(layout, nil)
+	repo.On("GetSeatsByAuditorium", mock.Anything, 4).Return(seats, nil)
+
+	seatMap, err := service.GetSeatMap(context.Background(), 1, 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, seatMap.Columns)
+	assert.Equal(t, 2, seatMap.TotalSeats)
+	assert.Equal(t, "aisle", seatMap.Rows[0].Cells[1].Type)
+	assert.Equal(t, 11, seatMap.Rows[0].Cells[2].Seat.ID)
+}
+
+func TestGetSeatMap_WithoutLayoutUsesSeatRows(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	seats := []*models.Seat{
+		{ID: 1, SeatNumber: "1A", RowNumber: 1},
+		{ID: 2, SeatNumber: "1B", RowNumber: 1},
+		{ID: 3, SeatNumber: "2A", RowNumber: 2},
+	}
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 4).Return(&models.Auditorium{ID: 4, CinemaID: 1}, nil)
+	repo.On("GetSeatMap", mock.Anything, 4).Return(nil, nil)
+	repo.On("GetSeatsByAuditorium", mock.Anything, 4).Return(seats, nil)
+
+	seatMap, err := service.GetSeatMap(context.Background(), 1, 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, seatMap.Columns)
+	assert.Equal(t, 3, seatMap.TotalSeats)
+	assert.Len(t, seatMap.Rows, 2)
+	assert.Equal(t, "2", seatMap.Rows[1].Label)
+	assert.Equal(t, 3, seatMap.Rows[1].Cells[0].Seat.ID)
+}
+
+func TestGetSeatMap_AuditoriumNotFound(t *testing.T) {
+	repo := new(MockSeatAvailabilityRepository)
+	auditoriumRepo := new(MockAuditoriumRepository)
+	service := NewSeatService(repo, new(MockScreeningRepository), auditoriumRepo)
+
+	auditoriumRepo.On("GetAuditoriumByID", mock.Anything, 99).Return(nil, nil)
+
+	seatMap, err := service.GetSeatMap(context.Background(), 1, 99)
+
+	assert.NoError(t, err)
+	assert.Nil(t, seatMap)
+	repo.AssertNotCalled(t, "GetSeatMap", mock.Anything, mock.Anything)
+}
@@ -2,20 +2,28 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/andre/project-app-bioskop-golang/internal/models"
+	"github.com/andre/project-app-bioskop-golang/internal/tracing"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo     UserRepository
-	emailService EmailSender
-	jwtSecret    string
+	userRepo        UserRepository
+	emailService    EmailSender
+	uow             UnitOfWork
+	jwtSecret       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
 // UserRepository defines the persistence behavior needed by the user domain
@@ -24,27 +32,57 @@ type UserRepository interface {
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetUserCinemaIDs(ctx context.Context, userID int) ([]int, error)
+	UpdateUserRole(ctx context.Context, userID int, role string, cinemaIDs []int) error
 	CreateSession(ctx context.Context, session *models.UserSession) error
-	GetSessionByToken(ctx context.Context, token string) (*models.UserSession, error)
-	DeleteSession(ctx context.Context, token string) error
+	GetSessionByID(ctx context.Context, id int) (*models.UserSession, error)
+	TouchSession(ctx context.Context, id int) error
+	RevokeSession(ctx context.Context, id int) error
+	ListActiveSessions(ctx context.Context, userID int) ([]*models.UserSession, error)
+	RevokeUserSession(ctx context.Context, userID, id int) error
+	RevokeUserSessions(ctx context.Context, userID int) (int, error)
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int) error
 }
 
+// tokenClaims are the claims of an access token: the user ID is the subject
+type tokenClaims struct {
+	SessionID int    `json:"sid"`
+	Role      string `json:"role"`
+	CinemaIDs []int  `json:"cinema_ids,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// refreshTokenBytes is the number of random bytes in a refresh token
+const refreshTokenBytes = 32
+
+// sessionTouchInterval is how often a session's last use is recorded while it makes requests
+const sessionTouchInterval = time.Minute
+
 // EmailSender captures the OTP sending capability; concrete EmailService satisfies this.
 type EmailSender interface {
 	SendOTP(ctx context.Context, userID int, email, username string) error
 }
 
-// NewUserService creates a new UserService
-func NewUserService(userRepo UserRepository, emailService EmailSender, jwtSecret string) *UserService {
+// NewUserService creates a new UserService. Access tokens expire after accessTokenTTL;
+// a session ends when its latest refresh token is not used within refreshTokenTTL.
+func NewUserService(userRepo UserRepository, emailService EmailSender, uow UnitOfWork, jwtSecret string, accessTokenTTL, refreshTokenTTL time.Duration) *UserService {
 	return &UserService{
-		userRepo:     userRepo,
-		emailService: emailService,
-		jwtSecret:    jwtSecret,
+		userRepo:        userRepo,
+		emailService:    emailService,
+		uow:             uow,
+		jwtSecret:       jwtSecret,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
 // RegisterUser registers a new user
 func (s *UserService) RegisterUser(ctx context.Context, req *models.UserRegisterRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer span.End()
+
 	// Check if username already exists
 	existingUser, err := s.userRepo.GetUserByUsername(ctx, req.Username)
 	if err != nil {
@@ -93,8 +131,11 @@ func (s *UserService) RegisterUser(ctx context.Context, req *models.UserRegister
 	return user, nil
 }
 
-// LoginUser authenticates a user and returns a token
-func (s *UserService) LoginUser(ctx context.Context, req *models.UserLoginRequest) (*models.UserLoginResponse, error) {
+// LoginUser authenticates a user and returns tokens of a new session on the client's device
+func (s *UserService) LoginUser(ctx context.Context, req *models.UserLoginRequest, client models.ClientInfo) (*models.UserLoginResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	defer span.End()
+
 	// Get user by username
 	user, err := s.userRepo.GetUserByUsername(ctx, req.Username)
 	if err != nil {
@@ -110,87 +151,270 @@ func (s *UserService) LoginUser(ctx context.Context, req *models.UserLoginReques
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Generate token
-	token, err := s.generateToken(user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// Cinema staff and admins carry their cinemas in the token
+	if models.IsCinemaScopedRole(user.Role) {
+		user.CinemaIDs, err = s.userRepo.GetUserCinemaIDs(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user cinemas: %w", err)
+		}
 	}
 
-	// Save session
-	session := &models.UserSession{
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-	}
-	err = s.userRepo.CreateSession(ctx, session)
+	// Start a session with its first pair of tokens
+	var tokens *models.TokenResponse
+	err = s.uow.WithTx(ctx, func(ctx context.Context) error {
+		session := &models.UserSession{
+			UserID:    user.ID,
+			UserAgent: client.UserAgent,
+			IPAddress: client.IPAddress,
+			ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+		}
+		if err := s.userRepo.CreateSession(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		tokens, err = s.issueTokens(ctx, user, session.ID)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, err
 	}
 
 	return &models.UserLoginResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Token:    token,
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		TokenResponse: *tokens,
 	}, nil
 }
 
-// LogoutUser logs out a user by deleting the session
+// RefreshToken exchanges a refresh token for a new access token and refresh token.
+// A refresh token works once: when a used one comes back it has leaked, so the whole
+// session is revoked and models.ErrRefreshTokenReused returned. It returns
+// models.ErrInvalidRefreshToken for unknown or expired tokens and ended sessions.
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RefreshToken")
+	defer span.End()
+
+	var tokens *models.TokenResponse
+	var sessionID int
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		stored, err := s.userRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return models.ErrInvalidRefreshToken
+		}
+		sessionID = stored.SessionID
+		if stored.UsedAt != nil {
+			return models.ErrRefreshTokenReused
+		}
+		if time.Now().After(stored.ExpiresAt) {
+			return models.ErrInvalidRefreshToken
+		}
+
+		session, err := s.userRepo.GetSessionByID(ctx, stored.SessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil || session.RevokedAt != nil {
+			return models.ErrInvalidRefreshToken
+		}
+
+		if err := s.userRepo.MarkRefreshTokenUsed(ctx, stored.ID); err != nil {
+			return err
+		}
+
+		// Tokens carry the user's current role and cinemas
+		user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil {
+			return models.ErrInvalidRefreshToken
+		}
+		if models.IsCinemaScopedRole(user.Role) {
+			user.CinemaIDs, err = s.userRepo.GetUserCinemaIDs(ctx, user.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get user cinemas: %w", err)
+			}
+		}
+
+		tokens, err = s.issueTokens(ctx, user, session.ID)
+		return err
+	})
+
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		// Revoke outside the transaction above, which rolled back
+		if revokeErr := s.userRepo.RevokeSession(ctx, sessionID); revokeErr != nil {
+			return nil, revokeErr
+		}
+		span.SetAttribute("session.revoked", true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// LogoutUser logs out a user by revoking the session of their access token
 func (s *UserService) LogoutUser(ctx context.Context, token string) error {
-	err := s.userRepo.DeleteSession(ctx, token)
+	ctx, span := tracing.Start(ctx, "UserService.LogoutUser")
+	defer span.End()
+
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepo.RevokeSession(ctx, claims.SessionID)
 	if err != nil {
 		return fmt.Errorf("failed to logout user: %w", err)
 	}
 	return nil
 }
 
-// VerifyToken verifies a JWT token and returns the user ID
-func (s *UserService) VerifyToken(ctx context.Context, tokenString string) (int, error) {
-	// First check if session exists
-	session, err := s.userRepo.GetSessionByToken(ctx, tokenString)
+// ListSessions lists the active sessions of a user, marking the current one
+func (s *UserService) ListSessions(ctx context.Context, userID, currentSessionID int) ([]*models.UserSession, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListSessions")
+	defer span.End()
+
+	sessions, err := s.userRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs a user out of one of their sessions, e.g. on a lost device.
+// It returns models.ErrSessionNotFound if the session is not theirs or already ended.
+func (s *UserService) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeSession")
+	defer span.End()
+
+	return s.userRepo.RevokeUserSession(ctx, userID, sessionID)
+}
+
+// RevokeAllSessions logs a user out everywhere, including the current session,
+// and returns how many sessions ended
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID int) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeAllSessions")
+	defer span.End()
+
+	return s.userRepo.RevokeUserSessions(ctx, userID)
+}
+
+// VerifyToken verifies a JWT token and returns the identity it was issued to
+func (s *UserService) VerifyToken(ctx context.Context, tokenString string) (*models.Identity, error) {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyToken")
+	defer span.End()
+
+	// Parse JWT token
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the session was not logged out or revoked
+	session, err := s.userRepo.GetSessionByID(ctx, claims.SessionID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get session: %w", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-	if session == nil {
-		return 0, errors.New("invalid session")
+	if session == nil || session.RevokedAt != nil {
+		return nil, errors.New("invalid session")
 	}
 
 	// Check if session is expired
 	if time.Now().After(session.ExpiresAt) {
-		return 0, errors.New("session expired")
+		return nil, errors.New("session expired")
 	}
 
-	// Parse JWT token
-	claims := &jwt.RegisteredClaims{}
+	// Record the use at most once per interval; it only orders the session list, so a
+	// failed write does not fail the request
+	if time.Since(session.LastUsedAt) >= sessionTouchInterval {
+		_ = s.userRepo.TouchSession(ctx, session.ID)
+	}
+
+	// Extract user ID from token
+	var id int
+	_, err = fmt.Sscanf(claims.Subject, "%d", &id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id in token: %w", err)
+	}
+
+	return &models.Identity{UserID: id, SessionID: session.ID, Role: claims.Role, CinemaIDs: claims.CinemaIDs}, nil
+}
+
+// parseToken checks the signature and expiry of an access token and returns its claims
+func (s *UserService) parseToken(tokenString string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
+	return claims, nil
+}
 
-	// Extract user ID from token
-	userID, err := token.Claims.GetSubject()
+// issueTokens creates a refresh token for a session and an access token for the user
+func (s *UserService) issueTokens(ctx context.Context, user *models.User, sessionID int) (*models.TokenResponse, error) {
+	refreshToken, err := generateRefreshToken()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get user id from token: %w", err)
+		return nil, err
+	}
+	err = s.userRepo.CreateRefreshToken(ctx, &models.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	var id int
-	_, err = fmt.Sscanf(userID, "%d", &id)
+	token, err := s.generateToken(user, sessionID)
 	if err != nil {
-		return 0, fmt.Errorf("invalid user id in token: %w", err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// generateRefreshToken returns a new random refresh token
+func generateRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return id, nil
+// hashRefreshToken returns the hash a refresh token is stored under
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-// generateToken generates a JWT token
-func (s *UserService) generateToken(userID int) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		Subject:   fmt.Sprintf("%d", userID),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// generateToken generates a JWT access token of a session carrying the user's role and cinemas
+func (s *UserService) generateToken(user *models.User, sessionID int) (string, error) {
+	claims := &tokenClaims{
+		SessionID: sessionID,
+		Role:      user.Role,
+		CinemaIDs: user.CinemaIDs,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprintf("%d", user.ID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -204,12 +428,56 @@ func (s *UserService) generateToken(userID int) (string, error) {
 
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
 	user, err := s.userRepo.GetUserByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
-	if user != nil {
-		user.Password = ""
+	if user == nil {
+		return nil, nil
+	}
+
+	user.Password = ""
+	if models.IsCinemaScopedRole(user.Role) {
+		user.CinemaIDs, err = s.userRepo.GetUserCinemaIDs(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user cinemas: %w", err)
+		}
 	}
 	return user, nil
 }
+
+// ChangeUserRole gives a user a new role. Cinema staff and admins must be assigned to at
+// least one cinema; other roles cannot be. The user is signed out everywhere, so their
+// next login carries the new role. It returns nil, nil if the user does not exist.
+func (s *UserService) ChangeUserRole(ctx context.Context, userID int, req *models.UserRoleRequest) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangeUserRole")
+	defer span.End()
+
+	if models.IsCinemaScopedRole(req.Role) != (len(req.CinemaIDs) > 0) {
+		return nil, models.ErrInvalidCinemaAssignment
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	err = s.userRepo.UpdateUserRole(ctx, userID, req.Role, req.CinemaIDs)
+	if err != nil {
+		if errors.Is(err, models.ErrCinemaNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	user.Password = ""
+	user.Role = req.Role
+	user.CinemaIDs = req.CinemaIDs
+	return user, nil
+}
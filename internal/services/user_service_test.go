@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -45,27 +46,89 @@ func (m *MockUserRepository) GetUserByID(ctx context.Context, id int) (*models.U
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetUserCinemaIDs(ctx context.Context, userID int) ([]int, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, userID int, role string, cinemaIDs []int) error {
+	args := m.Called(ctx, userID, role, cinemaIDs)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) CreateSession(ctx context.Context, session *models.UserSession) error {
 	args := m.Called(ctx, session)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetSessionByToken(ctx context.Context, token string) (*models.UserSession, error) {
-	args := m.Called(ctx, token)
+func (m *MockUserRepository) GetSessionByID(ctx context.Context, id int) (*models.UserSession, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.UserSession), args.Error(1)
 }
 
-func (m *MockUserRepository) DeleteSession(ctx context.Context, token string) error {
+func (m *MockUserRepository) TouchSession(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeSession(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListActiveSessions(ctx context.Context, userID int) ([]*models.UserSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UserSession), args.Error(1)
+}
+
+func (m *MockUserRepository) RevokeUserSession(ctx context.Context, userID, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeUserSessions(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
 	args := m.Called(ctx, token)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkRefreshTokenUsed(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// testClient is the device tests log in from
+var testClient = models.ClientInfo{UserAgent: "Mozilla/5.0 (iPhone)", IPAddress: "203.0.113.7"}
+
+// newTestUserService returns a UserService issuing 15-minute access tokens and 30-day refresh tokens
+func newTestUserService(userRepo UserRepository) *UserService {
+	return NewUserService(userRepo, nil, inlineUnitOfWork{}, "test-secret", 15*time.Minute, 720*time.Hour)
+}
+
 func TestRegisterUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	req := &models.UserRegisterRequest{Username: "testuser", Email: "test@example.com", Password: "password123"}
 
@@ -87,7 +150,7 @@ func TestRegisterUser_Success(t *testing.T) {
 
 func TestRegisterUser_UsernameExists(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	req := &models.UserRegisterRequest{Username: "existinguser", Email: "test@example.com", Password: "password123"}
 	existingUser := &models.User{ID: 1, Username: "existinguser"}
@@ -104,7 +167,7 @@ func TestRegisterUser_UsernameExists(t *testing.T) {
 
 func TestRegisterUser_EmailExists(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	req := &models.UserRegisterRequest{Username: "testuser", Email: "existing@example.com", Password: "password123"}
 	existingUser := &models.User{ID: 1, Email: "existing@example.com"}
@@ -122,7 +185,7 @@ func TestRegisterUser_EmailExists(t *testing.T) {
 
 func TestLoginUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	existingUser := &models.User{ID: 1, Username: "testuser", Email: "test@example.com", Password: string(hashedPassword)}
@@ -130,26 +193,59 @@ func TestLoginUser_Success(t *testing.T) {
 	req := &models.UserLoginRequest{Username: "testuser", Password: "password123"}
 
 	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(existingUser, nil)
-	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*models.UserSession")).Return(nil)
+	mockRepo.On("CreateSession", mock.Anything, mock.MatchedBy(func(session *models.UserSession) bool {
+		return session.UserAgent == testClient.UserAgent && session.IPAddress == testClient.IPAddress
+	})).Return(nil).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.UserSession).ID = 7 })
+	mockRepo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.SessionID == 7 && len(token.TokenHash) == 64
+	})).Return(nil)
 
-	response, err := service.LoginUser(context.Background(), req)
+	response, err := service.LoginUser(context.Background(), req, testClient)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, response)
 	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, 900, response.ExpiresIn)
 	assert.Equal(t, "testuser", response.Username)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestLoginUser_CinemaAdminGetsCinemasInToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	existingUser := &models.User{ID: 2, Username: "manager", Password: string(hashedPassword), Role: models.RoleCinemaAdmin}
+
+	mockRepo.On("GetUserByUsername", mock.Anything, "manager").Return(existingUser, nil)
+	mockRepo.On("GetUserCinemaIDs", mock.Anything, 2).Return([]int{1}, nil)
+	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*models.UserSession")).Return(nil).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.UserSession).ID = 7 })
+	mockRepo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+	response, err := service.LoginUser(context.Background(), &models.UserLoginRequest{Username: "manager", Password: "password123"}, testClient)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleCinemaAdmin, response.Role)
+
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(&models.UserSession{ID: 7, UserID: 2, LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	identity, err := service.VerifyToken(context.Background(), response.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, identity.CinemaIDs)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestLoginUser_InvalidCredentials(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	req := &models.UserLoginRequest{Username: "nonexistent", Password: "password123"}
 
 	mockRepo.On("GetUserByUsername", mock.Anything, "nonexistent").Return(nil, nil)
 
-	response, err := service.LoginUser(context.Background(), req)
+	response, err := service.LoginUser(context.Background(), req, testClient)
 
 	assert.Error(t, err)
 	assert.Nil(t, response)
@@ -159,7 +255,7 @@ func TestLoginUser_InvalidCredentials(t *testing.T) {
 
 func TestLoginUser_WrongPassword(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
 	existingUser := &models.User{ID: 1, Username: "testuser", Password: string(hashedPassword)}
@@ -168,7 +264,7 @@ func TestLoginUser_WrongPassword(t *testing.T) {
 
 	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(existingUser, nil)
 
-	response, err := service.LoginUser(context.Background(), req)
+	response, err := service.LoginUser(context.Background(), req, testClient)
 
 	assert.Error(t, err)
 	assert.Nil(t, response)
@@ -178,40 +274,224 @@ func TestLoginUser_WrongPassword(t *testing.T) {
 
 func TestVerifyToken_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
-	token, _ := service.generateToken(1)
-	session := &models.UserSession{UserID: 1, Token: token, ExpiresAt: time.Now().Add(24 * time.Hour)}
+	token, _ := service.generateToken(&models.User{ID: 1, Role: models.RoleCustomer}, 7)
+	session := &models.UserSession{ID: 7, UserID: 1, LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(24 * time.Hour)}
 
-	mockRepo.On("GetSessionByToken", mock.Anything, token).Return(session, nil)
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(session, nil)
 
-	userID, err := service.VerifyToken(context.Background(), token)
+	identity, err := service.VerifyToken(context.Background(), token)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 1, userID)
+	assert.Equal(t, 1, identity.UserID)
+	assert.Equal(t, 7, identity.SessionID)
+	assert.Equal(t, models.RoleCustomer, identity.Role)
 	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "TouchSession", mock.Anything, mock.Anything)
 }
 
-func TestVerifyToken_InvalidToken(t *testing.T) {
+func TestVerifyToken_RecordsSessionUse(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
-	invalidToken := "invalid.token"
-	mockRepo.On("GetSessionByToken", mock.Anything, invalidToken).Return(nil, nil)
+	token, _ := service.generateToken(&models.User{ID: 1, Role: models.RoleCustomer}, 7)
+	session := &models.UserSession{ID: 7, UserID: 1, LastUsedAt: time.Now().Add(-time.Hour), ExpiresAt: time.Now().Add(24 * time.Hour)}
 
-	userID, err := service.VerifyToken(context.Background(), invalidToken)
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(session, nil)
+	mockRepo.On("TouchSession", mock.Anything, 7).Return(errors.New("connection reset"))
+
+	identity, err := service.VerifyToken(context.Background(), token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, identity.SessionID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVerifyToken_CarriesRoleAndCinemas(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	token, _ := service.generateToken(&models.User{ID: 4, Role: models.RoleCinemaStaff, CinemaIDs: []int{2, 3}}, 7)
+	session := &models.UserSession{ID: 7, UserID: 4, LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(24 * time.Hour)}
+
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(session, nil)
+
+	identity, err := service.VerifyToken(context.Background(), token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleCinemaStaff, identity.Role)
+	assert.Equal(t, []int{2, 3}, identity.CinemaIDs)
+	assert.True(t, identity.CanManageCinema(3))
+	assert.False(t, identity.CanManageCinema(1))
+}
+
+func TestVerifyToken_InvalidToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	identity, err := service.VerifyToken(context.Background(), "invalid.token")
 
 	assert.Error(t, err)
-	assert.Equal(t, 0, userID)
+	assert.Nil(t, identity)
+	mockRepo.AssertNotCalled(t, "GetSessionByID", mock.Anything, mock.Anything)
+}
+
+func TestVerifyToken_RevokedSession(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	token, _ := service.generateToken(&models.User{ID: 1, Role: models.RoleCustomer}, 7)
+	revokedAt := time.Now()
+	session := &models.UserSession{ID: 7, UserID: 1, ExpiresAt: time.Now().Add(24 * time.Hour), RevokedAt: &revokedAt}
+
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(session, nil)
+
+	identity, err := service.VerifyToken(context.Background(), token)
+
+	assert.EqualError(t, err, "invalid session")
+	assert.Nil(t, identity)
+}
+
+func TestVerifyToken_ExpiredAccessToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil, inlineUnitOfWork{}, "test-secret", -time.Minute, 720*time.Hour)
+
+	token, _ := service.generateToken(&models.User{ID: 1, Role: models.RoleCustomer}, 7)
+
+	identity, err := service.VerifyToken(context.Background(), token)
+
+	assert.EqualError(t, err, "invalid token")
+	assert.Nil(t, identity)
+}
+
+func TestRefreshToken_RotatesTokens(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	stored := &models.RefreshToken{ID: 5, SessionID: 7, ExpiresAt: time.Now().Add(time.Hour)}
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashRefreshToken("old-token")).Return(stored, nil)
+	mockRepo.On("GetSessionByID", mock.Anything, 7).Return(&models.UserSession{ID: 7, UserID: 4, LastUsedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	mockRepo.On("MarkRefreshTokenUsed", mock.Anything, 5).Return(nil)
+	mockRepo.On("GetUserByID", mock.Anything, 4).Return(&models.User{ID: 4, Role: models.RoleCinemaStaff}, nil)
+	mockRepo.On("GetUserCinemaIDs", mock.Anything, 4).Return([]int{2}, nil)
+	mockRepo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.SessionID == 7 && token.TokenHash != hashRefreshToken("old-token")
+	})).Return(nil)
+
+	tokens, err := service.RefreshToken(context.Background(), "old-token")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "old-token", tokens.RefreshToken)
+	claims, err := service.parseToken(tokens.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, claims.SessionID)
+	assert.Equal(t, []int{2}, claims.CinemaIDs)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_ReuseRevokesSession(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	usedAt := time.Now().Add(-time.Minute)
+	stored := &models.RefreshToken{ID: 5, SessionID: 7, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashRefreshToken("stolen-token")).Return(stored, nil)
+	mockRepo.On("RevokeSession", mock.Anything, 7).Return(nil)
+
+	tokens, err := service.RefreshToken(context.Background(), "stolen-token")
+
+	assert.ErrorIs(t, err, models.ErrRefreshTokenReused)
+	assert.Nil(t, tokens)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestRefreshToken_Invalid(t *testing.T) {
+	revokedAt := time.Now()
+	tests := []struct {
+		name    string
+		stored  *models.RefreshToken
+		session *models.UserSession
+	}{
+		{"unknown token", nil, nil},
+		{"expired token", &models.RefreshToken{ID: 5, SessionID: 7, ExpiresAt: time.Now().Add(-time.Minute)}, nil},
+		{"revoked session", &models.RefreshToken{ID: 5, SessionID: 7, ExpiresAt: time.Now().Add(time.Hour)}, &models.UserSession{ID: 7, RevokedAt: &revokedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := newTestUserService(mockRepo)
+
+			if tt.stored == nil {
+				mockRepo.On("GetRefreshTokenByHash", mock.Anything, mock.Anything).Return(nil, nil)
+			} else {
+				mockRepo.On("GetRefreshTokenByHash", mock.Anything, mock.Anything).Return(tt.stored, nil)
+			}
+			if tt.session != nil {
+				mockRepo.On("GetSessionByID", mock.Anything, 7).Return(tt.session, nil)
+			}
+
+			tokens, err := service.RefreshToken(context.Background(), "some-token")
+
+			assert.ErrorIs(t, err, models.ErrInvalidRefreshToken)
+			assert.Nil(t, tokens)
+			mockRepo.AssertNotCalled(t, "MarkRefreshTokenUsed", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestListSessions_MarksCurrent(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	mockRepo.On("ListActiveSessions", mock.Anything, 1).Return([]*models.UserSession{
+		{ID: 9, UserID: 1, UserAgent: "curl/8.0"},
+		{ID: 7, UserID: 1, UserAgent: testClient.UserAgent},
+	}, nil)
+
+	sessions, err := service.ListSessions(context.Background(), 1, 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRevokeSession_NotOwned(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	mockRepo.On("RevokeUserSession", mock.Anything, 1, 12).Return(models.ErrSessionNotFound)
+
+	err := service.RevokeSession(context.Background(), 1, 12)
+
+	assert.ErrorIs(t, err, models.ErrSessionNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRevokeAllSessions_Success(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	mockRepo.On("RevokeUserSessions", mock.Anything, 1).Return(3, nil)
+
+	revoked, err := service.RevokeAllSessions(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, revoked)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestLogoutUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
-	token := "valid.token"
-	mockRepo.On("DeleteSession", mock.Anything, token).Return(nil)
+	token, _ := service.generateToken(&models.User{ID: 1, Role: models.RoleCustomer}, 7)
+	mockRepo.On("RevokeSession", mock.Anything, 7).Return(nil)
 
 	err := service.LogoutUser(context.Background(), token)
 
@@ -221,7 +501,7 @@ func TestLogoutUser_Success(t *testing.T) {
 
 func TestGetUserByID_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	expectedUser := &models.User{ID: 1, Username: "testuser", Email: "test@example.com", Password: "hidden"}
 	mockRepo.On("GetUserByID", mock.Anything, 1).Return(expectedUser, nil)
@@ -237,7 +517,7 @@ func TestGetUserByID_Success(t *testing.T) {
 
 func TestGetUserByID_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, nil, "test-secret")
+	service := newTestUserService(mockRepo)
 
 	mockRepo.On("GetUserByID", mock.Anything, 999).Return(nil, nil)
 
@@ -247,3 +527,45 @@ func TestGetUserByID_NotFound(t *testing.T) {
 	assert.Nil(t, user)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestChangeUserRole_AssignsCinemaStaff(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	mockRepo.On("GetUserByID", mock.Anything, 4).Return(&models.User{ID: 4, Username: "usher", Password: "hidden", Role: models.RoleCustomer}, nil)
+	mockRepo.On("UpdateUserRole", mock.Anything, 4, models.RoleCinemaStaff, []int{2}).Return(nil)
+
+	user, err := service.ChangeUserRole(context.Background(), 4, &models.UserRoleRequest{Role: models.RoleCinemaStaff, CinemaIDs: []int{2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleCinemaStaff, user.Role)
+	assert.Equal(t, []int{2}, user.CinemaIDs)
+	assert.Empty(t, user.Password)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeUserRole_InvalidCinemaAssignment(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	_, err := service.ChangeUserRole(context.Background(), 4, &models.UserRoleRequest{Role: models.RoleCinemaAdmin})
+	assert.ErrorIs(t, err, models.ErrInvalidCinemaAssignment)
+
+	_, err = service.ChangeUserRole(context.Background(), 4, &models.UserRoleRequest{Role: models.RoleSuperAdmin, CinemaIDs: []int{1}})
+	assert.ErrorIs(t, err, models.ErrInvalidCinemaAssignment)
+
+	mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChangeUserRole_UserNotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := newTestUserService(mockRepo)
+
+	mockRepo.On("GetUserByID", mock.Anything, 999).Return(nil, nil)
+
+	user, err := service.ChangeUserRole(context.Background(), 999, &models.UserRoleRequest{Role: models.RoleSuperAdmin})
+
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
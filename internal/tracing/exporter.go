@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Exporters
+const (
+	ExporterNone   = "none"   // discard spans
+	ExporterStdout = "stdout" // write spans to standard output
+	ExporterFile   = "file"   // append spans to a file
+)
+
+// NewExporter returns the exporter of the given kind; path is the file the file
+// exporter appends to
+func NewExporter(kind, path string) (Exporter, error) {
+	switch kind {
+	case ExporterNone:
+		return discardExporter{}, nil
+	case ExporterStdout:
+		return NewJSONExporter(os.Stdout), nil
+	case ExporterFile:
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		return NewJSONExporter(file), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", kind)
+	}
+}
+
+// JSONExporter writes each span as a line of JSON
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONExporter creates a new JSONExporter writing to w; Close closes w if it is a file
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// ExportSpan writes span; spans that cannot be written are dropped
+func (e *JSONExporter) ExportSpan(span *SpanData) {
+	line, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(line, '\n'))
+}
+
+// Close closes the file the exporter writes to
+func (e *JSONExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if file, ok := e.w.(*os.File); ok && file != os.Stdout && file != os.Stderr {
+		return file.Close()
+	}
+	return nil
+}
+
+// discardExporter drops every span
+type discardExporter struct{}
+
+func (discardExporter) ExportSpan(*SpanData) {}
+
+func (discardExporter) Close() error { return nil }
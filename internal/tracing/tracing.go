@@ -0,0 +1,244 @@
+// Package tracing records spans, the timed operations a request goes through, in the style
+// of OpenTelemetry: spans are propagated through context.Context, across services with the
+// W3C traceparent header, and written out by an exporter, without depending on a collector
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TraceID identifies a trace, all the spans of one request
+type TraceID [16]byte
+
+// String returns the trace ID as 32 hex digits
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+// String returns the span ID as 16 hex digits
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is what identifies a span to its children, in this process or another
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // whether the trace's spans are exported
+}
+
+// IsValid reports whether the span context has both a trace and a span ID
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// SpanData is an ended span as exporters receive it
+type SpanData struct {
+	Name         string         `json:"name"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	StartTime    time.Time      `json:"start_time"`
+	EndTime      time.Time      `json:"end_time"`
+	DurationMS   float64        `json:"duration_ms"`
+	Status       string         `json:"status"` // ok or error
+	Error        string         `json:"error,omitempty"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+// Span is a timed operation; End it when the operation is over
+type Span struct {
+	tracer  *Tracer
+	context SpanContext
+	mu      sync.Mutex
+	data    SpanData
+	ended   bool
+}
+
+// SpanContext returns the identity of the span
+func (s *Span) SpanContext() SpanContext {
+	return s.context
+}
+
+// SetName renames the span, e.g. once the route of a request is known
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttribute sets an attribute describing the operation
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// RecordError marks the operation failed with err; a nil err is ignored
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = "error"
+	s.data.Error = err.Error()
+}
+
+// End ends the span and exports it if its trace is sampled; later calls do nothing
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	s.data.DurationMS = float64(s.data.EndTime.Sub(s.data.StartTime).Microseconds()) / 1000
+	data := s.data
+	s.mu.Unlock()
+
+	if s.context.Sampled {
+		s.tracer.exporter.ExportSpan(&data)
+	}
+}
+
+// Exporter writes out ended spans
+type Exporter interface {
+	ExportSpan(span *SpanData)
+	Close() error
+}
+
+// Tracer starts spans and hands them to its exporter when they end
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a new Tracer; a nil exporter discards spans, which still carry
+// trace IDs for logs and propagation
+func NewTracer(exporter Exporter) *Tracer {
+	if exporter == nil {
+		exporter = discardExporter{}
+	}
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts a span as a child of the span in ctx, or of the remote span ctx was
+// propagated from, or else as the root of a new trace. The returned context carries it.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, ok := parentSpanContext(ctx)
+	sc := SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+	if !ok {
+		sc = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	sc.SpanID = newSpanID()
+
+	span := &Span{
+		tracer:  t,
+		context: sc,
+		data: SpanData{
+			Name:      name,
+			TraceID:   sc.TraceID.String(),
+			SpanID:    sc.SpanID.String(),
+			StartTime: time.Now(),
+			Status:    "ok",
+		},
+	}
+	if ok {
+		span.data.ParentSpanID = parent.SpanID.String()
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+var global atomic.Pointer[Tracer]
+
+func init() {
+	global.Store(NewTracer(nil))
+}
+
+// SetTracer sets the tracer Start uses
+func SetTracer(t *Tracer) {
+	global.Store(t)
+}
+
+// Start starts a span with the tracer set by SetTracer; see Tracer.Start
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return global.Load().Start(ctx, name)
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// remoteKey is the context key of a span context propagated from another service
+type remoteKey struct{}
+
+// SpanFromContext returns the current span of ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a context whose spans continue the trace of sc,
+// a span of another service
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func parentSpanContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.context, true
+	}
+	if sc, ok := ctx.Value(remoteKey{}).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+	return SpanContext{}, false
+}
+
+// Logger returns logger with the trace and span IDs of ctx's span as fields, so log
+// lines can be matched to traces
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return logger
+	}
+	return logger.With(
+		zap.String("trace_id", span.context.TraceID.String()),
+		zap.String("span_id", span.context.SpanID.String()),
+	)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		putUint64(id[:8], rand.Uint64())
+		putUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		putUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+}
@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingExporter keeps the spans it is given
+type recordingExporter struct {
+	spans []*SpanData
+}
+
+func (e *recordingExporter) ExportSpan(span *SpanData) {
+	e.spans = append(e.spans, span)
+}
+
+func (e *recordingExporter) Close() error { return nil }
+
+func TestParseTraceparent_Valid(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+}
+
+func TestParseTraceparent_Invalid(t *testing.T) {
+	headers := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",          // missing flags
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",       // zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",       // zero span ID
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",       // uppercase
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",       // invalid version
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", // extra field in version 00
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",        // short trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g",       // invalid flags
+	}
+	for _, header := range headers {
+		_, ok := ParseTraceparent(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestTracer_Start_ChildSpansShareTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "POST /api/booking")
+	_, child := tracer.Start(ctx, "BookingService.CreateBooking")
+	child.SetAttribute("seats", 2)
+	child.RecordError(errors.New("seat already booked"))
+	child.End()
+	root.End()
+	root.End()
+
+	require.Len(t, exporter.spans, 2)
+	childData, rootData := exporter.spans[0], exporter.spans[1]
+	assert.Equal(t, rootData.TraceID, childData.TraceID)
+	assert.Equal(t, rootData.SpanID, childData.ParentSpanID)
+	assert.Empty(t, rootData.ParentSpanID)
+	assert.Equal(t, "error", childData.Status)
+	assert.Equal(t, "seat already booked", childData.Error)
+	assert.Equal(t, 2, childData.Attributes["seats"])
+	assert.Equal(t, "ok", rootData.Status)
+}
+
+func TestTracer_Start_ContinuesRemoteTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter)
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "GET /api/movies")
+	span.End()
+
+	require.Len(t, exporter.spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exporter.spans[0].TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", exporter.spans[0].ParentSpanID)
+}
+
+func TestTracer_Start_UnsampledRemoteTraceIsNotExported(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(exporter)
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "GET /api/movies")
+	span.End()
+
+	assert.Empty(t, exporter.spans)
+	assert.Equal(t, remote.TraceID, span.SpanContext().TraceID)
+}
+
+func TestJSONExporter_WritesOneLinePerSpan(t *testing.T) {
+	var out bytes.Buffer
+	tracer := NewTracer(NewJSONExporter(&out))
+
+	_, first := tracer.Start(context.Background(), "first")
+	first.End()
+	_, second := tracer.Start(context.Background(), "second")
+	second.End()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var span SpanData
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &span))
+	assert.Equal(t, "second", span.Name)
+	assert.Len(t, span.TraceID, 32)
+	assert.Len(t, span.SpanID, 16)
+}
+
+func TestNewExporter_UnknownKind(t *testing.T) {
+	_, err := NewExporter("jaeger", "")
+
+	assert.ErrorContains(t, err, "unknown trace exporter")
+}
+
+func TestLogger_AddsTraceFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx, span := NewTracer(nil).Start(context.Background(), "request")
+
+	Logger(ctx, zap.New(core)).Info("handled")
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, span.SpanContext().TraceID.String(), fields["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID.String(), fields["span_id"])
+}
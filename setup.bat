@@ -7,10 +7,6 @@ REM Create database
 echo Creating database...
 psql -U postgres -c "CREATE DATABASE bioskop_db;"
 
-REM Apply schema
-echo Applying database schema...
-psql -U postgres -d bioskop_db -f db/schema.sql
-
 REM Download dependencies
 echo Downloading Go dependencies...
 go mod download
@@ -18,8 +14,13 @@ go mod download
 REM Build the application
 echo Building the application...
 go build -o bin/app.exe cmd/main/main.go
+go build -o bin/migrate.exe cmd/migrate/main.go
 go build -o bin/seeder.exe cmd/seeder/main.go
 
+REM Apply migrations
+echo Applying database migrations...
+bin/migrate.exe up
+
 REM Seed initial data
 echo Seeding initial data...
 bin/seeder.exe
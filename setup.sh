@@ -7,10 +7,6 @@ echo "Setting up Cinema Booking System..."
 echo "Creating database..."
 createdb bioskop_db
 
-# Apply schema
-echo "Applying database schema..."
-psql bioskop_db < db/schema.sql
-
 # Download dependencies
 echo "Downloading Go dependencies..."
 go mod download
@@ -18,8 +14,13 @@ go mod download
 # Build the application
 echo "Building the application..."
 go build -o bin/app cmd/main/main.go
+go build -o bin/migrate cmd/migrate/main.go
 go build -o bin/seeder cmd/seeder/main.go
 
+# Apply migrations
+echo "Applying database migrations..."
+./bin/migrate up
+
 # Seed initial data
 echo "Seeding initial data..."
 ./bin/seeder